@@ -25,6 +25,7 @@ require (
 	golang.org/x/vuln v1.1.4 // indirect
 	honnef.co/go/tools v0.7.0-0.dev.0.20251022135355-8273271481d0 // indirect
 	mvdan.cc/gofumpt v0.9.2 // indirect
+	mvdan.cc/xurls/v2 v2.6.0 // indirect
 )
 
 replace golang.org/x/tools/gopls => ./gopls
@@ -2,11 +2,15 @@ package cache
 
 import (
 	"context"
+	"errors"
 	"go/parser"
 	"go/token"
+	"math"
+	"time"
 
 	"golang.org/x/tools/gopls/internal/cache/parsego"
 	"golang.org/x/tools/gopls/internal/file"
+	"golang.org/x/tools/gopls/internal/protocol"
 )
 
 // ParseGoImpl is an exported version of the internal parseGoImpl function.
@@ -38,3 +42,138 @@ import (
 func ParseGoImpl(ctx context.Context, fset *token.FileSet, fh file.Handle, mode parser.Mode, purgeFuncBodies bool) (*parsego.File, error) {
 	return parseGoImpl(ctx, fset, fh, mode, purgeFuncBodies)
 }
+
+// SpeculativeSequenceID is the sequence ID of the snapshots returned by
+// [Snapshot.CloneWithOverlays].
+const SpeculativeSequenceID = math.MaxUint64
+
+// CloneWithOverlays returns a speculative copy of the snapshot in which the
+// given contents replace the corresponding files, as if they had been opened
+// as unsaved overlays.
+//
+// The returned snapshot is NOT installed in the view: the session, its
+// overlays and all other snapshots are left untouched, so the edits are
+// discarded as soon as the snapshot is released. Derived data (metadata,
+// type-checked packages, diagnostics) for the modified files is recomputed
+// lazily, while everything else is shared with the receiver through the
+// memoization store.
+//
+// This is intended for external tools (such as LLM/MCP bridges) that need to
+// answer "what-if" questions, e.g. whether a proposed edit type-checks,
+// without writing to disk.
+//
+// The returned snapshot has sequence ID [SpeculativeSequenceID], which no
+// snapshot installed in a view ever takes.
+//
+// The caller must call the returned release function when finished with the
+// snapshot.
+func (s *Snapshot) CloneWithOverlays(ctx context.Context, contents map[protocol.DocumentURI][]byte) (*Snapshot, func()) {
+	// Mirror Session.invalidateViewLocked: don't clone before the view has
+	// finished its initial workspace load.
+	s.AwaitInitialized(ctx)
+
+	now := time.Now()
+	changed := StateChange{Files: make(map[protocol.DocumentURI]file.Handle, len(contents))}
+	for uri, content := range contents {
+		// Files that don't exist yet are created, so that the new file is
+		// added to its package instead of being treated as an edit.
+		action := file.Change
+		if fh, err := s.ReadFile(ctx, uri); err == nil {
+			if _, err := fh.Content(); err != nil {
+				action = file.Create
+			}
+		}
+		changed.Files[uri] = &overlay{
+			uri:     uri,
+			content: content,
+			modTime: now,
+			hash:    file.HashOf(content),
+		}
+		changed.Modifications = append(changed.Modifications, file.Modification{
+			URI:    uri,
+			Action: action,
+			Text:   content,
+		})
+	}
+
+	result, _ := s.clone(ctx, s.view.baseCtx, changed, func() {})
+	// Don't take the sequence ID of the view's next snapshot.
+	result.sequenceID = SpeculativeSequenceID
+	release := func() {
+		// Nothing supersedes a speculative snapshot, so cancel its
+		// background work explicitly.
+		result.cancel()
+		result.decref()
+	}
+	return result, release
+}
+
+// PackageStats reports the number of packages whose metadata is loaded in
+// the snapshot, and how many of them have a type-checked package currently
+// held in memory.
+//
+// This is intended for external tools (such as LLM/MCP bridges) that report
+// on the state of the workspace.
+func (s *Snapshot) PackageStats() (loaded, typeChecked int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.meta != nil {
+		loaded = len(s.meta.Packages)
+	}
+	for _, ph := range s.packages.All() {
+		if ph.state >= validPackage {
+			typeChecked++
+		}
+	}
+	return loaded, typeChecked
+}
+
+// IsFiltered reports whether uri is excluded by the view's directoryFilters,
+// including the implicit filter of a module cache inside the view's folder.
+//
+// This is intended for external tools (such as LLM/MCP bridges) that walk
+// the workspace themselves.
+func (v *View) IsFiltered(uri protocol.DocumentURI) bool {
+	return v.filterFunc()(uri)
+}
+
+// Initialized reports, without blocking, whether the view's initial
+// workspace load has finished (see [Snapshot.AwaitInitialized]).
+func (v *View) Initialized() bool {
+	select {
+	case <-v.initialWorkspaceLoad:
+		return true
+	default:
+		return false
+	}
+}
+
+// LoadPackagePaths loads the packages of the given import paths that are
+// not yet in the snapshot's metadata graph, with "go list", and adds them
+// to it.
+//
+// The initial workspace load only loads the workspace packages and their
+// dependencies. This is intended for external tools (such as LLM/MCP
+// bridges) that resolve references to other packages, e.g. a package of a
+// dependency module, or of the standard library, that no workspace package
+// imports. Paths that do not denote a package are ignored.
+func (s *Snapshot) LoadPackagePaths(ctx context.Context, paths ...string) error {
+	if err := s.awaitLoaded(ctx); err != nil {
+		return err
+	}
+	md := s.MetadataGraph()
+	var scopes []loadScope
+	for _, path := range paths {
+		if len(md.ForPackagePath[PackagePath(path)]) == 0 {
+			scopes = append(scopes, packageLoadScope(path))
+		}
+	}
+	if len(scopes) == 0 {
+		return nil
+	}
+	err := s.load(ctx, NoNetwork, scopes...)
+	if errors.Is(err, errNoPackages) {
+		return nil
+	}
+	return err
+}
@@ -0,0 +1,49 @@
+package debug
+
+import (
+	"context"
+	"net/http"
+
+	"golang.org/x/tools/internal/event/core"
+	"golang.org/x/tools/internal/event/export/metric"
+	"golang.org/x/tools/internal/event/label"
+)
+
+// This file exports a few debug internals for external tools (such as
+// LLM/MCP bridges) that record their own metrics and export them next to
+// the gopls metrics.
+//
+// These wrappers exist to avoid modifying the instance exporter. When
+// cherry-picking changes from upstream gopls, this file should be reviewed
+// but typically will not need changes.
+
+// AddMetrics registers additional metrics, aggregated from the metric events
+// delivered to this instance and exported on its /metrics/ page along with
+// the gopls metrics.
+//
+// AddMetrics must be called before the instance is used.
+func (i *Instance) AddMetrics(register func(*metric.Config)) {
+	if i.prometheus == nil {
+		return
+	}
+	var metrics metric.Config
+	register(&metrics)
+	extra := metrics.Exporter(func(ctx context.Context, ev core.Event, lm label.Map) context.Context {
+		return i.prometheus.ProcessEvent(ctx, ev, lm)
+	})
+	exporter := i.exporter
+	i.exporter = func(ctx context.Context, ev core.Event, lm label.Map) context.Context {
+		ctx = extra(ctx, ev, lm)
+		return exporter(ctx, ev, lm)
+	}
+}
+
+// ServeMetrics serves the metrics of this instance in the Prometheus text
+// format, like the /metrics/ page of the debug server.
+func (i *Instance) ServeMetrics(w http.ResponseWriter, r *http.Request) {
+	if i.prometheus == nil {
+		http.NotFound(w, r)
+		return
+	}
+	i.prometheus.Serve(w, r)
+}
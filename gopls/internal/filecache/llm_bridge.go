@@ -0,0 +1,41 @@
+package filecache
+
+import (
+	"io/fs"
+	"path/filepath"
+)
+
+// This file exports a few filecache internals for external tools (such as
+// LLM/MCP bridges) that report on the state of the cache.
+//
+// These wrappers exist to avoid modifying the internal cache logic. When
+// cherry-picking changes from upstream gopls, this file should be reviewed
+// but typically will not need changes.
+
+// Usage reports the directory of the persistent cache of this executable,
+// and the number and total size of the files it currently holds. It walks
+// the directory, so it should not be called frequently.
+func Usage() (dir string, files int, bytes int64, err error) {
+	dir, err = getCacheDir()
+	if err != nil {
+		return "", 0, 0, err
+	}
+	err = filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return nil // ignore errors (e.g. files deleted by gc)
+		}
+		if d.Type().IsRegular() {
+			if info, err := d.Info(); err == nil {
+				files++
+				bytes += info.Size()
+			}
+		}
+		return nil
+	})
+	return dir, files, bytes, err
+}
+
+// Budget returns the current soft limit on disk usage (see [SetBudget]).
+func Budget() int64 {
+	return SetBudget(0)
+}
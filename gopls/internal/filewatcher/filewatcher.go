@@ -40,6 +40,11 @@ type Watcher struct {
 	// gopls directoryFilters or similar rules.
 	skipDirFunc func(dirPath string) bool
 
+	// watchFileFunc, if non-nil, reports whether a file at the given
+	// absolute path should be watched although skipFile would skip it,
+	// e.g. a configuration file.
+	watchFileFunc func(filePath string) bool
+
 	mu sync.Mutex // guards all fields below
 
 	// in is the queue of fsnotify events waiting to be processed.
@@ -64,6 +69,13 @@ func WithSkipDir(fn func(dirPath string) bool) Option {
 	return func(w *Watcher) { w.skipDirFunc = fn }
 }
 
+// WithWatchFile sets a function that reports whether a file at the given
+// absolute path should be watched even though the built-in [skipFile]
+// heuristic would skip it.
+func WithWatchFile(fn func(filePath string) bool) Option {
+	return func(w *Watcher) { w.watchFileFunc = fn }
+}
+
 // New creates a new file watcher and starts its event-handling loop. The
 // [Watcher.Close] method must be called to clean up resources.
 //
@@ -309,6 +321,12 @@ func skipFile(fileName string) bool {
 	}
 }
 
+// skipFile reports whether the file at the given path should be skipped,
+// consulting watchFileFunc for the files skipped by the built-in heuristic.
+func (w *Watcher) skipFile(path string) bool {
+	return skipFile(filepath.Base(path)) && (w.watchFileFunc == nil || !w.watchFileFunc(path))
+}
+
 // WatchDir walks through the directory and all its subdirectories, adding
 // them to the watcher.
 func (w *Watcher) WatchDir(path string) error {
@@ -356,7 +374,7 @@ func (w *Watcher) convertEvent(event fsnotify.Event) (_ protocol.FileEvent, isDi
 	if isDir && w.skipDirFunc != nil && w.skipDirFunc(event.Name) {
 		return protocol.FileEvent{}, true
 	}
-	if !isDir && skipFile(filepath.Base(event.Name)) {
+	if !isDir && w.skipFile(event.Name) {
 		return protocol.FileEvent{}, false
 	}
 
@@ -495,7 +513,7 @@ func (w *Watcher) walkDir(path string, isDir bool, errHandler func(error), fn fu
 		if e.IsDir() && w.skipDirFunc != nil && w.skipDirFunc(childPath) {
 			continue
 		}
-		if !e.IsDir() && skipFile(e.Name()) {
+		if !e.IsDir() && w.skipFile(childPath) {
 			continue
 		}
 
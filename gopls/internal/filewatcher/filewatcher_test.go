@@ -579,6 +579,60 @@ func TestSkipDirFunc(t *testing.T) {
 	}
 }
 
+func TestWatchFileFunc(t *testing.T) {
+	switch runtime.GOOS {
+	case "darwin", "linux", "windows":
+	default:
+		t.Skip("unsupported OS")
+	}
+
+	root := t.TempDir()
+
+	events := make(chan protocol.FileEvent, 10)
+	eventsHandler := func(es []protocol.FileEvent) {
+		for _, e := range es {
+			events <- e
+		}
+	}
+	errHandler := func(err error) {
+		t.Errorf("error from watcher: %v", err)
+	}
+	watchFileFunc := func(filePath string) bool {
+		return filepath.Base(filePath) == "config.json"
+	}
+
+	w, err := filewatcher.New(50*time.Millisecond, nil, eventsHandler, errHandler, filewatcher.WithWatchFile(watchFileFunc))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		if err := w.Close(); err != nil {
+			t.Errorf("failed to close the file watcher: %v", err)
+		}
+	}()
+
+	if err := w.WatchDir(root); err != nil {
+		t.Fatal(err)
+	}
+
+	// other.json is skipped; config.json is watched despite its extension.
+	if err := os.WriteFile(filepath.Join(root, "other.json"), []byte("{}"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(root, "config.json"), []byte("{}"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	select {
+	case e := <-events:
+		if got := filepath.Base(e.URI.Path()); got != "config.json" || e.Type != protocol.Created {
+			t.Errorf("got event %v for %s, want Created for config.json", e.Type, got)
+		}
+	case <-time.After(30 * time.Second):
+		t.Fatal("timed out waiting for the config.json event")
+	}
+}
+
 func TestStress(t *testing.T) {
 	switch runtime.GOOS {
 	case "darwin", "linux", "windows":
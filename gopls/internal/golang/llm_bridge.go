@@ -39,6 +39,9 @@ type ResolveNodeResult struct {
 	EnclosingFunc string
 	// IsDefinition indicates whether this node is a definition (not just a reference)
 	IsDefinition bool
+	// Candidates lists the distinct symbols the locator matched, this one
+	// first, if there were several
+	Candidates []api.LocatorCandidate
 }
 
 // SourceContext provides rich context about a symbol, suitable for LLM consumption.
@@ -66,6 +69,10 @@ type SourceContext struct {
 
 	// Snippet is the HERO field. Zero-RTT access to the code.
 	Snippet string `json:"snippet"`
+
+	// Handle is the stable handle of the symbol, if it has one (see
+	// SymbolHandle).
+	Handle string `json:"handle,omitempty"`
 }
 
 // ResolveNode resolves a SymbolLocator to the matching AST node and types.Object.
@@ -78,6 +85,10 @@ type SourceContext struct {
 //   - Optional kind filtering (function, method, struct, etc.)
 //   - Fuzzy line hint matching (for disambiguation)
 //
+// A locator with a handle resolves exactly to the declaration of its
+// symbol, which must be in fh (see ResolveLocator, which also turns symbol
+// paths into handles).
+//
 // This function bypasses the LSP protocol layer entirely and works directly
 // with gopls internal types.
 func ResolveNode(ctx context.Context, snapshot *cache.Snapshot, fh file.Handle, locator api.SymbolLocator) (*ResolveNodeResult, error) {
@@ -87,6 +98,11 @@ func ResolveNode(ctx context.Context, snapshot *cache.Snapshot, fh file.Handle,
 		return nil, fmt.Errorf("failed to get package for %s: %w", locator.ContextFile, err)
 	}
 
+	// A handle identifies the symbol exactly.
+	if locator.Handle != "" {
+		return resolveHandleNode(pkg, pgf, locator.Handle)
+	}
+
 	// Build a cursor for efficient AST traversal
 	info := pkg.TypesInfo()
 
@@ -94,6 +110,8 @@ func ResolveNode(ctx context.Context, snapshot *cache.Snapshot, fh file.Handle,
 	scopeStack := []scopeFrame{{node: pgf.File, enclosingFunc: ""}}
 
 	var candidates []ResolveNodeResult
+	var parents []nodeParentInfo // of each candidate
+	var rejected []rejectedCandidate
 	var bestCandidate *ResolveNodeResult
 
 	// Walk the AST to find matching symbols
@@ -129,6 +147,7 @@ func ResolveNode(ctx context.Context, snapshot *cache.Snapshot, fh file.Handle,
 
 		// Apply filters
 		if !matchesLocatorFilters(locator, parentInfo.parent, parentInfo.kind).passed {
+			rejected = append(rejected, rejectedCandidate{ident, obj, parentInfo})
 			return true
 		}
 
@@ -142,6 +161,7 @@ func ResolveNode(ctx context.Context, snapshot *cache.Snapshot, fh file.Handle,
 		}
 
 		candidates = append(candidates, candidate)
+		parents = append(parents, parentInfo)
 		bestCandidate = selectBestCandidate(bestCandidate, &candidate, pgf, locator, isDef)
 
 		return true
@@ -149,10 +169,14 @@ func ResolveNode(ctx context.Context, snapshot *cache.Snapshot, fh file.Handle,
 
 	if bestCandidate == nil {
 		if len(candidates) == 0 {
-			return nil, fmt.Errorf("symbol '%s' not found in file", locator.SymbolName)
+			return nil, &SymbolNotFoundError{
+				Err:         fmt.Errorf("symbol '%s' not found in file", locator.SymbolName),
+				Suggestions: symbolSuggestions(pkg, pgf, locator.SymbolName, rejected),
+			}
 		}
 		bestCandidate = &candidates[0]
 	}
+	bestCandidate.Candidates = ambiguousCandidates(pgf, locator, bestCandidate, candidates, parents)
 
 	return bestCandidate, nil
 }
@@ -226,12 +250,7 @@ func updateScopeStack(n ast.Node, currentStack []scopeFrame) scopeFrame {
 
 	// Update enclosing scope if this is a function declaration
 	if fn, ok := n.(*ast.FuncDecl); ok {
-		if fn.Recv == nil {
-			currentFrame.enclosingFunc = fn.Name.Name
-		} else {
-			recvTypeName := getReceiverTypeName(fn.Recv)
-			currentFrame.enclosingFunc = fmt.Sprintf("(%s).%s", recvTypeName, fn.Name.Name)
-		}
+		currentFrame.enclosingFunc = funcScopeName(fn)
 	}
 
 	// Update enclosing scope if this is a type declaration (struct/interface)
@@ -243,6 +262,15 @@ func updateScopeStack(n ast.Node, currentStack []scopeFrame) scopeFrame {
 	return currentFrame
 }
 
+// funcScopeName returns the name of the scope of a function declaration:
+// "Name" for functions, "(Recv).Name" for methods.
+func funcScopeName(fn *ast.FuncDecl) string {
+	if fn.Recv == nil {
+		return fn.Name.Name
+	}
+	return fmt.Sprintf("(%s).%s", getReceiverTypeName(fn.Recv), fn.Name.Name)
+}
+
 // nodeParentInfo contains the extracted parent scope and kind information for a node.
 type nodeParentInfo struct {
 	parent string
@@ -683,6 +711,7 @@ func buildSourceContext(fset *token.FileSet, obj types.Object, node ast.Node) So
 		Signature:  signature,
 		Snippet:    snippet,
 		DocComment: docComment,
+		Handle:     SymbolHandle(obj),
 	}
 }
 
@@ -1789,6 +1818,9 @@ func FormatSymbolSummary(sym *api.Symbol) string {
 	if sym.Parent != "" {
 		parts = append(parts, fmt.Sprintf("**Parent**: `%s`", sym.Parent))
 	}
+	if sym.Handle != "" {
+		parts = append(parts, fmt.Sprintf("**Handle**: `%s`", sym.Handle))
+	}
 	if sym.Signature != "" {
 		parts = append(parts, fmt.Sprintf("\n**Signature**\n%s", sym.Signature))
 	}
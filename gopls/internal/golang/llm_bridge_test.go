@@ -2,6 +2,8 @@ package golang
 
 import (
 	"context"
+	"errors"
+	"fmt"
 	"go/ast"
 	"go/parser"
 	"go/token"
@@ -1272,6 +1274,177 @@ func (s *Server) Stop() {
 	}
 }
 
+// TestResolveNode_Ambiguous tests that ResolveNode reports the distinct
+// symbols matched by an ambiguous locator, the selected one first.
+func TestResolveNode_Ambiguous(t *testing.T) {
+	testenv.NeedsGoPackages(t)
+
+	files := map[string][]byte{
+		"go.mod": []byte("module example.com\ngo 1.21\n"),
+		"main.go": []byte(`package main
+
+type Circle struct{ r float64 }
+
+func (c Circle) Area() float64 { return 3 * c.r * c.r }
+
+type Square struct{ a float64 }
+
+func (s Square) Area() float64 { return s.a * s.a }
+
+func main() {
+	_ = Circle{1}.Area()
+	_ = Circle{2}.Area()
+}
+`),
+	}
+
+	fix := setupLLMTest(t, files)
+	defer fix.cleanup()
+
+	fh, err := fix.snapshot.ReadFile(fix.ctx, protocol.URIFromPath(fix.mainPath))
+	if err != nil {
+		t.Fatalf("failed to read file: %v", err)
+	}
+
+	tests := []struct {
+		name      string
+		locator   api.SymbolLocator
+		wantLines []int // of the candidates, or nil if not ambiguous
+	}{
+		{
+			name:      "definitions first",
+			locator:   api.SymbolLocator{SymbolName: "Area", ContextFile: fix.mainPath},
+			wantLines: []int{5, 9},
+		},
+		{
+			name:      "line hint",
+			locator:   api.SymbolLocator{SymbolName: "Area", ContextFile: fix.mainPath, LineHint: 9},
+			wantLines: []int{9, 12}, // the nearest occurrence of Circle.Area
+		},
+		{
+			name:    "parent scope",
+			locator: api.SymbolLocator{SymbolName: "Area", ParentScope: "Square", ContextFile: fix.mainPath},
+		},
+		{
+			name:    "uses of one symbol",
+			locator: api.SymbolLocator{SymbolName: "Circle", ContextFile: fix.mainPath},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := ResolveNode(fix.ctx, fix.snapshot, fh, tt.locator)
+			if err != nil {
+				t.Fatalf("ResolveNode failed: %v", err)
+			}
+			var lines []int
+			for _, c := range result.Candidates {
+				lines = append(lines, c.Line)
+			}
+			if fmt.Sprint(lines) != fmt.Sprint(tt.wantLines) {
+				t.Fatalf("Got candidates at lines %v, want %v", lines, tt.wantLines)
+			}
+			if len(lines) == 0 {
+				return
+			}
+			if got := fix.sandbox.Workdir.URI("main.go").Path(); result.Candidates[0].File != got {
+				t.Errorf("Got file %q, want %q", result.Candidates[0].File, got)
+			}
+			if c := result.Candidates[1]; c.Kind != "method" || c.Handle == "" {
+				t.Errorf("Got candidate %+v, want a method with a handle", c)
+			}
+		})
+	}
+}
+
+// TestResolveNode_Suggestions tests the "did you mean" suggestions of a
+// locator that matches no symbol.
+func TestResolveNode_Suggestions(t *testing.T) {
+	testenv.NeedsGoPackages(t)
+
+	files := map[string][]byte{
+		"go.mod": []byte("module example.com\ngo 1.21\n"),
+		"main.go": []byte(`package main
+
+type Server struct {
+	name string
+}
+
+func (s *Server) Start() {
+	s.name = "started"
+}
+
+func main() {
+	var server Server
+	server.Start()
+}
+`),
+		"other.go": []byte(`package main
+
+func Restart() {}
+`),
+	}
+
+	fix := setupLLMTest(t, files)
+	defer fix.cleanup()
+
+	fh, err := fix.snapshot.ReadFile(fix.ctx, protocol.URIFromPath(fix.mainPath))
+	if err != nil {
+		t.Fatalf("failed to read file: %v", err)
+	}
+
+	tests := []struct {
+		name    string
+		locator api.SymbolLocator
+		want    []string // parent.name of the suggestions
+	}{
+		{
+			name:    "typo",
+			locator: api.SymbolLocator{SymbolName: "Strat", ContextFile: fix.mainPath},
+			want:    []string{"*Server.Start"},
+		},
+		{
+			name:    "wrong parent scope",
+			locator: api.SymbolLocator{SymbolName: "Start", ParentScope: "Client", ContextFile: fix.mainPath},
+			want:    []string{"*Server.Start", ".Restart"},
+		},
+		{
+			name:    "declared in another file",
+			locator: api.SymbolLocator{SymbolName: "Restart", ContextFile: fix.mainPath},
+			want:    []string{".Restart", "*Server.Start"},
+		},
+		{
+			name:    "local variable",
+			locator: api.SymbolLocator{SymbolName: "srver", ContextFile: fix.mainPath},
+			want:    []string{".Server", "main.server"},
+		},
+		{
+			name:    "nothing similar",
+			locator: api.SymbolLocator{SymbolName: "Quux", ContextFile: fix.mainPath},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := ResolveNode(fix.ctx, fix.snapshot, fh, tt.locator)
+			var notFound *SymbolNotFoundError
+			if !errors.As(err, &notFound) {
+				t.Fatalf("Got error %v, want a SymbolNotFoundError", err)
+			}
+			var got []string
+			for _, s := range notFound.Suggestions {
+				got = append(got, s.ParentScope+"."+s.Name)
+			}
+			if fmt.Sprint(got) != fmt.Sprint(tt.want) {
+				t.Errorf("Got suggestions %v, want %v", got, tt.want)
+			}
+			if len(got) > 0 && !strings.Contains(err.Error(), "did you mean") {
+				t.Errorf("Got error %q, want a did you mean suggestion", err)
+			}
+		})
+	}
+}
+
 // ===== ExtractSymbolAtDefinition Tests =====
 
 // TestExtractSymbolAtDefinition tests the ExtractSymbolAtDefinition function
@@ -0,0 +1,362 @@
+package golang
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"go/ast"
+	"go/token"
+	"go/types"
+	"strings"
+
+	"golang.org/x/mod/module"
+	"golang.org/x/tools/gopls/internal/cache"
+	"golang.org/x/tools/gopls/internal/cache/metadata"
+	"golang.org/x/tools/gopls/internal/cache/parsego"
+	"golang.org/x/tools/gopls/internal/protocol"
+	"golang.org/x/tools/gopls/internal/util/safetoken"
+	"golang.org/x/tools/gopls/mcpbridge/api"
+)
+
+// This file implements symbol handles: opaque, stable identifiers of
+// symbols returned by the MCP tools and accepted by any tool in place of a
+// SymbolLocator, and the fully-qualified symbol paths (net/http.Get) that
+// resolve to them.
+//
+// A handle is the package path and the path of the object within the
+// package, like objectpath, but by name rather than by index, so that it
+// survives unrelated edits:
+//
+//	example.com/app/server#Serve          package-level object
+//	example.com/app/server#Server.Start   method, field or interface method
+//
+// Local variables, and members of unnamed types, have no handle.
+
+// ErrStaleHandle reports that the symbol of a handle no longer exists.
+var ErrStaleHandle = errors.New("stale symbol handle")
+
+// SymbolHandle returns the handle of obj, or "" if it has none.
+func SymbolHandle(obj types.Object) string {
+	if obj == nil || obj.Pkg() == nil {
+		return ""
+	}
+	pkg := obj.Pkg()
+	if obj.Parent() == pkg.Scope() {
+		return FormatSymbolHandle(pkg.Path(), "", obj.Name())
+	}
+	switch obj := obj.(type) {
+	case *types.Func:
+		recv := obj.Origin().Signature().Recv()
+		if recv == nil {
+			return ""
+		}
+		if named := namedType(recv.Type()); named != nil && named.Obj().Parent() == pkg.Scope() {
+			return FormatSymbolHandle(pkg.Path(), named.Obj().Name(), obj.Name())
+		}
+	case *types.Var:
+		if !obj.IsField() {
+			return ""
+		}
+		// Fields don't know their struct: look for it among the
+		// package-level types.
+		field := obj.Origin()
+		for _, name := range pkg.Scope().Names() {
+			tn, ok := pkg.Scope().Lookup(name).(*types.TypeName)
+			if !ok {
+				continue
+			}
+			if st, ok := tn.Type().Underlying().(*types.Struct); ok {
+				for f := range st.Fields() {
+					if f == field {
+						return FormatSymbolHandle(pkg.Path(), name, obj.Name())
+					}
+				}
+			}
+		}
+	}
+	return ""
+}
+
+// DefinedObject returns the object declared by an identifier name on the
+// given (1-based) line of pgf, a file of pkg, or nil if there is none.
+func DefinedObject(pkg *cache.Package, pgf *parsego.File, line int, name string) types.Object {
+	var obj types.Object
+	ast.Inspect(pgf.File, func(n ast.Node) bool {
+		if id, ok := n.(*ast.Ident); ok && id.Name == name && safetoken.Line(pgf.Tok, id.Pos()) == line {
+			if def := pkg.TypesInfo().Defs[id]; def != nil {
+				obj = def
+			}
+		}
+		return obj == nil
+	})
+	return obj
+}
+
+// namedType returns the named type of t or *t, or nil.
+func namedType(t types.Type) *types.Named {
+	if ptr, ok := types.Unalias(t).(*types.Pointer); ok {
+		t = ptr.Elem()
+	}
+	named, _ := types.Unalias(t).(*types.Named)
+	if named != nil {
+		named = named.Origin()
+	}
+	return named
+}
+
+// FormatSymbolHandle returns the handle of the symbol name of the package
+// with the given path, declared at package level or, if parent is set, as
+// a method or field of the package-level type parent.
+func FormatSymbolHandle(pkgPath, parent, name string) string {
+	if parent != "" {
+		return pkgPath + "#" + parent + "." + name
+	}
+	return pkgPath + "#" + name
+}
+
+// ParseSymbolHandle returns the components of a handle (see
+// FormatSymbolHandle).
+func ParseSymbolHandle(handle string) (pkgPath, parent, name string, err error) {
+	pkgPath, path, ok := strings.Cut(handle, "#")
+	if ok {
+		parent, name, ok = strings.Cut(path, ".")
+		if !ok {
+			parent, name, ok = "", path, true
+		}
+	}
+	if !ok || pkgPath == "" || !token.IsIdentifier(name) || parent != "" && !token.IsIdentifier(parent) {
+		return "", "", "", fmt.Errorf("invalid symbol handle %q: use a handle returned by a tool, such as example.com/pkg#Server.Start", handle)
+	}
+	return pkgPath, parent, name, nil
+}
+
+// lookupHandle returns the object of pkg with the given handle path, or
+// nil. Promoted methods and fields are not found: a handle refers to the
+// type that declares them.
+func lookupHandle(pkg *types.Package, parent, name string) types.Object {
+	if parent == "" {
+		return pkg.Scope().Lookup(name)
+	}
+	tn, ok := pkg.Scope().Lookup(parent).(*types.TypeName)
+	if !ok {
+		return nil
+	}
+	if named, ok := types.Unalias(tn.Type()).(*types.Named); ok {
+		for m := range named.Methods() {
+			if m.Name() == name {
+				return m
+			}
+		}
+	}
+	switch t := tn.Type().Underlying().(type) {
+	case *types.Struct:
+		for f := range t.Fields() {
+			if f.Name() == name {
+				return f
+			}
+		}
+	case *types.Interface:
+		for m := range t.ExplicitMethods() {
+			if m.Name() == name {
+				return m
+			}
+		}
+	}
+	return nil
+}
+
+// ResolveLocator returns a locator that resolves exactly to the symbol of
+// the handle or symbol path of locator, in the file that declares it. A
+// locator with neither must have a symbol name and a context file; it is
+// returned unchanged. The package of a handle or symbol path is loaded on
+// demand if no workspace package depends on it.
+//
+// The resolution reports the candidates of an ambiguous locator, or, with
+// an error of type *SymbolNotFoundError, the symbols that a locator that
+// matches none may have meant. The error wraps ErrStaleHandle if the symbol
+// of a handle no longer exists in the snapshot.
+func ResolveLocator(ctx context.Context, snapshot *cache.Snapshot, locator api.SymbolLocator) (api.SymbolLocator, *api.LocatorResolution, error) {
+	if locator.Handle == "" && locator.SymbolPath == "" {
+		if locator.SymbolName == "" || locator.ContextFile == "" {
+			return locator, nil, fmt.Errorf("symbol_name and context_file are required, unless the handle or the symbol_path of a symbol is given")
+		}
+		fh, err := snapshot.ReadFile(ctx, protocol.URIFromPath(locator.ContextFile))
+		if err != nil {
+			return locator, nil, fmt.Errorf("failed to read file %s: %v", locator.ContextFile, err)
+		}
+		result, err := ResolveNode(ctx, snapshot, fh, locator)
+		if err != nil {
+			return locator, resolutionOf(err), err
+		}
+		if len(result.Candidates) == 0 {
+			return locator, nil, nil
+		}
+		return locator, &api.LocatorResolution{Candidates: result.Candidates}, nil
+	}
+	md, err := snapshot.LoadMetadataGraph(ctx)
+	if err != nil {
+		return locator, nil, err
+	}
+	if locator.Handle == "" {
+		handle, err := handleForSymbolPath(md, locator.SymbolPath)
+		if errors.Is(err, errUnknownPackage) {
+			// The package may be one that no workspace package imports.
+			if err := snapshot.LoadPackagePaths(ctx, symbolPathPackages(locator.SymbolPath)...); err != nil {
+				return locator, nil, err
+			}
+			md = snapshot.MetadataGraph()
+			handle, err = handleForSymbolPath(md, locator.SymbolPath)
+		}
+		if err != nil {
+			return locator, nil, err
+		}
+		locator.Handle = handle
+	}
+	pkgPath, parent, name, err := ParseSymbolHandle(locator.Handle)
+	if err != nil {
+		return locator, nil, err
+	}
+	if len(md.ForPackagePath[metadata.PackagePath(pkgPath)]) == 0 {
+		if err := snapshot.LoadPackagePaths(ctx, pkgPath); err != nil {
+			return locator, nil, err
+		}
+		md = snapshot.MetadataGraph()
+	}
+	// Non-test packages come first: symbols of in-package test files are
+	// only found in the test variant.
+	var pkg *cache.Package // the first package, for suggestions
+	for _, mp := range md.ForPackagePath[metadata.PackagePath(pkgPath)] {
+		if mp.IsIntermediateTestVariant() {
+			continue
+		}
+		pkgs, err := snapshot.TypeCheck(ctx, mp.ID)
+		if err != nil {
+			return locator, nil, err
+		}
+		if pkg == nil {
+			pkg = pkgs[0]
+		}
+		obj := lookupHandle(pkgs[0].Types(), parent, name)
+		if obj == nil || !obj.Pos().IsValid() {
+			continue
+		}
+		locator.ContextFile = pkgs[0].FileSet().File(obj.Pos()).Name()
+		locator.SymbolName = obj.Name()
+		return locator, nil, nil
+	}
+	member := strings.TrimPrefix(FormatSymbolHandle("", parent, name), "#")
+	if locator.SymbolPath != "" {
+		err = fmt.Errorf("symbol %s not found: package %s has no %s", locator.SymbolPath, pkgPath, member)
+	} else {
+		err = fmt.Errorf("%w %q: %s no longer exists in package %s; find it again, e.g. with go_search",
+			ErrStaleHandle, locator.Handle, member, pkgPath)
+	}
+	if pkg != nil {
+		err = &SymbolNotFoundError{Err: err, Suggestions: symbolSuggestions(pkg, nil, name, nil)}
+	}
+	return locator, resolutionOf(err), err
+}
+
+// resolutionOf returns the resolution that reports the suggestions of a
+// *SymbolNotFoundError, or nil.
+func resolutionOf(err error) *api.LocatorResolution {
+	var notFound *SymbolNotFoundError
+	if errors.As(err, &notFound) && len(notFound.Suggestions) > 0 {
+		return &api.LocatorResolution{Suggestions: notFound.Suggestions}
+	}
+	return nil
+}
+
+// errUnknownPackage is the error of handleForSymbolPath for a symbol path
+// that starts with no import path of the metadata graph.
+var errUnknownPackage = errors.New("unknown package")
+
+// handleForSymbolPath returns the handle of a fully-qualified symbol path
+// such as "net/http.(*Client).Do", "net/http.Client.Do" or "net/http.Get",
+// for a package of the metadata graph md. As the last element of an import
+// path may contain dots ("gopkg.in/yaml.v3"), the longest import path of a
+// package in md wins.
+func handleForSymbolPath(md *metadata.Graph, path string) (string, error) {
+	invalid := fmt.Errorf("invalid symbol path %q: use an import path followed by the symbol, such as net/http.Get or net/http.(*Client).Do", path)
+	slash := strings.LastIndex(path, "/") + 1
+	first := strings.Index(path[slash:], ".")
+	if first <= 0 {
+		return "", invalid
+	}
+	for i := len(path) - 1; i >= slash+first; i-- {
+		if path[i] == '.' && len(md.ForPackagePath[metadata.PackagePath(path[:i])]) > 0 {
+			parent, name, ok := parseSymbolPathMember(path[i+1:])
+			if !ok {
+				return "", invalid
+			}
+			return FormatSymbolHandle(path[:i], parent, name), nil
+		}
+	}
+	return "", fmt.Errorf("symbol %s not found: %w %s, which is neither in the workspace nor one of its dependencies", path, errUnknownPackage, path[:slash+first])
+}
+
+// symbolPathPackages returns the import paths that a symbol path may start
+// with: its prefixes that end before a dot and are valid import paths.
+func symbolPathPackages(path string) []string {
+	slash := strings.LastIndex(path, "/") + 1
+	var paths []string
+	for i := slash; i < len(path); i++ {
+		if path[i] == '.' && module.CheckImportPath(path[:i]) == nil {
+			paths = append(paths, path[:i])
+		}
+	}
+	return paths
+}
+
+// parseSymbolPathMember parses the part of a symbol path after the import
+// path: "Name", "Type.Name", "(Type).Name" or "(*Type).Name", where Type
+// may have type arguments.
+func parseSymbolPathMember(member string) (parent, name string, ok bool) {
+	if rest, found := strings.CutPrefix(member, "("); found {
+		recv, after, found := strings.Cut(rest, ")")
+		name, ok = strings.CutPrefix(after, ".")
+		if !found || !ok {
+			return "", "", false
+		}
+		parent = strings.TrimPrefix(recv, "*")
+	} else if parent, name, ok = strings.Cut(member, "."); !ok {
+		parent, name = "", member
+	}
+	parent, _, _ = strings.Cut(parent, "[")
+	if !token.IsIdentifier(name) || parent != "" && !token.IsIdentifier(parent) {
+		return "", "", false
+	}
+	return parent, name, true
+}
+
+// resolveHandleNode returns the declaration of the symbol of a handle in
+// pgf, a file of pkg (see ResolveLocator).
+func resolveHandleNode(pkg *cache.Package, pgf *parsego.File, handle string) (*ResolveNodeResult, error) {
+	pkgPath, parent, name, err := ParseSymbolHandle(handle)
+	if err != nil {
+		return nil, err
+	}
+	if got := string(pkg.Metadata().PkgPath); got != pkgPath {
+		return nil, fmt.Errorf("symbol handle %q refers to package %s, not to package %s of %s", handle, pkgPath, got, pgf.URI.Path())
+	}
+	obj := lookupHandle(pkg.Types(), parent, name)
+	if obj == nil {
+		return nil, fmt.Errorf("%w %q: no longer exists", ErrStaleHandle, handle)
+	}
+	var ident *ast.Ident
+	ast.Inspect(pgf.File, func(n ast.Node) bool {
+		if id, ok := n.(*ast.Ident); ok && id.Pos() == obj.Pos() {
+			ident = id
+		}
+		return ident == nil
+	})
+	if ident == nil {
+		return nil, fmt.Errorf("symbol handle %q is not declared in %s", handle, pgf.URI.Path())
+	}
+	return &ResolveNodeResult{
+		Node:         ident,
+		Pos:          ident.Pos(),
+		Object:       obj,
+		IsDefinition: true,
+	}, nil
+}
@@ -0,0 +1,302 @@
+package golang
+
+import (
+	"cmp"
+	"fmt"
+	"go/ast"
+	"go/token"
+	"go/types"
+	"slices"
+	"strings"
+
+	"golang.org/x/tools/gopls/internal/cache"
+	"golang.org/x/tools/gopls/internal/cache/parsego"
+	"golang.org/x/tools/gopls/internal/fuzzy"
+	"golang.org/x/tools/gopls/mcpbridge/api"
+)
+
+// This file reports how ResolveNode resolved a locator when it did not
+// designate exactly one symbol: the distinct symbols an ambiguous locator
+// matched, or "did you mean" suggestions for a locator that matched none.
+
+// maxSuggestions is the number of suggestions of a SymbolNotFoundError.
+const maxSuggestions = 5
+
+// minSimilarity is the name similarity of the weakest suggestion.
+const minSimilarity = 0.6
+
+// SymbolNotFoundError reports that no symbol matches a locator, with the
+// symbols it may have meant.
+type SymbolNotFoundError struct {
+	Err         error                  // what was not found
+	Suggestions []api.LocatorCandidate // most similar first
+}
+
+func (e *SymbolNotFoundError) Error() string {
+	msg := e.Err.Error()
+	if len(e.Suggestions) > 0 {
+		var names []string
+		for _, s := range e.Suggestions {
+			names = append(names, describeCandidate(s))
+		}
+		msg += "; did you mean " + strings.Join(names, ", ") + "?"
+	}
+	return msg
+}
+
+func (e *SymbolNotFoundError) Unwrap() error { return e.Err }
+
+// describeCandidate returns a short description of a candidate, such as
+// "Greeting (method of *Person, main.go:21)".
+func describeCandidate(c api.LocatorCandidate) string {
+	var desc strings.Builder
+	desc.WriteString(c.Name + " (")
+	if c.Kind != "" {
+		desc.WriteString(c.Kind)
+		if c.ParentScope != "" {
+			desc.WriteString(" of " + c.ParentScope)
+		}
+		desc.WriteString(", ")
+	}
+	fmt.Fprintf(&desc, "%s:%d)", shortPath(c.File), c.Line)
+	return desc.String()
+}
+
+// shortPath returns the last element of a file path.
+func shortPath(path string) string {
+	return path[strings.LastIndexAny(path, `/\`)+1:]
+}
+
+// DescribeResolution returns a note on the candidates of an ambiguous
+// locator, to head the text output of a tool, or "" if there are none.
+func DescribeResolution(resolution *api.LocatorResolution) string {
+	if resolution == nil || len(resolution.Candidates) < 2 {
+		return ""
+	}
+	candidates := resolution.Candidates
+	var note strings.Builder
+	fmt.Fprintf(&note, "Note: the locator matched %d symbols; using %s. Others:\n", len(candidates), describeCandidate(candidates[0]))
+	for _, c := range candidates[1:] {
+		fmt.Fprintf(&note, "  - %s", describeCandidate(c))
+		if c.Handle != "" {
+			fmt.Fprintf(&note, " [handle: %s]", c.Handle)
+		}
+		note.WriteString("\n")
+	}
+	note.WriteString("Set parent_scope, kind or line_hint, or use a handle, to select another one.\n\n")
+	return note.String()
+}
+
+// ambiguousCandidates returns the distinct symbols among the candidates of
+// a locator in pgf, selected first, or nil if they all refer to the same
+// symbol. parents holds the parent information of each candidate.
+func ambiguousCandidates(pgf *parsego.File, locator api.SymbolLocator, selected *ResolveNodeResult, candidates []ResolveNodeResult, parents []nodeParentInfo) []api.LocatorCandidate {
+	// The occurrences of a symbol are represented by the best of them.
+	type symbol struct {
+		best   *ResolveNodeResult
+		parent nodeParentInfo
+	}
+	var (
+		symbols []*symbol
+		byKey   = make(map[any]*symbol)
+	)
+	key := func(c *ResolveNodeResult) any {
+		if c.Object != nil {
+			return c.Object
+		}
+		return c.Pos
+	}
+	for i := range candidates {
+		c := &candidates[i]
+		s, ok := byKey[key(c)]
+		if !ok {
+			s = &symbol{}
+			byKey[key(c)] = s
+			symbols = append(symbols, s)
+		}
+		if best := selectBestCandidate(s.best, c, pgf, locator, c.IsDefinition); best != s.best {
+			s.best, s.parent = best, parents[i]
+		}
+	}
+	if len(symbols) < 2 {
+		return nil
+	}
+
+	selectedKey := key(selected)
+	slices.SortStableFunc(symbols, func(a, b *symbol) int {
+		if key(a.best) == selectedKey || key(b.best) == selectedKey {
+			return cmp.Compare(boolRank(key(b.best) == selectedKey), boolRank(key(a.best) == selectedKey))
+		}
+		if locator.LineHint > 0 {
+			return cmp.Compare(scoreCandidate(*b.best, pgf, locator).confidence, scoreCandidate(*a.best, pgf, locator).confidence)
+		}
+		return cmp.Compare(boolRank(b.best.IsDefinition), boolRank(a.best.IsDefinition))
+	})
+
+	result := make([]api.LocatorCandidate, len(symbols))
+	for i, s := range symbols {
+		result[i] = api.LocatorCandidate{
+			Name:         locator.SymbolName,
+			Kind:         candidateKind(s.best.Object),
+			ParentScope:  s.parent.parent,
+			File:         pgf.URI.Path(),
+			Line:         pgf.Tok.Position(s.best.Pos).Line,
+			Score:        scoreCandidate(*s.best, pgf, locator).confidence,
+			IsDefinition: s.best.IsDefinition,
+			Handle:       SymbolHandle(s.best.Object),
+		}
+	}
+	return result
+}
+
+func boolRank(b bool) int {
+	if b {
+		return 1
+	}
+	return 0
+}
+
+// candidateKind returns the kind of a candidate symbol, or "" if unknown.
+func candidateKind(obj types.Object) string {
+	if obj == nil {
+		return ""
+	}
+	return getKindFromObject(obj)
+}
+
+// rejectedCandidate is an occurrence of the name of a locator that its
+// filters rejected.
+type rejectedCandidate struct {
+	ident  *ast.Ident
+	obj    types.Object
+	parent nodeParentInfo
+}
+
+// symbolSuggestions returns the symbols that a locator for name, which
+// matched nothing, may have meant, most similar first: the occurrences of
+// name in pgf rejected by the other criteria of the locator, then the
+// package-level symbols of pkg (with the methods and fields of its types)
+// and the local symbols of pgf, if not nil, with a similar name.
+func symbolSuggestions(pkg *cache.Package, pgf *parsego.File, name string, rejected []rejectedCandidate) []api.LocatorCandidate {
+	var (
+		suggestions []api.LocatorCandidate
+		seen        = make(map[types.Object]bool)
+		matcher     = fuzzy.NewMatcher(name)
+	)
+	add := func(obj types.Object, parent string, pos token.Pos, score float64) {
+		if obj == nil || seen[obj] || score < minSimilarity {
+			return
+		}
+		seen[obj] = true
+		posn := pkg.FileSet().Position(pos)
+		suggestions = append(suggestions, api.LocatorCandidate{
+			Name:         obj.Name(),
+			Kind:         candidateKind(obj),
+			ParentScope:  parent,
+			File:         posn.Filename,
+			Line:         posn.Line,
+			Score:        score,
+			IsDefinition: pos == obj.Pos(),
+			Handle:       SymbolHandle(obj),
+		})
+	}
+
+	for _, r := range rejected {
+		add(r.obj, r.parent.parent, r.ident.Pos(), 1)
+	}
+
+	scope := pkg.Types().Scope()
+	for _, n := range scope.Names() {
+		obj := scope.Lookup(n)
+		add(obj, "", obj.Pos(), nameSimilarity(matcher, name, n))
+		tn, ok := obj.(*types.TypeName)
+		if !ok {
+			continue
+		}
+		if named, ok := types.Unalias(tn.Type()).(*types.Named); ok {
+			for m := range named.Methods() {
+				add(m, getParentScope(m, ""), m.Pos(), nameSimilarity(matcher, name, m.Name()))
+			}
+		}
+		switch t := tn.Type().Underlying().(type) {
+		case *types.Struct:
+			for f := range t.Fields() {
+				add(f, n, f.Pos(), nameSimilarity(matcher, name, f.Name()))
+			}
+		case *types.Interface:
+			for m := range t.ExplicitMethods() {
+				add(m, n, m.Pos(), nameSimilarity(matcher, name, m.Name()))
+			}
+		}
+	}
+
+	// Local symbols, with their enclosing function as parent.
+	var decls []ast.Decl
+	if pgf != nil {
+		decls = pgf.File.Decls
+	}
+	for _, decl := range decls {
+		fn, ok := decl.(*ast.FuncDecl)
+		if !ok {
+			continue
+		}
+		ast.Inspect(fn, func(n ast.Node) bool {
+			if id, ok := n.(*ast.Ident); ok {
+				if obj := pkg.TypesInfo().Defs[id]; obj != nil && obj.Parent() != scope {
+					if v, ok := obj.(*types.Var); !ok || !v.IsField() {
+						add(obj, funcScopeName(fn), id.Pos(), nameSimilarity(matcher, name, id.Name))
+					}
+				}
+			}
+			return true
+		})
+	}
+
+	slices.SortStableFunc(suggestions, func(a, b api.LocatorCandidate) int {
+		return cmp.Or(cmp.Compare(b.Score, a.Score), cmp.Compare(a.Name, b.Name))
+	})
+	if len(suggestions) > maxSuggestions {
+		suggestions = suggestions[:maxSuggestions]
+	}
+	return suggestions
+}
+
+// nameSimilarity returns the similarity between the name of a locator and
+// the name of a symbol, between 0 and 1: 1 if they are equal, otherwise the
+// best of the fuzzy match of name against candidate (for prefixes and
+// abbreviations: "Greet" for "Greeting") and the case-insensitive edit
+// distance (for typos: "Strat" for "Start"), below 1. matcher matches name.
+func nameSimilarity(matcher *fuzzy.Matcher, name, candidate string) float64 {
+	if name == candidate {
+		return 1
+	}
+	a, b := strings.ToLower(name), strings.ToLower(candidate)
+	edit := 1 - float64(editDistance(a, b))/float64(max(len(a), len(b)))
+	if score := float64(matcher.Score(candidate)); score > edit {
+		edit = score
+	}
+	if edit > 0.95 {
+		return 0.95
+	}
+	return edit
+}
+
+// editDistance returns the Levenshtein distance between a and b, in bytes.
+func editDistance(a, b string) int {
+	row := make([]int, len(b)+1)
+	for j := range row {
+		row[j] = j
+	}
+	for i := 1; i <= len(a); i++ {
+		prev := row[0] // row[i-1][j-1]
+		row[0] = i
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			prev, row[j] = row[j], min(min(row[j], row[j-1])+1, prev+cost)
+		}
+	}
+	return row[len(b)]
+}
@@ -0,0 +1,291 @@
+package lsprpc
+
+import (
+	"context"
+	"log"
+	"net"
+	"os"
+	"path"
+	"sync"
+
+	"golang.org/x/tools/gopls/internal/cache"
+	"golang.org/x/tools/gopls/internal/file"
+	"golang.org/x/tools/gopls/internal/protocol"
+	"golang.org/x/tools/gopls/internal/server"
+	"golang.org/x/tools/gopls/internal/settings"
+	"golang.org/x/tools/internal/jsonrpc2"
+)
+
+// This file exports a few lsprpc internals for external tools (such as
+// LLM/MCP bridges) that attach to a shared gopls daemon instead of creating
+// their own cache, or that share their own session with an editor.
+//
+// These wrappers exist to avoid modifying the internal forwarder and
+// dialer. When cherry-picking changes from upstream gopls, this file should
+// be reviewed but typically will not need changes.
+
+// ResolveRemote resolves a -remote style address (e.g. "auto", "auto;id",
+// "unix;/path/to/socket" or "localhost:37374") to the real network and
+// address, exactly as the gopls forwarder does. Addresses on the 'auto'
+// network resolve to a per-user socket derived from the current executable.
+func ResolveRemote(rawAddr string) (network, address string, err error) {
+	d, err := newAutoDialer(rawAddr, nil)
+	if err != nil {
+		return "", "", err
+	}
+	return d.network, d.addr, nil
+}
+
+// DialRemote connects to the daemon at rawAddr, with the same semantics as
+// the gopls forwarder: if rawAddr is on the 'auto' network and no daemon is
+// listening, the current executable is started in the background with the
+// arguments returned by argFunc, and dialing is retried for a few seconds.
+func DialRemote(ctx context.Context, rawAddr string, argFunc func(network, address string) []string) (net.Conn, error) {
+	d, err := newAutoDialer(rawAddr, argFunc)
+	if err != nil {
+		return nil, err
+	}
+	return d.dialNet(ctx)
+}
+
+// NewSharedSessionServer returns a jsonrpc2.StreamServer that serves every
+// incoming stream as an LSP client of the given existing session, rather
+// than creating a new session per stream like [StreamServer] does. This lets
+// an editor and an in-process MCP server observe identical snapshots,
+// including the editor's overlays, without duplicating the cache.
+//
+// The session outlives its LSP clients: shutdown and exit requests end the
+// client's connection but never shut down the session or the process. When
+// a client disconnects, the documents it left open are closed, and the
+// views added for its workspace folders are removed, unless another client
+// still uses them. Views the session had before, and views passed to
+// [SharedSessionServer.KeepView], are never removed.
+func NewSharedSessionServer(session *cache.Session, optionsFunc func(*settings.Options)) *SharedSessionServer {
+	return &SharedSessionServer{
+		session:          session,
+		optionsOverrides: optionsFunc,
+		views:            make(map[protocol.DocumentURI]int),
+		kept:             make(map[protocol.DocumentURI]bool),
+		overlays:         make(map[protocol.DocumentURI]int),
+	}
+}
+
+// A SharedSessionServer serves LSP clients from a shared session. See
+// [NewSharedSessionServer].
+type SharedSessionServer struct {
+	session          *cache.Session
+	optionsOverrides func(*settings.Options)
+
+	mu       sync.Mutex
+	views    map[protocol.DocumentURI]int  // folders of the views added for clients, with the number of clients using each
+	kept     map[protocol.DocumentURI]bool // folders of the views used outside of the LSP clients
+	overlays map[protocol.DocumentURI]int  // documents open in clients, with the number of clients that opened each
+}
+
+// KeepView records that the view of dir, which may not exist yet, is used
+// outside of the LSP clients, for example by an MCP server, so that it is not
+// removed when the clients stop using it.
+func (s *SharedSessionServer) KeepView(dir protocol.DocumentURI) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.kept[dir] = true
+}
+
+// ServeStream implements the jsonrpc2.StreamServer interface.
+func (s *SharedSessionServer) ServeStream(ctx context.Context, conn jsonrpc2.Conn) error {
+	client := protocol.ClientDispatcher(conn)
+	options := settings.DefaultOptions(s.optionsOverrides)
+	svr := &sessionScopedServer{
+		Server: server.New(s.session, client, options),
+		shared: s,
+		views:  make(map[protocol.DocumentURI]bool),
+		open:   make(map[protocol.DocumentURI]bool),
+	}
+	executable, err := os.Executable()
+	if err != nil {
+		log.Printf("error getting gopls path: %v", err)
+		executable = ""
+	}
+	ctx = protocol.WithClient(ctx, client)
+	conn.Go(ctx,
+		protocol.Handlers(
+			handshaker(s.session, executable, false,
+				protocol.ServerHandler(svr,
+					jsonrpc2.MethodNotFound))))
+	<-conn.Done()
+	svr.disconnect(context.WithoutCancel(ctx))
+	return conn.Err()
+}
+
+// viewFolders returns the folders of the session's views.
+func (s *SharedSessionServer) viewFolders() map[protocol.DocumentURI]bool {
+	folders := make(map[protocol.DocumentURI]bool)
+	for _, view := range s.session.Views() {
+		folders[view.Folder().Dir] = true
+	}
+	return folders
+}
+
+// sessionScopedServer is the LSP server of a client of a shared session,
+// whose lifecycle requests don't affect the session, and which keeps track
+// of the documents and views the client uses, to release them when it
+// disconnects.
+type sessionScopedServer struct {
+	protocol.Server
+	shared *SharedSessionServer
+
+	// Guarded by shared.mu:
+	folders []protocol.WorkspaceFolder    // of the initialize request
+	views   map[protocol.DocumentURI]bool // folders of the views used by the client, counted in shared.views
+	open    map[protocol.DocumentURI]bool // documents open in the client, counted in shared.overlays
+}
+
+// Shutdown does not shut down the shared session (see [server.Shutdown]).
+func (*sessionScopedServer) Shutdown(context.Context) error { return nil }
+
+// Exit does not terminate the process (see [server.Exit]); the client
+// closes its connection afterwards.
+func (*sessionScopedServer) Exit(context.Context) error { return nil }
+
+func (s *sessionScopedServer) Initialize(ctx context.Context, params *protocol.ParamInitialize) (*protocol.InitializeResult, error) {
+	folders := params.WorkspaceFolders
+	if len(folders) == 0 && params.RootURI != "" {
+		folders = []protocol.WorkspaceFolder{{URI: string(params.RootURI), Name: path.Base(params.RootURI.Path())}}
+	}
+	s.shared.mu.Lock()
+	s.folders = folders
+	s.shared.mu.Unlock()
+	return s.Server.Initialize(ctx, params)
+}
+
+func (s *sessionScopedServer) Initialized(ctx context.Context, params *protocol.InitializedParams) error {
+	s.shared.mu.Lock()
+	folders := s.folders
+	s.shared.mu.Unlock()
+	return s.addFolders(folders, func() error { return s.Server.Initialized(ctx, params) })
+}
+
+// DidChangeWorkspaceFolders removes the views of the removed folders only
+// once no other client uses them.
+func (s *sessionScopedServer) DidChangeWorkspaceFolders(ctx context.Context, params *protocol.DidChangeWorkspaceFoldersParams) error {
+	s.shared.mu.Lock()
+	var removed []protocol.WorkspaceFolder
+	for _, folder := range params.Event.Removed {
+		if dir, err := protocol.ParseDocumentURI(folder.URI); err == nil && s.releaseView(dir) {
+			removed = append(removed, folder)
+		}
+	}
+	s.shared.mu.Unlock()
+	event := protocol.WorkspaceFoldersChangeEvent{Added: params.Event.Added, Removed: removed}
+	return s.addFolders(params.Event.Added, func() error {
+		return s.Server.DidChangeWorkspaceFolders(ctx, &protocol.DidChangeWorkspaceFoldersParams{Event: event})
+	})
+}
+
+// addFolders calls add, which adds views for folders, and records the
+// views of folders that were added, or that were added for another client,
+// as used by the client. add is called without holding shared.mu, which
+// is held while recording the views.
+func (s *sessionScopedServer) addFolders(folders []protocol.WorkspaceFolder, add func() error) error {
+	before := s.shared.viewFolders()
+	err := add()
+	after := s.shared.viewFolders()
+
+	s.shared.mu.Lock()
+	defer s.shared.mu.Unlock()
+	for _, folder := range folders {
+		dir, err := protocol.ParseDocumentURI(folder.URI)
+		if err != nil || s.views[dir] {
+			continue
+		}
+		if (!before[dir] && after[dir]) || s.shared.views[dir] > 0 {
+			s.views[dir] = true
+			s.shared.views[dir]++
+		}
+	}
+	return err
+}
+
+// releaseView records that the client no longer uses the view of dir, and
+// reports whether the view can be removed: no client uses it anymore, and it
+// is not kept. shared.mu must be held.
+func (s *sessionScopedServer) releaseView(dir protocol.DocumentURI) bool {
+	if !s.views[dir] {
+		return false
+	}
+	delete(s.views, dir)
+	s.shared.views[dir]--
+	if s.shared.views[dir] > 0 {
+		return false
+	}
+	delete(s.shared.views, dir)
+	return !s.shared.kept[dir]
+}
+
+func (s *sessionScopedServer) DidOpen(ctx context.Context, params *protocol.DidOpenTextDocumentParams) error {
+	if err := s.Server.DidOpen(ctx, params); err != nil {
+		return err
+	}
+	s.shared.mu.Lock()
+	defer s.shared.mu.Unlock()
+	if uri := params.TextDocument.URI; !s.open[uri] {
+		s.open[uri] = true
+		s.shared.overlays[uri]++
+	}
+	return nil
+}
+
+// DidClose closes the overlay of the document only once no other client
+// has it open.
+func (s *sessionScopedServer) DidClose(ctx context.Context, params *protocol.DidCloseTextDocumentParams) error {
+	s.shared.mu.Lock()
+	last := s.closeDocument(params.TextDocument.URI)
+	s.shared.mu.Unlock()
+	if !last {
+		return nil
+	}
+	return s.Server.DidClose(ctx, params)
+}
+
+// closeDocument records that the client closed the document, and reports
+// whether no client has it open anymore. shared.mu must be held.
+func (s *sessionScopedServer) closeDocument(uri protocol.DocumentURI) bool {
+	if !s.open[uri] {
+		return true // not opened through this server
+	}
+	delete(s.open, uri)
+	s.shared.overlays[uri]--
+	if s.shared.overlays[uri] > 0 {
+		return false
+	}
+	delete(s.shared.overlays, uri)
+	return true
+}
+
+// disconnect closes the documents left open by the client, and removes the
+// views that only it used.
+func (s *sessionScopedServer) disconnect(ctx context.Context) {
+	s.shared.mu.Lock()
+	var closed []file.Modification
+	for uri := range s.open {
+		if s.closeDocument(uri) {
+			closed = append(closed, file.Modification{URI: uri, Action: file.Close, Version: -1})
+		}
+	}
+	var removed []protocol.DocumentURI
+	for dir := range s.views {
+		if s.releaseView(dir) {
+			removed = append(removed, dir)
+		}
+	}
+	s.shared.mu.Unlock()
+
+	if len(closed) > 0 {
+		if _, err := s.shared.session.DidModifyFiles(ctx, closed); err != nil {
+			log.Printf("error closing the documents of a disconnected client: %v", err)
+		}
+	}
+	for _, dir := range removed {
+		s.shared.session.RemoveView(ctx, dir)
+	}
+}
@@ -10,6 +10,23 @@ package api
 // 2. "Hints over Constraints": Line numbers are treated as fuzzy search hints, not hard addresses.
 // 3. "Visual over Logical": Inputs should be what the LLM *sees* in the text buffer.
 type SymbolLocator struct {
+	// Handle is the stable handle of a symbol, as returned by the tools in
+	// api.Symbol.Handle. It identifies the symbol exactly, in place of the
+	// other fields.
+	//
+	// Example: "example.com/project/server#Server.Start"
+	Handle string `json:"handle,omitempty" jsonschema:"The handle of the symbol, as returned by other tools (e.g. 'example.com/project/server#Server.Start'). Identifies the symbol exactly: when given, the other fields are not needed."`
+
+	// SymbolPath is the fully-qualified Go path of a package-level symbol,
+	// or of a method or field of a package-level type: the import path,
+	// then the optional receiver type, then the member. It identifies the
+	// symbol without a context file, in the workspace, the modules it
+	// requires, or the standard library; packages that the workspace does
+	// not import are loaded on demand.
+	//
+	// Example: "github.com/acme/foo/store.(*DB).Query", "net/http.Get"
+	SymbolPath string `json:"symbol_path,omitempty" jsonschema:"The fully-qualified path of the symbol (e.g. 'net/http.Get', 'github.com/acme/foo/store.(*DB).Query', 'github.com/acme/foo/store.DB.Query'), for symbols of the workspace, the modules it requires and the standard library. When given, the other fields are not needed."`
+
 	// SymbolName is the exact identifier name of the symbol to find.
 	// This is the primary search key.
 	//
@@ -19,7 +36,7 @@ type SymbolLocator struct {
 	// - For variables: "retryCount"
 	//
 	// Example: "ServeHTTP"
-	SymbolName string `json:"symbol_name" jsonschema:"The exact name of the function, struct, method, or variable to find. Do not include package prefixes (e.g., use 'Println', not 'fmt.Println')."`
+	SymbolName string `json:"symbol_name,omitempty" jsonschema:"The exact name of the function, struct, method, or variable to find. Do not include package prefixes (e.g., use 'Println', not 'fmt.Println'). Required unless handle or symbol_path is given."`
 
 	// ContextFile is the absolute path of the file where the LLM is currently reading
 	// or where the reference to the symbol occurs.
@@ -29,7 +46,7 @@ type SymbolLocator struct {
 	// 2. Resolve local scopes (if the symbol is a local variable).
 	//
 	// Example: "/Users/dev/project/server/http.go"
-	ContextFile string `json:"context_file" jsonschema:"The absolute path of the file you are currently reading or analyzing. This serves as the starting point for resolution."`
+	ContextFile string `json:"context_file,omitempty" jsonschema:"The absolute path of the file you are currently reading or analyzing. This serves as the starting point for resolution. Required unless handle or symbol_path is given."`
 
 	// PackageIdentifier is the package name or alias *as seen in the ContextFile*.
 	//
@@ -83,3 +100,41 @@ type SymbolLocator struct {
 	// Example: "func (s *Server) Start(ctx context.Context)"
 	SignatureSnippet string `json:"signature_snippet,omitempty" jsonschema:"A distinct snippet of code (like the function signature) used to verify the match."`
 }
+
+// LocatorResolution reports how a SymbolLocator was resolved when it did not
+// designate exactly one symbol: the symbols it matched when it was
+// ambiguous, or the symbols with a similar name when it matched none.
+type LocatorResolution struct {
+	// Candidates are the distinct symbols the locator matched, best first,
+	// if there were several. The tool used the first one; narrow the
+	// locator with the parent_scope, kind or line_hint of another one, or
+	// use its handle, to select it instead.
+	Candidates []LocatorCandidate `json:"candidates,omitempty" jsonschema:"the distinct symbols matched by an ambiguous locator, best first; the first one was used"`
+
+	// Suggestions are "did you mean" symbols, most similar first, if the
+	// locator matched nothing.
+	Suggestions []LocatorCandidate `json:"suggestions,omitempty" jsonschema:"symbols with a similar name, most similar first, when the locator matched no symbol"`
+}
+
+// LocatorCandidate is a symbol that a SymbolLocator matched, or nearly
+// matched.
+type LocatorCandidate struct {
+	Name string `json:"name" jsonschema:"the name of the symbol"`
+	Kind string `json:"kind,omitempty" jsonschema:"the kind of the symbol (function, method, type, field, variable, const)"`
+
+	// ParentScope is the receiver of a method, the type of a field, or the
+	// function enclosing a local symbol, as accepted by
+	// SymbolLocator.ParentScope.
+	ParentScope string `json:"parent_scope,omitempty" jsonschema:"the receiver type, struct type or enclosing function, usable as locator parent_scope"`
+
+	File string `json:"file" jsonschema:"the absolute path of the file of the candidate"`
+	Line int    `json:"line" jsonschema:"the line of the candidate (1-based)"`
+
+	// Score is the confidence in a candidate of an ambiguous locator (from
+	// its distance to the line hint), or the similarity of the name of a
+	// suggestion, between 0 and 1.
+	Score float64 `json:"score" jsonschema:"the confidence in a candidate, or the name similarity of a suggestion, between 0 and 1"`
+
+	IsDefinition bool   `json:"is_definition,omitempty" jsonschema:"whether the candidate is the declaration of the symbol rather than a use"`
+	Handle       string `json:"handle,omitempty" jsonschema:"the stable handle of the symbol, usable as locator handle"`
+}
@@ -94,11 +94,183 @@ type Diagnostic struct {
 	CodeSnippet string `json:"code_snippet" jsonschema:"the source code line containing the diagnostic"`
 }
 
+// ICheckEditParams is the input for go_check_edit tool.
+type ICheckEditParams struct {
+	// Files are the proposed changes. They are applied to an isolated,
+	// in-memory copy of the workspace and discarded afterwards; nothing is
+	// written to disk.
+	Files []FileEdit `json:"files" jsonschema:"proposed file changes to type-check (never written to disk)"`
+	// Cwd optionally specifies the working directory for the check.
+	// When set, creates/uses a view for that directory (useful for testing with temp directories).
+	// When empty, uses the default view (normal usage).
+	Cwd string `json:"Cwd,omitempty" jsonschema:"the working directory for the check (default: use default view)"`
+	// References optionally locates a symbol whose references are returned
+	// from the edited workspace, e.g. to check that a changed signature
+	// reaches every caller, or that a new function is used.
+	References *SymbolLocator `json:"references,omitempty" jsonschema:"optional semantic symbol locator: also return the references to this symbol in the workspace with the edits applied"`
+}
+
+// FileEdit describes a proposed change to a single file.
+// Either Content (full replacement) or Edits (text replacements applied to
+// the current content) should be set. If both are set, Edits are applied to
+// Content.
+type FileEdit struct {
+	// Path is the absolute path of the file. The file does not need to exist
+	// when Content is provided.
+	Path string `json:"path" jsonschema:"absolute path of the file to change (may be a new file when content is set)"`
+	// Content is the complete proposed content of the file.
+	Content *string `json:"content,omitempty" jsonschema:"the complete proposed file content"`
+	// Edits are applied in order to the file content.
+	Edits []TextEdit `json:"edits,omitempty" jsonschema:"text replacements applied in order to the current file content"`
+}
+
+// TextEdit replaces an exact, unique occurrence of OldText with NewText.
+// Text matching is used instead of line/column numbers, which are error-prone
+// for LLMs.
+type TextEdit struct {
+	OldText string `json:"old_text" jsonschema:"exact text to replace (must occur exactly once in the file)"`
+	NewText string `json:"new_text" jsonschema:"replacement text"`
+}
+
+// OCheckEditResult is the output for go_check_edit tool.
+type OCheckEditResult struct {
+	Summary string `json:"summary" jsonschema:"check summary"`
+	// Compiles reports whether the workspace has no errors with the edits applied.
+	Compiles bool `json:"compiles" jsonschema:"whether the workspace type-checks without errors after the edits"`
+	// Diagnostics are computed against the edited (speculative) workspace.
+	Diagnostics []Diagnostic `json:"diagnostics,omitempty" jsonschema:"diagnostics of the workspace with the edits applied"`
+	// References are the references to the symbol of the references
+	// locator, computed against the edited workspace.
+	References []Reference        `json:"references,omitempty" jsonschema:"references to the located symbol in the workspace with the edits applied"`
+	Resolution *LocatorResolution `json:"resolution,omitempty" jsonschema:"the candidates of an ambiguous locator, or 'did you mean' suggestions if it matched no symbol"`
+}
+
+// ISyncDocumentParams is the input for go_sync_document tool.
+type ISyncDocumentParams struct {
+	// Path is the absolute path of the document.
+	Path string `json:"path" jsonschema:"absolute path of the document"`
+	// Action is one of "open", "change" or "close".
+	// "open" and "change" store Content as the document's unsaved buffer;
+	// "close" discards the buffer so tools read the file from disk again.
+	Action string `json:"action" jsonschema:"one of: open, change, close"`
+	// Content is the full buffer content (required for open and change).
+	Content string `json:"content,omitempty" jsonschema:"the full current buffer content (required for open and change)"`
+	// Version is the editor's document version. If 0, the previous version
+	// is incremented.
+	Version int32 `json:"version,omitempty" jsonschema:"the editor document version (default: previous version + 1)"`
+	// Cwd optionally specifies the working directory of the document's workspace.
+	// When set, creates/uses a view for that directory (useful for testing with temp directories).
+	// When empty, the session selects the view (normal usage).
+	Cwd string `json:"Cwd,omitempty" jsonschema:"the working directory of the document's workspace (default: automatic)"`
+}
+
+// OSyncDocumentResult is the output for go_sync_document tool.
+type OSyncDocumentResult struct {
+	Summary string `json:"summary" jsonschema:"document synchronization summary"`
+	// OpenDocuments lists the documents currently held as unsaved buffers.
+	OpenDocuments []OpenDocument `json:"open_documents,omitempty" jsonschema:"documents currently held as unsaved buffers"`
+}
+
+// OpenDocument describes a client-pushed document buffer.
+type OpenDocument struct {
+	Path    string `json:"path" jsonschema:"absolute path of the document"`
+	Version int32  `json:"version" jsonschema:"the document version"`
+}
+
+// IServerStatusParams is the input for go_server_status tool.
+type IServerStatusParams struct {
+	// IncludeFileCache requests the size of the persistent gopls file cache,
+	// which requires walking the cache directory.
+	IncludeFileCache bool `json:"include_file_cache,omitempty" jsonschema:"whether to report the on-disk file cache size (slower, default: false)"`
+}
+
+// OServerStatusResult is the output for go_server_status tool.
+type OServerStatusResult struct {
+	Summary string `json:"summary" jsonschema:"server status summary"`
+	// GoplsVersion is the version of the embedded gopls.
+	GoplsVersion string `json:"gopls_version" jsonschema:"version of the embedded gopls"`
+	// Uptime is the time since the server started, e.g. "1h2m3s".
+	Uptime string `json:"uptime" jsonschema:"time since the server started"`
+	// Views describes each gopls view of the session.
+	Views []ViewStatus `json:"views" jsonschema:"the gopls views of the session"`
+	// OpenDocuments is the number of client-pushed document buffers.
+	OpenDocuments int `json:"open_documents" jsonschema:"number of unsaved buffers pushed with go_sync_document"`
+	// Watcher describes the file watcher, if any.
+	Watcher *WatcherStatus `json:"watcher,omitempty" jsonschema:"the file watcher, if any"`
+	// FileCache describes the persistent file cache, if requested.
+	FileCache *FileCacheStatus `json:"file_cache,omitempty" jsonschema:"the persistent gopls file cache, if requested"`
+	// Memory describes the memory usage of the process.
+	Memory MemoryStatus `json:"memory" jsonschema:"memory usage of the process"`
+}
+
+// ViewStatus describes a gopls view.
+type ViewStatus struct {
+	ID         string   `json:"id" jsonschema:"the view ID"`
+	Root       string   `json:"root" jsonschema:"root directory of the view"`
+	Type       string   `json:"type" jsonschema:"view type (e.g. GoMod, GoWork, GOPATH, AdHoc)"`
+	GOOS       string   `json:"goos" jsonschema:"target operating system"`
+	GOARCH     string   `json:"goarch" jsonschema:"target architecture"`
+	GoVersion  string   `json:"go_version" jsonschema:"version of the go command"`
+	BuildFlags []string `json:"build_flags,omitempty" jsonschema:"build flags of the view"`
+	// Snapshot is the sequence number of the view's current snapshot, which
+	// increases with every change gopls observes.
+	Snapshot uint64 `json:"snapshot" jsonschema:"sequence number of the current snapshot"`
+	// Initialized reports whether the initial workspace load finished.
+	Initialized bool `json:"initialized" jsonschema:"whether the initial workspace load finished"`
+	// InitError is the error of the initial workspace load, if any.
+	InitError string `json:"init_error,omitempty" jsonschema:"error of the initial workspace load, if any"`
+	// WorkspacePackages is the number of packages in the workspace.
+	WorkspacePackages int `json:"workspace_packages" jsonschema:"number of workspace packages"`
+	// LoadedPackages is the number of packages with loaded metadata,
+	// including dependencies.
+	LoadedPackages int `json:"loaded_packages" jsonschema:"number of packages with loaded metadata, including dependencies"`
+	// TypeCheckedPackages is the number of type-checked packages held in memory.
+	TypeCheckedPackages int `json:"type_checked_packages" jsonschema:"number of type-checked packages held in memory"`
+}
+
+// WatcherStatus describes the file watcher.
+type WatcherStatus struct {
+	Dir           string `json:"dir" jsonschema:"watched directory"`
+	PendingEvents int    `json:"pending_events" jsonschema:"file events not yet delivered to gopls"`
+}
+
+// FileCacheStatus describes the persistent gopls file cache.
+type FileCacheStatus struct {
+	Dir         string `json:"dir" jsonschema:"cache directory"`
+	Files       int    `json:"files" jsonschema:"number of cached files"`
+	Bytes       int64  `json:"bytes" jsonschema:"total size of cached files"`
+	BudgetBytes int64  `json:"budget_bytes" jsonschema:"soft limit on the cache size"`
+}
+
+// MemoryStatus describes the memory usage of the process.
+type MemoryStatus struct {
+	HeapAllocBytes uint64 `json:"heap_alloc_bytes" jsonschema:"bytes of allocated heap objects"`
+	SysBytes       uint64 `json:"sys_bytes" jsonschema:"bytes of memory obtained from the OS"`
+	NumGC          uint32 `json:"num_gc" jsonschema:"number of completed GC cycles"`
+	Goroutines     int    `json:"goroutines" jsonschema:"number of goroutines"`
+}
+
 // ISearchParams is the input for go_search tool.
 type ISearchParams struct {
-	Query string `json:"query" jsonschema:"the fuzzy search query to use for matching symbols"`
+	// Query is a symbol name, or a qualified name "X.Name" where X is the
+	// receiver type of a method, the type of a field or interface method,
+	// or the package name.
+	Query string `json:"query" jsonschema:"the fuzzy search query to use for matching symbols: a name (Start) or a qualified name (Server.Start, http.Get)"`
 	// MaxResults limits the number of search results returned. If 0 or not set, defaults to 10.
 	MaxResults int `json:"max_results,omitempty" jsonschema:"maximum number of results (default: 10, 0 = unlimited)"`
+	// Kinds restricts the results to symbols of these kinds.
+	Kinds []SymbolKind `json:"kinds,omitempty" jsonschema:"only return symbols of these kinds: function, method, struct, interface, type, field, var, const"`
+	// Package restricts the results to a package tree, given either as an
+	// import path or as a directory relative to the workspace root.
+	Package string `json:"package,omitempty" jsonschema:"only return symbols of the packages at or below this import path (example.com/app/storage), or of the files in this directory relative to the workspace root (./storage, or ./storage/... for the whole tree)"`
+	// ExportedOnly restricts the results to exported symbols.
+	ExportedOnly bool `json:"exported_only,omitempty" jsonschema:"only return exported symbols (for methods and fields, of exported types)"`
+	// Tests selects the symbols of test files: included (the default),
+	// excluded, or the only ones returned.
+	Tests string `json:"tests,omitempty" jsonschema:"symbols of _test.go files: include (default), exclude or only"`
+	// IncludeDependencies also searches the packages the workspace depends
+	// on, including the standard library.
+	IncludeDependencies bool `json:"include_dependencies,omitempty" jsonschema:"also search the dependencies and standard library packages imported by the workspace (listed after the workspace symbols)"`
 	// Cwd optionally specifies the working directory for symbol search.
 	// When set, creates/uses a view for that directory (useful for testing with temp directories).
 	// When empty, searches all available views (normal usage).
@@ -112,29 +284,216 @@ type OSearchResult struct {
 	Symbols []*Symbol `json:"symbols,omitempty" jsonschema:"matching symbols with signatures and documentation"`
 }
 
+// ISearchDocsParams is the input for go_search_docs tool.
+type ISearchDocsParams struct {
+	// Query is a free-text query, matched against the names, doc comments,
+	// identifiers and string literals of the workspace symbols.
+	Query string `json:"query" jsonschema:"free-text query, e.g. retry backoff"`
+	// MaxResults limits the number of results returned. If 0 or not set, defaults to 10.
+	MaxResults int `json:"max_results,omitempty" jsonschema:"maximum number of results (default: 10)"`
+	// Cwd optionally specifies the working directory for the search.
+	// When set, creates/uses a view for that directory (useful for testing with temp directories).
+	// When empty, uses the default view (normal usage).
+	Cwd string `json:"Cwd,omitempty" jsonschema:"the working directory for the search (default: use default view)"`
+}
+
+// OSearchDocsResult is the output for go_search_docs tool.
+type OSearchDocsResult struct {
+	Summary string `json:"summary" jsonschema:"search results summary"`
+	// Results are the matching symbols, best first.
+	Results []DocSearchResult `json:"results,omitempty" jsonschema:"matching symbols, best first"`
+}
+
+// DocSearchResult is a symbol matching a go_search_docs query.
+type DocSearchResult struct {
+	// Symbol is the matching symbol, with its doc comment.
+	Symbol *Symbol `json:"symbol" jsonschema:"the matching symbol, with its doc comment"`
+	// Score is the BM25 relevance score of the symbol.
+	Score float64 `json:"score" jsonschema:"relevance score (higher is better)"`
+	// Snippet is the part of the doc comment or string literal that best
+	// matches the query, if any.
+	Snippet string `json:"snippet,omitempty" jsonschema:"the best matching sentence of the doc comment, or string literal"`
+}
+
+// IStructuralSearchParams is the input for go_structural_search tool.
+type IStructuralSearchParams struct {
+	// Pattern is a Go expression, statement or statement list with
+	// metavariables: $x matches any node (and the same node wherever $x
+	// occurs again), $*x any number of nodes in a list, and $_ and $*_
+	// match without binding.
+	Pattern string `json:"pattern" jsonschema:"Go expression or statement(s) with metavariables, e.g. $db.Query($q, $*_). $x matches any expression (the same one at each occurrence), $*x any number of list elements (arguments, statements), $_ and $*_ match without binding"`
+	// Constraints restricts the expressions bound to metavariables, by
+	// name (with or without "$"): a type, "implements:T", or "const",
+	// negated by a leading "!".
+	Constraints map[string]string `json:"constraints,omitempty" jsonschema:"constraints on metavariables by name, e.g. {\"db\": \"*database/sql.DB\", \"q\": \"!const\"}: a type (*sql.DB or *database/sql.DB), implements:T for types assignable to interface T, const for constant expressions; a leading ! negates"`
+	// Package restricts the search to a package tree, given either as an
+	// import path or as a directory relative to the workspace root.
+	Package string `json:"package,omitempty" jsonschema:"only search the packages at or below this import path, or the files in this directory relative to the workspace root (./storage, or ./storage/... for the whole tree)"`
+	// MaxResults limits the number of matches returned. If 0 or not set, defaults to 50.
+	MaxResults int `json:"max_results,omitempty" jsonschema:"maximum number of matches (default: 50)"`
+	// Cwd optionally specifies the working directory for the search.
+	// When set, creates/uses a view for that directory (useful for testing with temp directories).
+	// When empty, uses the default view (normal usage).
+	Cwd string `json:"Cwd,omitempty" jsonschema:"the working directory for the search (default: use default view)"`
+}
+
+// OStructuralSearchResult is the output for go_structural_search tool.
+type OStructuralSearchResult struct {
+	Summary string `json:"summary" jsonschema:"search results summary"`
+	// Matches are the matches, by file and position.
+	Matches []StructuralMatch `json:"matches,omitempty" jsonschema:"the matches, by file and position"`
+	// Total is the number of matches before applying max_results.
+	Total int `json:"total" jsonschema:"number of matches before applying max_results"`
+}
+
+// StructuralMatch is a match of a structural search pattern.
+type StructuralMatch struct {
+	File      string `json:"file" jsonschema:"absolute path of the file"`
+	Line      int    `json:"line" jsonschema:"line of the start of the match (1-based)"`
+	Column    int    `json:"column" jsonschema:"column of the start of the match (1-based, in bytes)"`
+	EndLine   int    `json:"end_line" jsonschema:"line of the end of the match (1-based)"`
+	EndColumn int    `json:"end_column" jsonschema:"column of the end of the match (1-based, in bytes)"`
+	// Text is the source of the match, truncated if long.
+	Text string `json:"text" jsonschema:"source of the match (truncated if long)"`
+	// Bindings are the sources of the nodes bound to the metavariables.
+	Bindings map[string]string `json:"bindings,omitempty" jsonschema:"source of the nodes bound to each metavariable"`
+	// EnclosingFunction is the function or method containing the match,
+	// such as "(*Server).Start", if any.
+	EnclosingFunction string `json:"enclosing_function,omitempty" jsonschema:"the function or method containing the match, e.g. (*Server).Start"`
+}
+
+// IStructuralReplaceParams is the input for go_structural_replace tool.
+type IStructuralReplaceParams struct {
+	// Pattern is the code to replace, in the syntax of go_structural_search.
+	Pattern string `json:"pattern" jsonschema:"Go expression or statement(s) with metavariables to replace, as in go_structural_search, e.g. errors.Wrap($e, $m)"`
+	// Replacement is the code that replaces each match, in which the
+	// metavariables of the pattern stand for the code they matched.
+	Replacement string `json:"replacement" jsonschema:"Go code replacing each match, using the metavariables of the pattern, e.g. fmt.Errorf($m+\": %w\", $e)"`
+	// Constraints restricts the expressions bound to metavariables, as in
+	// go_structural_search.
+	Constraints map[string]string `json:"constraints,omitempty" jsonschema:"constraints on metavariables by name, as in go_structural_search: a type, implements:T or const; a leading ! negates"`
+	// Package restricts the replacement to a package tree, given either as
+	// an import path or as a directory relative to the workspace root.
+	Package string `json:"package,omitempty" jsonschema:"only replace in the packages at or below this import path, or the files in this directory relative to the workspace root (./storage, or ./storage/... for the whole tree)"`
+	// Apply writes the changes. If false, the changes are only previewed.
+	Apply bool `json:"apply,omitempty" jsonschema:"write the changes to the files (default: false, preview only)"`
+	// Cwd optionally specifies the working directory for the replacement.
+	// When set, creates/uses a view for that directory (useful for testing with temp directories).
+	// When empty, uses the default view (normal usage).
+	Cwd string `json:"Cwd,omitempty" jsonschema:"the working directory for the replacement (default: use default view)"`
+}
+
+// OStructuralReplaceResult is the output for go_structural_replace tool.
+type OStructuralReplaceResult struct {
+	Summary string `json:"summary" jsonschema:"replacement summary"`
+	// Diff is the unified diff of the changes.
+	Diff string `json:"diff,omitempty" jsonschema:"unified diff of the changes"`
+	// Files are the files changed, or to be changed.
+	Files []string `json:"files,omitempty" jsonschema:"the files changed (or to be changed, in a preview)"`
+	// Replacements is the number of matches replaced.
+	Replacements int `json:"replacements" jsonschema:"number of matches replaced"`
+	// Skipped is the number of matches that were not replaced because
+	// they overlap another match; running the tool again replaces them.
+	Skipped int `json:"skipped,omitempty" jsonschema:"number of matches nested in other matches, not replaced (run again to replace them)"`
+	// Applied reports whether the changes were written.
+	Applied bool `json:"applied" jsonschema:"whether the changes were written to the files"`
+}
+
+// ISearchBySignatureParams is the input for go_search_by_signature tool.
+type ISearchBySignatureParams struct {
+	// Signature is a function type pattern, such as
+	// "func(context.Context, *http.Request) error". Types are resolved in
+	// the scope of each searched package: unqualified names refer to the
+	// package's own types or to predeclared types, and qualified names to
+	// the packages it (transitively) imports, by package name.
+	// "_" matches any type, and "..." any number of parameters or results.
+	Signature string `json:"signature" jsonschema:"the function type to match, e.g. func(context.Context, *http.Request) error or func(io.Reader) (*Config, ...). _ matches any type, ... matches any number of parameters or results"`
+	// IncludeDependencies also searches the packages imported by the
+	// workspace, including the standard library (exported symbols only).
+	IncludeDependencies bool `json:"include_dependencies,omitempty" jsonschema:"also search the exported functions and methods of the packages imported by the workspace, including the standard library"`
+	// MaxResults limits the number of results returned. If 0 or not set, defaults to 20.
+	MaxResults int `json:"max_results,omitempty" jsonschema:"maximum number of results (default: 20)"`
+	// Cwd optionally specifies the working directory for the search.
+	// When set, creates/uses a view for that directory (useful for testing with temp directories).
+	// When empty, uses the default view (normal usage).
+	Cwd string `json:"Cwd,omitempty" jsonschema:"the working directory for the search (default: use default view)"`
+}
+
+// OSearchBySignatureResult is the output for go_search_by_signature tool.
+type OSearchBySignatureResult struct {
+	Summary string `json:"summary" jsonschema:"search results summary"`
+	// Symbols are the matching functions and methods, with their signature.
+	Symbols []*Symbol `json:"symbols,omitempty" jsonschema:"matching functions and methods with their signatures"`
+	// Total is the number of matches before applying max_results.
+	Total int `json:"total" jsonschema:"number of matching functions and methods before applying max_results"`
+}
+
 // ISymbolReferencesParams is the input for go_symbol_references tool.
 type ISymbolReferencesParams struct {
 	// Locator specifies the symbol to find references for.
 	// This uses semantic information (symbol name, context file, package, scope)
 	// instead of error-prone line/column numbers.
 	Locator SymbolLocator `json:"locator" jsonschema:"semantic symbol locator (symbol_name, context_file, package_name, parent_scope, kind, line_hint)"`
+	// Kinds restricts the references to these kinds of use.
+	Kinds []ReferenceKind `json:"kinds,omitempty" jsonschema:"only return references of these kinds: call, read, write, address, type, embedding, interface, field_key, declaration"`
+	// Tests selects the references in test files: included (the default),
+	// excluded, or the only ones returned.
+	Tests string `json:"tests,omitempty" jsonschema:"references in _test.go files: include (default), exclude or only"`
+}
+
+// ReferenceKind describes how a reference uses a symbol.
+type ReferenceKind string
+
+const (
+	ReferenceCall        ReferenceKind = "call"        // f(), x.M()
+	ReferenceRead        ReferenceKind = "read"        // any other use of a value, including method values
+	ReferenceWrite       ReferenceKind = "write"       // x = v, x += v, x++, x[i] = v, x.f = v
+	ReferenceAddress     ReferenceKind = "address"     // &x
+	ReferenceType        ReferenceKind = "type"        // var x T, T(v), T{}, *T
+	ReferenceEmbedding   ReferenceKind = "embedding"   // struct{ T }, interface{ I }
+	ReferenceInterface   ReferenceKind = "interface"   // var _ I = T{}, or a method that implements or is implemented by the symbol
+	ReferenceFieldKey    ReferenceKind = "field_key"   // T{F: v}
+	ReferenceDeclaration ReferenceKind = "declaration" // another declaration of the symbol
+)
+
+// Reference is a use of a symbol.
+type Reference struct {
+	File   string        `json:"file" jsonschema:"the absolute path of the file"`
+	Line   int           `json:"line" jsonschema:"the line of the reference (1-based)"`
+	Column int           `json:"column" jsonschema:"the column of the reference (1-based)"`
+	Kind   ReferenceKind `json:"kind" jsonschema:"how the symbol is used: call, read, write, address, type, embedding, interface, field_key or declaration"`
+
+	// EnclosingFunction is the function or method containing the
+	// reference: "Name", "Type.Name" or "(*Type).Name". Empty for
+	// package-level declarations.
+	EnclosingFunction string `json:"enclosing_function,omitempty" jsonschema:"the function or method containing the reference"`
+
+	InTestFile bool   `json:"in_test_file,omitempty" jsonschema:"whether the reference is in a _test.go file"`
+	Text       string `json:"text,omitempty" jsonschema:"the line of code of the reference"`
 }
 
 // OSymbolReferencesResult is the output for go_symbol_references tool.
 type OSymbolReferencesResult struct {
 	Summary string `json:"summary" jsonschema:"symbol references summary"`
+	// References are the references to the symbol, with the kind of use.
+	References []Reference `json:"references,omitempty" jsonschema:"the references, with how each one uses the symbol"`
 	// Symbols is the list of rich symbol information for each unique referenced symbol.
 	// This provides signature, documentation, and snippet for each symbol.
 	Symbols []*Symbol `json:"symbols,omitempty" jsonschema:"rich symbol information for each referenced symbol"`
 
-	// TotalCount is the total number of references found.
-	TotalCount int `json:"total_count,omitempty" jsonschema:"total number of references found"`
+	// TotalCount is the total number of references found, before the
+	// kinds and tests filters.
+	TotalCount int `json:"total_count,omitempty" jsonschema:"total number of references found, before filtering"`
 	// Returned is the number of references returned in this response.
 	Returned int `json:"returned,omitempty" jsonschema:"number of references returned"`
 	// Truncated indicates whether not all references were returned.
 	Truncated bool `json:"truncated,omitempty" jsonschema:"whether the result was truncated due to size limits"`
 	// Hint provides guidance when results are truncated.
 	Hint string `json:"hint,omitempty" jsonschema:"suggestion for getting more details"`
+
+	// Resolution reports the candidates of an ambiguous locator, or the
+	// symbols it may have meant if it matched none.
+	Resolution *LocatorResolution `json:"resolution,omitempty" jsonschema:"the candidates of an ambiguous locator, or 'did you mean' suggestions if it matched no symbol"`
 }
 
 // IRenameSymbolParams is the input for go_dryrun_rename_symbol tool.
@@ -153,6 +512,10 @@ type ORenameSymbolResult struct {
 	// Changes is a line-by-line diff format that's LLM-friendly.
 	// Each change shows the complete old/new line content for easy verification and rewriting.
 	Changes []RenameChange `json:"changes,omitempty" jsonschema:"line-by-line changes with full line content"`
+
+	// Resolution reports the candidates of an ambiguous locator, or the
+	// symbols it may have meant if it matched none.
+	Resolution *LocatorResolution `json:"resolution,omitempty" jsonschema:"the candidates of an ambiguous locator, or 'did you mean' suggestions if it matched no symbol"`
 }
 
 // RenameChange represents a single line change in a rename operation.
@@ -188,6 +551,10 @@ type OImplementationResult struct {
 	Symbols []*Symbol `json:"symbols,omitempty" jsonschema:"rich symbol information for each implementation"`
 	// Summary is a human-readable summary of the results.
 	Summary string `json:"summary" jsonschema:"implementation results summary"`
+
+	// Resolution reports the candidates of an ambiguous locator, or the
+	// symbols it may have meant if it matched none.
+	Resolution *LocatorResolution `json:"resolution,omitempty" jsonschema:"the candidates of an ambiguous locator, or 'did you mean' suggestions if it matched no symbol"`
 }
 
 // IReadFileParams is the input for go_read_file tool.
@@ -267,6 +634,10 @@ type ODefinitionResult struct {
 	Symbol *Symbol `json:"symbol,omitempty" jsonschema:"the symbol at the definition location"`
 	// Summary is a human-readable summary of the result.
 	Summary string `json:"summary" jsonschema:"definition result summary"`
+
+	// Resolution reports the candidates of an ambiguous locator, or the
+	// symbols it may have meant if it matched none.
+	Resolution *LocatorResolution `json:"resolution,omitempty" jsonschema:"the candidates of an ambiguous locator, or 'did you mean' suggestions if it matched no symbol"`
 }
 
 // IAnalyzeWorkspaceParams is the input for analyze_workspace tool.
@@ -448,6 +819,10 @@ type OCallHierarchyResult struct {
 	TotalOutgoing int `json:"total_outgoing,omitempty" jsonschema:"total number of outgoing calls"`
 	// Summary is a human-readable summary.
 	Summary string `json:"summary" jsonschema:"call hierarchy summary"`
+
+	// Resolution reports the candidates of an ambiguous locator, or the
+	// symbols it may have meant if it matched none.
+	Resolution *LocatorResolution `json:"resolution,omitempty" jsonschema:"the candidates of an ambiguous locator, or 'did you mean' suggestions if it matched no symbol"`
 }
 
 // CallHierarchyCall represents a call in the hierarchy.
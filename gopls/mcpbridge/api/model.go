@@ -259,6 +259,12 @@ type Symbol struct {
 	// Kind defines the semantic category.
 	Kind SymbolKind `json:"kind" jsonschema:"the semantic type (function, method, struct, etc.)"`
 
+	// Handle identifies the symbol exactly and stably: tools taking a
+	// SymbolLocator accept it in place of the other locator fields. Empty
+	// for symbols without a stable identity, such as local variables.
+	// Example: "example.com/project/server#Server.Start"
+	Handle string `json:"handle,omitempty" jsonschema:"stable handle of the symbol, accepted by the tools taking a locator (locator.handle)"`
+
 	// Context (The Anti-Entropy Fields) ----------------------------------
 
 	// Receiver is CRITICAL for methods. It specifies the type that owns this method.
@@ -0,0 +1,250 @@
+package core
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"regexp"
+	"slices"
+	"sync"
+	"time"
+)
+
+// Audit log: the wrapper of GenericTool.Register records every tool call (who called which
+// tool with which arguments, and how it went) as a line of JSON in the file
+// configured by MCPConfig.Audit. Unlike the metrics and spans of metrics.go,
+// records include the (redacted) input arguments.
+
+const (
+	defaultAuditMaxSizeBytes = 10 << 20 // 10MB
+	defaultAuditMaxBackups   = 3
+
+	redacted = "[REDACTED]"
+)
+
+// AuditLog appends audit records to a size-rotated JSONL file. It is safe
+// for concurrent use, and may be shared by the handlers of several MCP
+// sessions.
+type AuditLog struct {
+	path       string
+	maxSize    int64
+	maxBackups int
+	rules      []auditRule
+
+	mu   sync.Mutex
+	f    *os.File
+	size int64
+}
+
+// auditRule is a compiled AuditRedaction.
+type auditRule struct {
+	tool    string
+	args    []string
+	pattern *regexp.Regexp
+}
+
+// OpenAuditLog opens the audit log configured by cfg for appending. It
+// returns nil if the audit log is not enabled.
+func OpenAuditLog(cfg *AuditConfig) (*AuditLog, error) {
+	if cfg == nil || cfg.Path == "" {
+		return nil, nil
+	}
+	a := &AuditLog{
+		path:       cfg.Path,
+		maxSize:    cfg.MaxSizeBytes,
+		maxBackups: cfg.MaxBackups,
+	}
+	if a.maxSize <= 0 {
+		a.maxSize = defaultAuditMaxSizeBytes
+	}
+	if a.maxBackups <= 0 {
+		a.maxBackups = defaultAuditMaxBackups
+	}
+	for i, r := range cfg.Redact {
+		rule := auditRule{tool: r.Tool, args: r.Args}
+		if r.Pattern != "" {
+			re, err := regexp.Compile(r.Pattern)
+			if err != nil {
+				return nil, fmt.Errorf("audit.redact[%d]: invalid pattern: %v", i, err)
+			}
+			rule.pattern = re
+		}
+		a.rules = append(a.rules, rule)
+	}
+	if err := a.open(); err != nil {
+		return nil, err
+	}
+	return a, nil
+}
+
+// WithAuditLog records every tool call of the handler in the audit log.
+func WithAuditLog(a *AuditLog) HandlerOption {
+	return func(h *Handler) {
+		h.audit = a
+	}
+}
+
+// Close closes the audit log file.
+func (a *AuditLog) Close() error {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if a.f == nil {
+		return nil
+	}
+	err := a.f.Close()
+	a.f = nil
+	return err
+}
+
+// auditRecord is a line of the audit log.
+type auditRecord struct {
+	Time       time.Time       `json:"time"`
+	Session    string          `json:"session,omitempty"`
+	Client     string          `json:"client,omitempty"`
+	Token      string          `json:"token,omitempty"`
+	Tool       string          `json:"tool"`
+	Args       json.RawMessage `json:"args,omitempty"`
+	DurationMs float64         `json:"duration_ms"`
+	ResultSize int             `json:"result_bytes"`
+	Truncated  bool            `json:"truncated"`
+	Error      string          `json:"error,omitempty"`
+}
+
+// recordCall appends the record of a tool call to the log. Auditing is
+// best effort: a failure to write the record is logged, and does not fail
+// the tool call.
+func (a *AuditLog) recordCall(c *toolCall) {
+	rec := auditRecord{
+		Time:       c.start,
+		Token:      tokenName(c.req),
+		Tool:       c.tool,
+		DurationMs: float64(c.duration) / float64(time.Millisecond),
+		ResultSize: c.responseBytes,
+		Truncated:  c.truncatedFrom > 0,
+	}
+	if c.req != nil {
+		if c.req.Session != nil {
+			rec.Session = c.req.Session.ID()
+			if params := c.req.Session.InitializeParams(); params != nil && params.ClientInfo != nil {
+				rec.Client = params.ClientInfo.Name
+			}
+		}
+		if c.req.Params != nil {
+			rec.Args = c.req.Params.Arguments
+		}
+	}
+	if c.err != nil {
+		rec.Error = c.err.Error()
+	}
+	if err := a.write(rec); err != nil {
+		log.Printf("[gopls-mcp] Failed to write audit record: %v", err)
+	}
+}
+
+// write appends a record to the log, rotating the file first if the record
+// would grow it past the maximum size.
+func (a *AuditLog) write(rec auditRecord) error {
+	rec.Args = a.redact(rec.Tool, rec.Args)
+	data, err := json.Marshal(rec)
+	if err != nil {
+		return err
+	}
+	data = append(data, '\n')
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if a.f == nil {
+		return fmt.Errorf("audit log %s is closed", a.path)
+	}
+	if a.size > 0 && a.size+int64(len(data)) > a.maxSize {
+		if err := a.rotate(); err != nil {
+			return err
+		}
+	}
+	n, err := a.f.Write(data)
+	a.size += int64(n)
+	return err
+}
+
+// open opens the log file for appending. a.mu must be held, or a must not be
+// shared yet.
+func (a *AuditLog) open() error {
+	f, err := os.OpenFile(a.path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0600)
+	if err != nil {
+		return fmt.Errorf("failed to open audit log: %v", err)
+	}
+	info, err := f.Stat()
+	if err != nil {
+		f.Close()
+		return fmt.Errorf("failed to open audit log: %v", err)
+	}
+	a.f, a.size = f, info.Size()
+	return nil
+}
+
+// rotate shifts <path>.N to <path>.N+1, dropping the oldest backup, moves
+// the current file to <path>.1 and starts a new one. a.mu must be held.
+func (a *AuditLog) rotate() error {
+	if err := a.f.Close(); err != nil {
+		return fmt.Errorf("failed to rotate audit log: %v", err)
+	}
+	a.f = nil
+	os.Remove(fmt.Sprintf("%s.%d", a.path, a.maxBackups))
+	for i := a.maxBackups - 1; i >= 1; i-- {
+		os.Rename(fmt.Sprintf("%s.%d", a.path, i), fmt.Sprintf("%s.%d", a.path, i+1))
+	}
+	if err := os.Rename(a.path, a.path+".1"); err != nil {
+		return fmt.Errorf("failed to rotate audit log: %v", err)
+	}
+	return a.open()
+}
+
+// redact applies the redaction rules for tool to the JSON arguments.
+func (a *AuditLog) redact(tool string, args json.RawMessage) json.RawMessage {
+	if len(a.rules) == 0 || len(args) == 0 {
+		return args
+	}
+	var v any
+	if err := json.Unmarshal(args, &v); err != nil {
+		return json.RawMessage(fmt.Sprintf("%q", redacted)) // unparseable: log nothing
+	}
+	for _, rule := range a.rules {
+		if rule.tool != "" && rule.tool != tool {
+			continue
+		}
+		v = rule.apply(v)
+	}
+	data, err := json.Marshal(v)
+	if err != nil {
+		return json.RawMessage(fmt.Sprintf("%q", redacted))
+	}
+	return data
+}
+
+// apply redacts the named arguments and pattern matches in v, recursively.
+func (r auditRule) apply(v any) any {
+	switch v := v.(type) {
+	case map[string]any:
+		for k, elem := range v {
+			if slices.Contains(r.args, k) {
+				v[k] = redacted
+			} else {
+				v[k] = r.apply(elem)
+			}
+		}
+		return v
+	case []any:
+		for i, elem := range v {
+			v[i] = r.apply(elem)
+		}
+		return v
+	case string:
+		if r.pattern != nil {
+			return r.pattern.ReplaceAllLiteralString(v, redacted)
+		}
+		return v
+	default:
+		return v
+	}
+}
@@ -0,0 +1,87 @@
+package core
+
+import (
+	"context"
+	"slices"
+	"time"
+
+	"github.com/modelcontextprotocol/go-sdk/auth"
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+)
+
+// Per-token tool allowlists: the HTTP transport authenticates each request
+// and attaches the token's auth.TokenInfo, which the MCP SDK passes on to
+// requests in their Extra field. GenericTool.Register rejects calls to tools
+// that are not in the token's allowlist, and filterListedTools hides them
+// from tools/list.
+
+// auth.TokenInfo.Extra keys of the token name and tool allowlist.
+const (
+	tokenInfoNameKey  = "name"
+	tokenInfoToolsKey = "tools"
+)
+
+// staticTokenExpiration is the expiration of configured tokens, which never
+// expire (the MCP SDK requires an expiration).
+var staticTokenExpiration = time.Date(9999, time.December, 31, 0, 0, 0, 0, time.UTC)
+
+// TokenInfo returns the auth.TokenInfo for an authenticated request made
+// with the given token.
+func (t HTTPToken) TokenInfo() *auth.TokenInfo {
+	return &auth.TokenInfo{
+		Expiration: staticTokenExpiration,
+		Extra: map[string]any{
+			tokenInfoNameKey:  t.Name,
+			tokenInfoToolsKey: t.Tools,
+		},
+	}
+}
+
+// toolAllowed reports whether the request may call the named tool.
+// Requests that were not authenticated with a token (stdio mode, or HTTP
+// mode without tokens) may call every tool.
+func toolAllowed(req *mcp.CallToolRequest, name string) bool {
+	if req == nil {
+		return true
+	}
+	tools := allowedTools(req.Extra)
+	return len(tools) == 0 || slices.Contains(tools, name)
+}
+
+// allowedTools returns the tool allowlist of the token a request was
+// authenticated with, or nil if it may call every tool.
+func allowedTools(extra *mcp.RequestExtra) []string {
+	if extra == nil || extra.TokenInfo == nil {
+		return nil
+	}
+	tools, _ := extra.TokenInfo.Extra[tokenInfoToolsKey].([]string)
+	return tools
+}
+
+// filterListedTools is a receiving middleware that removes the tools that
+// the request's token may not call from the tools/list result.
+func filterListedTools(next mcp.MethodHandler) mcp.MethodHandler {
+	return func(ctx context.Context, method string, req mcp.Request) (mcp.Result, error) {
+		result, err := next(ctx, method, req)
+		res, ok := result.(*mcp.ListToolsResult)
+		if !ok || err != nil {
+			return result, err
+		}
+		if tools := allowedTools(req.GetExtra()); len(tools) > 0 {
+			res.Tools = slices.DeleteFunc(slices.Clone(res.Tools), func(tool *mcp.Tool) bool {
+				return !slices.Contains(tools, tool.Name)
+			})
+		}
+		return res, nil
+	}
+}
+
+// tokenName returns the name of the token the request was authenticated
+// with, if any.
+func tokenName(req *mcp.CallToolRequest) string {
+	if req == nil || req.Extra == nil || req.Extra.TokenInfo == nil {
+		return ""
+	}
+	name, _ := req.Extra.TokenInfo.Extra[tokenInfoNameKey].(string)
+	return name
+}
@@ -0,0 +1,149 @@
+package core
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+	"golang.org/x/tools/gopls/internal/cache"
+	"golang.org/x/tools/gopls/internal/golang"
+	"golang.org/x/tools/gopls/internal/protocol"
+	"golang.org/x/tools/gopls/mcpbridge/api"
+)
+
+// ===== go_check_edit =====
+// Speculative ("what-if") type checking: the proposed contents are applied as
+// overlays to an isolated clone of the current snapshot, which is discarded
+// once diagnostics, and the references to a symbol if requested, have been
+// computed. Neither the disk nor the session's
+// overlays are modified.
+
+func handleGoCheckEdit(ctx context.Context, h *Handler, req *mcp.CallToolRequest, input api.ICheckEditParams) (*mcp.CallToolResult, *api.OCheckEditResult, error) {
+	if len(input.Files) == 0 {
+		return nil, nil, fmt.Errorf("files is required: provide at least one proposed file change")
+	}
+
+	snapshot, release, err := h.snapshotForDir(input.Cwd)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer release()
+
+	contents := make(map[protocol.DocumentURI][]byte, len(input.Files))
+	for _, f := range input.Files {
+		content, err := proposedContent(ctx, snapshot, f)
+		if err != nil {
+			return nil, nil, err
+		}
+		uri := protocol.URIFromPath(f.Path)
+		if _, ok := contents[uri]; ok {
+			return nil, nil, fmt.Errorf("duplicate path %s: propose each file change once", f.Path)
+		}
+		contents[uri] = content
+	}
+
+	speculative, releaseSpeculative := snapshot.CloneWithOverlays(ctx, contents)
+	defer releaseSpeculative()
+
+	diagnostics, numPackages, err := collectWorkspaceDiagnostics(ctx, speculative)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	compiles := true
+	for _, diag := range diagnostics {
+		if diag.Severity == "Error" {
+			compiles = false
+			break
+		}
+	}
+
+	// Find the references in the edited workspace
+	var refs []api.Reference
+	var resolution *api.LocatorResolution
+	if input.References != nil {
+		var locator api.SymbolLocator
+		if locator, resolution, err = golang.ResolveLocator(ctx, speculative, *input.References); err != nil {
+			return locatorError(err, resolution, &api.OCheckEditResult{Summary: err.Error(), Compiles: compiles, Diagnostics: diagnostics, Resolution: resolution})
+		}
+		found, err := findReferences(ctx, speculative, locator)
+		if err != nil {
+			return nil, nil, err
+		}
+		refs = found.refs
+	}
+
+	var summary strings.Builder
+	fmt.Fprintf(&summary, "Checked %d proposed file change(s) against %d packages (changes were NOT written to disk).\n", len(input.Files), numPackages)
+	if len(diagnostics) == 0 {
+		summary.WriteString("No issues found.")
+	} else {
+		writeDiagnosticsList(&summary, diagnostics)
+	}
+	if input.References != nil {
+		if len(diagnostics) == 0 {
+			summary.WriteString("\n")
+		}
+		summary.WriteString(golang.DescribeResolution(resolution))
+		fmt.Fprintf(&summary, "Found %d reference(s) to %q with the edits applied", len(refs), input.References.SymbolName)
+		if len(refs) > 0 {
+			summary.WriteString(":\n" + countReferenceKinds(refs) + "\n")
+			for i, ref := range refs {
+				fmt.Fprintf(&summary, "%d. %s:%d:%d (%s)\n", i+1, ref.File, ref.Line, ref.Column, ref.Kind)
+			}
+		}
+	}
+
+	result := &api.OCheckEditResult{
+		Summary:     summary.String(),
+		Compiles:    compiles,
+		Diagnostics: diagnostics,
+		References:  refs,
+		Resolution:  resolution,
+	}
+	return &mcp.CallToolResult{Content: []mcp.Content{&mcp.TextContent{Text: summary.String()}}}, result, nil
+}
+
+// proposedContent computes the content of a file after applying the
+// proposed change. Text edits are applied to the explicit content if given,
+// and to the file's current content in the snapshot otherwise.
+func proposedContent(ctx context.Context, snapshot *cache.Snapshot, f api.FileEdit) ([]byte, error) {
+	if !filepath.IsAbs(f.Path) {
+		return nil, fmt.Errorf("path must be absolute: %q", f.Path)
+	}
+	if f.Content == nil && len(f.Edits) == 0 {
+		return nil, fmt.Errorf("no change proposed for %s: set content or edits", f.Path)
+	}
+
+	var text string
+	if f.Content != nil {
+		text = *f.Content
+	} else {
+		fh, err := snapshot.ReadFile(ctx, protocol.URIFromPath(f.Path))
+		if err != nil {
+			return nil, fmt.Errorf("failed to read file: %v", err)
+		}
+		content, err := fh.Content()
+		if err != nil {
+			return nil, fmt.Errorf("failed to get file content of %s (use content to create a new file): %v", f.Path, err)
+		}
+		text = string(content)
+	}
+
+	for i, edit := range f.Edits {
+		if edit.OldText == "" {
+			return nil, fmt.Errorf("edit %d for %s: old_text must not be empty", i, f.Path)
+		}
+		switch n := strings.Count(text, edit.OldText); n {
+		case 0:
+			return nil, fmt.Errorf("edit %d for %s: old_text not found", i, f.Path)
+		case 1:
+			text = strings.Replace(text, edit.OldText, edit.NewText, 1)
+		default:
+			return nil, fmt.Errorf("edit %d for %s: old_text occurs %d times, include more context to make it unique", i, f.Path, n)
+		}
+	}
+	return []byte(text), nil
+}
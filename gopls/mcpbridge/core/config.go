@@ -3,6 +3,8 @@ package core
 import (
 	"encoding/json"
 	"fmt"
+	"strconv"
+	"strings"
 
 	"golang.org/x/tools/gopls/internal/settings"
 )
@@ -34,6 +36,172 @@ type MCPConfig struct {
 	// Default: 32000 (32KB)
 	// JSON field name: max_response_bytes
 	MaxResponseBytes int `json:"max_response_bytes,omitempty"`
+
+	// HTTP configures authentication, TLS and access control for HTTP mode
+	// (-addr). It is ignored in stdio mode.
+	//
+	// Example:
+	// {
+	//   "http": {
+	//     "tokens": [
+	//       {"name": "ci", "token": "s3cret", "tools": ["go_build_check"]}
+	//     ],
+	//     "tls_cert_file": "/etc/gopls-mcp/cert.pem",
+	//     "tls_key_file": "/etc/gopls-mcp/key.pem",
+	//     "allowed_origins": ["https://devbox.example.com"]
+	//   }
+	// }
+	HTTP *HTTPConfig `json:"http,omitempty"`
+
+	// Audit enables a JSONL audit log of tool invocations. It is disabled
+	// unless a path is set.
+	//
+	// Example:
+	// {
+	//   "audit": {
+	//     "path": "/var/log/gopls-mcp/audit.jsonl",
+	//     "max_size_bytes": 10485760,
+	//     "redact": [
+	//       {"tool": "go_sync_document", "args": ["content"]},
+	//       {"pattern": "(?i)api[_-]?key=\\S+"}
+	//     ]
+	//   }
+	// }
+	Audit *AuditConfig `json:"audit,omitempty"`
+
+	// Tools selects the tools that are served and configures them.
+	//
+	// Example:
+	// {
+	//   "tools": {
+	//     "read_only": true,
+	//     "disabled": ["refactoring", "go_get_started"],
+	//     "per_tool": {
+	//       "go_search": {"max_response_bytes": 8000, "defaults": {"max_results": 20}}
+	//     }
+	//   }
+	// }
+	Tools *ToolsConfig `json:"tools,omitempty"`
+
+	// SearchIndex configures the symbol index used by go_search.
+	//
+	// Example:
+	// {
+	//   "search_index": {"persist": true}
+	// }
+	SearchIndex *SearchIndexConfig `json:"search_index,omitempty"`
+}
+
+// HTTPConfig holds the settings of the HTTP transport.
+type HTTPConfig struct {
+	// Tokens are the bearer tokens accepted by the server. When any token is
+	// configured (here or in TokenFile), requests without a valid
+	// "Authorization: Bearer <token>" header are rejected.
+	Tokens []HTTPToken `json:"tokens,omitempty"`
+
+	// TokenFile is the path of a static token file with one token per line,
+	// optionally followed by a comma-separated tool allowlist:
+	//
+	//	# comment
+	//	s3cret
+	//	readonly-token go_search,go_definition
+	//
+	// (can also be set via the -token-file flag)
+	TokenFile string `json:"token_file,omitempty"`
+
+	// TLSCertFile and TLSKeyFile enable HTTPS when both are set.
+	// (can also be set via the -tls-cert and -tls-key flags)
+	TLSCertFile string `json:"tls_cert_file,omitempty"`
+	TLSKeyFile  string `json:"tls_key_file,omitempty"`
+
+	// AllowedOrigins lists the browser origins (e.g. "https://example.com")
+	// allowed to call the server, or "*" for any origin. Requests without an
+	// Origin header are not affected. When empty, only loopback origins are
+	// allowed, which protects a local server against DNS rebinding.
+	AllowedOrigins []string `json:"allowed_origins,omitempty"`
+}
+
+// HTTPToken is a bearer token accepted in HTTP mode.
+type HTTPToken struct {
+	// Name identifies the token in logs. It is optional.
+	Name string `json:"name,omitempty"`
+
+	// Token is the secret bearer token.
+	Token string `json:"token"`
+
+	// Tools restricts the tools this token may call. Empty allows all tools.
+	Tools []string `json:"tools,omitempty"`
+}
+
+// AuditConfig holds the settings of the audit log.
+type AuditConfig struct {
+	// Path is the audit log file. Records are appended, one JSON object per
+	// line.
+	Path string `json:"path"`
+
+	// MaxSizeBytes is the size at which the log is rotated: the current file
+	// is renamed to <path>.1 (shifting older files to <path>.2 and so on) and
+	// a new file is started.
+	// Default: 10485760 (10MB)
+	MaxSizeBytes int64 `json:"max_size_bytes,omitempty"`
+
+	// MaxBackups is the number of rotated files to keep.
+	// Default: 3
+	MaxBackups int `json:"max_backups,omitempty"`
+
+	// Redact lists the rules applied to tool arguments before they are
+	// logged.
+	Redact []AuditRedaction `json:"redact,omitempty"`
+}
+
+// AuditRedaction is a redaction rule for the arguments of audited tool
+// calls. Redacted values are replaced by "[REDACTED]".
+type AuditRedaction struct {
+	// Tool restricts the rule to one tool. Empty applies it to all tools.
+	Tool string `json:"tool,omitempty"`
+
+	// Args are the names of arguments whose values are redacted entirely.
+	Args []string `json:"args,omitempty"`
+
+	// Pattern is a regular expression; matches in string argument values
+	// are redacted.
+	Pattern string `json:"pattern,omitempty"`
+}
+
+// ToolsConfig selects and configures the served tools. Tools are selected by
+// name (e.g. "go_search") or by go_list_tools category (e.g. "navigation").
+type ToolsConfig struct {
+	// Enabled lists the tools or categories to serve. Empty serves all
+	// tools.
+	Enabled []string `json:"enabled,omitempty"`
+
+	// Disabled lists tools or categories not to serve. It takes precedence
+	// over Enabled.
+	Disabled []string `json:"disabled,omitempty"`
+
+	// ReadOnly serves only the tools that are declared read-only, hiding
+	// any tool that edits files, session state or runs commands.
+	// (can also be set via the -read-only flag)
+	ReadOnly bool `json:"read_only,omitempty"`
+
+	// PerTool configures individual tools, by name.
+	PerTool map[string]ToolConfig `json:"per_tool,omitempty"`
+}
+
+// ToolConfig configures a single tool.
+type ToolConfig struct {
+	// MaxResponseBytes overrides the global max_response_bytes for the tool.
+	MaxResponseBytes int `json:"max_response_bytes,omitempty"`
+
+	// Defaults are default values for arguments the client omits.
+	Defaults map[string]any `json:"defaults,omitempty"`
+}
+
+// SearchIndexConfig configures the symbol index used by go_search.
+type SearchIndexConfig struct {
+	// Persist stores the index in the gopls file cache, so that a restarted
+	// server only re-parses the files that changed in the meantime.
+	Persist bool `json:"persist,omitempty"`
 }
 
 // DefaultConfig returns a default configuration.
@@ -83,6 +251,67 @@ func LoadConfigFromMap(m map[string]any) (*MCPConfig, error) {
 	return LoadConfig(data)
 }
 
+// Environment variables that override the configuration (see ApplyEnv).
+const (
+	// EnvMaxResponseBytes overrides max_response_bytes.
+	EnvMaxResponseBytes = "GOPLS_MCP_MAX_RESPONSE_BYTES"
+	// EnvGopls is a JSON object of gopls options, merged over the gopls
+	// options of the configuration.
+	EnvGopls = "GOPLS_MCP_GOPLS"
+	// EnvReadOnly, if true, enables tools.read_only.
+	EnvReadOnly = "GOPLS_MCP_READ_ONLY"
+	// EnvDisabledTools is a comma-separated list of tools or categories
+	// added to tools.disabled.
+	EnvDisabledTools = "GOPLS_MCP_DISABLED_TOOLS"
+)
+
+// ApplyEnv applies the environment variable overrides to the configuration,
+// looking up variables with getenv (e.g. os.Getenv).
+func (c *MCPConfig) ApplyEnv(getenv func(string) string) error {
+	if v := getenv(EnvMaxResponseBytes); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n <= 0 {
+			return fmt.Errorf("invalid %s %q: want a positive number of bytes", EnvMaxResponseBytes, v)
+		}
+		c.MaxResponseBytes = n
+	}
+	if v := getenv(EnvGopls); v != "" {
+		var gopls map[string]any
+		if err := json.Unmarshal([]byte(v), &gopls); err != nil {
+			return fmt.Errorf("invalid %s: %v", EnvGopls, err)
+		}
+		if c.Gopls == nil {
+			c.Gopls = make(map[string]any)
+		}
+		for k, v := range gopls {
+			c.Gopls[k] = v
+		}
+	}
+	if v := getenv(EnvReadOnly); v != "" {
+		readOnly, err := strconv.ParseBool(v)
+		if err != nil {
+			return fmt.Errorf("invalid %s %q: %v", EnvReadOnly, v, err)
+		}
+		if readOnly {
+			if c.Tools == nil {
+				c.Tools = &ToolsConfig{}
+			}
+			c.Tools.ReadOnly = true
+		}
+	}
+	if v := getenv(EnvDisabledTools); v != "" {
+		if c.Tools == nil {
+			c.Tools = &ToolsConfig{}
+		}
+		for _, tool := range strings.Split(v, ",") {
+			if tool = strings.TrimSpace(tool); tool != "" {
+				c.Tools.Disabled = append(c.Tools.Disabled, tool)
+			}
+		}
+	}
+	return nil
+}
+
 // ApplyGoplsOptions applies the gopls configuration to a settings.Options struct.
 // This uses gopls's native option parsing logic, so all standard gopls options
 // are supported without any hardcoding.
@@ -248,3 +248,89 @@ func TestDefaultConfig(t *testing.T) {
 		t.Errorf("Expected default MaxResponseBytes 32000, got %d", config.MaxResponseBytes)
 	}
 }
+
+func TestLoadConfigHTTP(t *testing.T) {
+	json := `{
+		"http": {
+			"tokens": [{"name": "ci", "token": "s3cret", "tools": ["go_build_check"]}],
+			"token_file": "/etc/tokens",
+			"tls_cert_file": "cert.pem",
+			"tls_key_file": "key.pem",
+			"allowed_origins": ["https://example.com"]
+		}
+	}`
+
+	config, err := LoadConfig([]byte(json))
+	if err != nil {
+		t.Fatalf("Failed to load config: %v", err)
+	}
+	if config.HTTP == nil {
+		t.Fatal("Expected http config")
+	}
+	if len(config.HTTP.Tokens) != 1 || config.HTTP.Tokens[0].Token != "s3cret" || len(config.HTTP.Tokens[0].Tools) != 1 {
+		t.Errorf("Unexpected tokens: %+v", config.HTTP.Tokens)
+	}
+	if config.HTTP.TokenFile != "/etc/tokens" || config.HTTP.TLSCertFile != "cert.pem" || config.HTTP.TLSKeyFile != "key.pem" {
+		t.Errorf("Unexpected http config: %+v", config.HTTP)
+	}
+	if len(config.HTTP.AllowedOrigins) != 1 {
+		t.Errorf("Expected 1 allowed origin, got %v", config.HTTP.AllowedOrigins)
+	}
+
+	info := config.HTTP.Tokens[0].TokenInfo()
+	if tools, _ := info.Extra[tokenInfoToolsKey].([]string); len(tools) != 1 || tools[0] != "go_build_check" {
+		t.Errorf("Expected token info to carry the tool allowlist, got %v", info.Extra)
+	}
+}
+
+func TestApplyEnv(t *testing.T) {
+	t.Run("Overrides", func(t *testing.T) {
+		config, err := LoadConfig([]byte(`{"gopls": {"staticcheck": true, "buildFlags": ["-tags=a"]}, "max_response_bytes": 1000}`))
+		if err != nil {
+			t.Fatalf("Failed to load config: %v", err)
+		}
+		env := map[string]string{
+			EnvMaxResponseBytes: "2000",
+			EnvGopls:            `{"buildFlags": ["-tags=b"]}`,
+			EnvReadOnly:         "true",
+			EnvDisabledTools:    "refactoring, go_get_started",
+		}
+		if err := config.ApplyEnv(func(k string) string { return env[k] }); err != nil {
+			t.Fatalf("Failed to apply env: %v", err)
+		}
+
+		if config.MaxResponseBytes != 2000 {
+			t.Errorf("Expected MaxResponseBytes 2000, got %d", config.MaxResponseBytes)
+		}
+		if config.Gopls["staticcheck"] != true {
+			t.Errorf("Expected staticcheck to be kept, got %v", config.Gopls)
+		}
+		if flags, _ := config.Gopls["buildFlags"].([]any); len(flags) != 1 || flags[0] != "-tags=b" {
+			t.Errorf("Expected buildFlags to be overridden, got %v", config.Gopls["buildFlags"])
+		}
+		if config.Tools == nil || !config.Tools.ReadOnly {
+			t.Errorf("Expected read-only mode, got %+v", config.Tools)
+		}
+		if config.Tools != nil && len(config.Tools.Disabled) != 2 {
+			t.Errorf("Expected 2 disabled tools, got %v", config.Tools.Disabled)
+		}
+	})
+
+	t.Run("Invalid", func(t *testing.T) {
+		for k, v := range map[string]string{
+			EnvMaxResponseBytes: "lots",
+			EnvGopls:            "{invalid json",
+			EnvReadOnly:         "maybe",
+		} {
+			err := DefaultConfig().ApplyEnv(func(key string) string {
+				if key == k {
+					return v
+				}
+				return ""
+			})
+			if err == nil {
+				t.Errorf("Expected error for %s=%q", k, v)
+			}
+		}
+	})
+}
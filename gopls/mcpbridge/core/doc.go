@@ -63,23 +63,120 @@ var docMap = map[string]string{
 **Note**: This also populates the workspace cache for faster subsequent tool calls.
 `,
 
-	ToolGoSearch: `Find symbols (functions, types, constants) by name with fuzzy matching.
+	ToolGoCheckEdit: `Check whether proposed file changes compile without writing them to disk.
+
+**When to use**: Before editing files, to verify that a change type-checks (including its effect on other packages).
+
+**Use this instead of**: Writing the file, running go_build_check, and reverting on failure.
+
+**Input**: For each file, either the complete new content, or edits as exact old_text/new_text replacements (old_text must be unique in the file). New files are supported via content. Optionally, a references locator returns the references to a symbol in the edited workspace (e.g. to check that a changed function reaches every caller).
+
+**Note**: Changes are applied to an isolated in-memory copy of the workspace and discarded afterwards. Pre-existing errors in the workspace are reported as well.
+
+**See also**: go_build_check for diagnostics of the files on disk.
+`,
+
+	ToolGoSearch: `Find symbols (functions, methods, types, fields, constants, variables) by name with fuzzy matching.
 
 **When to use**: You know part of a symbol's NAME (identifier) but not the full name or location.
 
 **Critical - This ONLY searches symbol names**:
 - ✅ Searches for identifier names: "formatSymbol", "Diag", "Server"
+- ✅ Qualified names: "Server.Start" (method or field of a type), "http.Get" (symbol of a package)
 - ❌ NOT for code patterns, phrases, or concepts
 - ❌ NOT for signatures like "func PackageDiagnostics"
-- ❌ NOT for descriptions like "diagnostic deduplicate logic"
+- ❌ NOT for descriptions like "diagnostic deduplicate logic" (use go_search_docs)
 
 **Use this instead of**: Grep/ripgrep when searching for symbol identifiers by name.
 
-**Output**: Symbol name, kind, file, line. Does NOT include signature/docs/body (use go_definition for those).
+**Filters**:
+- kinds: ["method"], ["struct", "interface"], ...
+- package: an import path ("example.com/app/storage", including its subpackages) or a directory relative to the workspace root ("./storage", or "./storage/..." for the whole tree)
+- exported_only: only exported symbols
+- tests: "exclude" or "only" the symbols of _test.go files
+- include_dependencies: also search the dependencies and the standard library
 
-**Example**: Searching "formatSymbol" matches formatPackageSymbols, formatPackageSymbolDetail, FormatSymbolSummary.
+**Output**: Symbol name (qualified by its type for methods and fields), kind, file, line, and handle; package path in the structured result. Pass the handle to go_definition, go_symbol_references, etc. instead of symbol_name + context_file. Does NOT include signature/docs/body (use go_definition for those).
+
+**Example**: Searching "formatSymbol" matches formatPackageSymbols, formatPackageSymbolDetail, FormatSymbolSummary. Searching "Close" with kinds ["method"] and package "./storage/..." finds the Close methods of the types in ./storage and its subdirectories.
 
 **See also**: go_definition for full details, go_list_package_symbols for exploring all symbols in a package.
+`,
+
+	ToolGoSearchBySignature: `Find functions and methods by the shape of their signature.
+
+**When to use**: You know what a function should take and return ("something that turns an io.Reader into a *Config") but not its name.
+
+**Input**: A function type pattern, resolved in the scope of each package: unqualified names are the package's own types or predeclared types, qualified names refer to imported packages by name.
+- "func(io.Reader) (*Config, error)": takes an io.Reader, returns a *Config and an error
+- "func(context.Context, ...) error": takes a context.Context first, then anything
+- "func(_) []_": takes one argument of any type, returns a slice
+- The "func" keyword may be omitted: "(string) int"
+
+**Matching**: By assignability, not text. A pattern parameter matches if it can be passed as the argument (io.Reader matches a parameter of type io.Reader or any); a result matches if it can be assigned to the pattern result (*MyError matches error). Variadic parameters accept any number of arguments.
+
+**Output**: Package, qualified name, signature, file and line of each match, workspace first. Set include_dependencies to also search the exported API of the imported packages, including the standard library.
+
+**See also**: go_search to find symbols by name, go_definition for the documentation and body of a match.
+`,
+
+	ToolGoSearchDocs: `Full-text search of the workspace symbols by what they do, ranked by relevance.
+
+**When to use**: You know the CONCEPT ("retry backoff", "parse config file") but not the name of the code implementing it.
+
+**Matches**: Symbol names split on camelCase (weighted higher), receiver and parent type names, doc comments, and the identifiers and string literals of each declaration. Words are matched case-insensitively, ignoring common inflections ("retries" matches "retry").
+
+**Use this instead of**: Grep for words in comments, or reading files to find where something is implemented.
+
+**Output**: Symbols ranked by BM25 score, best first, each with the sentence of its doc comment (or the string literal) that best matches the query.
+
+**Note**: Searches the workspace only, from the same incrementally updated index as go_search; no network access.
+
+**See also**: go_search when you know part of the symbol name, go_definition to read a result.
+`,
+
+	ToolGoStructuralSearch: `Find code by its syntax, with a Go pattern containing metavariables (like gogrep).
+
+**When to use**: You are looking for a code SHAPE rather than a name: calls with a certain kind of argument, error checks, misuse of an API.
+
+**Use this instead of**: Regex grep, which cannot follow nesting, ignore formatting or check types.
+
+**Pattern**: A Go expression, or a list of statements, where:
+- $x matches any expression, identifier or statement; a repeated $x must match identical code
+- $*x matches any number of consecutive list elements (arguments, statements, fields)
+- $_ and $*_ match likewise without binding
+- Examples: "$db.Query($q, $*_)", "fmt.Errorf($*_, $err)", "if $err != nil { return $*_ }", "$x = append($x, $*_)"
+
+**Constraints**: Map from metavariable to a condition on its type, checked with the type information of the package:
+- a type, as written in the package or with its import path: "string", "*sql.DB", "database/sql.DB"
+- "implements:T": the type implements interface T ("implements:error", "implements:io.Reader")
+- "const": the expression is a constant
+- a leading "!" negates: {"$q": "!const"} finds queries built at run time
+
+**Output**: File, range, source text, bindings and enclosing function of each match, by file and position. Filter the packages with package (an import path or "./dir/...").
+
+**See also**: go_search to find symbols by name, go_symbol_references to find the uses of one symbol.
+`,
+
+	ToolGoStructuralReplace: `Replace code by its syntax: every match of a pattern is rewritten from a template (like gofmt -r, with types).
+
+**When to use**: Codebase-wide API migrations and mechanical refactorings, e.g. replacing errors.Wrap($e, $m) with fmt.Errorf($m+": %w", $e).
+
+**Use this instead of**: Editing the matches one by one, or regex replacement.
+
+**Input**:
+- pattern, constraints and package: as in go_structural_search
+- replacement: Go code in which the metavariables of the pattern stand for the code they matched; an empty replacement deletes the matches
+- apply: write the changes, to all the files or none (default: preview only). Files with unsaved changes in an editor sharing the session are refused
+
+**Output**: Unified diff of the changes, with the number of replacements and the changed files. Imports are added and removed as needed, and the changed files are formatted. Bindings are parenthesized where the template requires it.
+
+**Notes**:
+- Matches nested in another match are left alone; run the tool again to replace them
+- Applied changes are written to disk, or to the buffers of documents synchronized with go_sync_document
+- Check the result with go_build_check
+
+**See also**: go_structural_search to try the pattern first, go_dryrun_rename_symbol to rename a symbol.
 `,
 
 	ToolGoSymbolReferences: `Find all usages of a symbol across the codebase.
@@ -88,9 +185,11 @@ var docMap = map[string]string{
 
 **Use this instead of**: Grep + manual file reading for finding references.
 
-**Input**: Use semantic locator (symbol_name + context_file). The context_file is where you see the symbol used.
+**Input**: Use semantic locator (symbol_name + context_file). The context_file is where you see the symbol used. Alternatively, the handle of a symbol returned by another tool, or its fully-qualified symbol_path ("example.com/app/store.(*DB).Query").
 
-**Output**: Reference locations (file, line, column) plus rich symbol information.
+**Filters**: kinds keeps the references of the given kinds (call, read, write, address, type, embedding, interface, field_key, declaration), e.g. ["write"] to find the mutations of a variable or field. tests is "include" (default), "exclude" or "only" for the references in _test.go files.
+
+**Output**: Reference locations (file, line, column), each with its kind of use, enclosing function and whether it is in a test file, plus rich symbol information. total_count counts the references before filtering.
 
 **See also**: go_dryrun_rename_symbol to preview rename operations.
 `,
@@ -125,7 +224,33 @@ var docMap = map[string]string{
 
 **When to use**: You need to see actual code or implementation details.
 
-**Note**: Unsaved editor changes are not included. This reads from disk.
+**Note**: Reads from disk, unless the file's unsaved buffer was pushed with go_sync_document.
+`,
+
+	ToolGoSyncDocument: `Push the unsaved content of an editor buffer to gopls-mcp.
+
+**When to use**: Running next to an editor, so that all tools (definition, references, diagnostics, ...) answer against what the user currently has in their buffers rather than the saved files.
+
+**Actions**:
+- open/change: store the full buffer content (a change to a document that is not open opens it)
+- close: discard the buffer, tools read the file from disk again
+
+**Note**: Unlike go_check_edit, buffers stay in effect for all tools until closed.
+
+**See also**: go_check_edit for one-off "what-if" checks that are discarded afterwards.
+`,
+
+	ToolGoServerStatus: `Report the state of the gopls-mcp server and its workspace.
+
+**When to use**: A tool returns odd, stale or empty results and you need to see what gopls-mcp thinks the workspace is.
+
+**Reports**:
+- Each view: root, type, GOOS/GOARCH, build flags, Go version, snapshot sequence number, initial load state
+- Package counts: workspace, loaded (including dependencies), type-checked in memory
+- Open documents, pending file watcher events, memory usage and uptime
+- The on-disk file cache size, with include_file_cache=true (slower)
+
+**Tip**: A snapshot number that doesn't increase after editing files means gopls has not seen the changes.
 `,
 
 	ToolGoDefinition: `Jump to the definition of a symbol.
@@ -134,7 +259,9 @@ var docMap = map[string]string{
 
 **Use this instead of**: Grep + manual file reading.
 
-**Input**: Use semantic locator (symbol_name + context_file where you see the symbol).
+**Input**: Use semantic locator (symbol_name + context_file where you see the symbol), or the handle of a symbol returned by another tool (e.g. go_search), or the fully-qualified symbol_path of a symbol of the workspace, a dependency or the standard library: "net/http.(*Client).Do", "example.com/app/store.DB.Query".
+
+**Output**: Signature, documentation and location of the definition, and its handle for use in later calls.
 
 **See also**: go_get_package_symbol_detail for exploring package APIs.
 `,
@@ -0,0 +1,451 @@
+package core
+
+import (
+	"cmp"
+	"context"
+	"fmt"
+	"go/ast"
+	"go/token"
+	"math"
+	"slices"
+	"strconv"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+	"golang.org/x/tools/gopls/internal/file"
+	"golang.org/x/tools/gopls/internal/protocol"
+	"golang.org/x/tools/gopls/mcpbridge/api"
+)
+
+// ===== go_search_docs =====
+// Full-text search over the workspace symbols, ranked with BM25. The
+// documents are the symbols of the symbol index (see symbol_index.go), made
+// of the terms of:
+//
+//   - the symbol name, split on camelCase, with a higher weight;
+//   - the receiver or parent type name;
+//   - the doc comment;
+//   - the identifiers and string literals of the declaration.
+//
+// Terms are lower-cased, stop words are dropped, and a light stemmer maps
+// plurals and verb forms to the same term ("retries", "retried", "retrying"
+// are all "retry"). The inverted index is kept in sync with the symbol
+// index, so it is updated from the file watcher events without parsing
+// anything again, and works entirely offline.
+
+const (
+	// bm25K1 and bm25B are the usual BM25 parameters: term frequency
+	// saturation and document length normalization.
+	bm25K1 = 1.2
+	bm25B  = 0.75
+
+	// nameWeight is the weight of the terms of a symbol's name, relative to
+	// those of its other texts.
+	nameWeight = 3
+
+	// The limits of the identifiers and string literals recorded per
+	// symbol, so that generated tables do not bloat the index.
+	maxSymbolIdentifiers = 200
+	maxSymbolStrings     = 50
+	maxStringLength      = 200
+)
+
+// symbolText is the searchable text of a symbol, other than its name.
+type symbolText struct {
+	Doc         string   // doc comment
+	Identifiers []string // identifiers of the declaration, without duplicates
+	Strings     []string // string literals of the declaration, without duplicates
+}
+
+// newSymbolText returns the searchable text of the declaration node with
+// the given doc comment.
+func newSymbolText(doc *ast.CommentGroup, node ast.Node) symbolText {
+	var text symbolText
+	if doc != nil {
+		text.Doc = strings.TrimSpace(doc.Text())
+	}
+	seen := make(map[string]bool)
+	ast.Inspect(node, func(n ast.Node) bool {
+		switch n := n.(type) {
+		case *ast.CommentGroup:
+			return false
+		case *ast.Ident:
+			if len(text.Identifiers) < maxSymbolIdentifiers && len(n.Name) > 1 && !seen[n.Name] {
+				seen[n.Name] = true
+				text.Identifiers = append(text.Identifiers, n.Name)
+			}
+		case *ast.BasicLit:
+			if n.Kind != token.STRING || len(text.Strings) >= maxSymbolStrings {
+				break
+			}
+			s, err := strconv.Unquote(n.Value)
+			if err != nil || strings.TrimSpace(s) == "" {
+				break
+			}
+			if len(s) > maxStringLength {
+				s = s[:maxStringLength]
+			}
+			if !seen["\x00"+s] {
+				seen["\x00"+s] = true
+				text.Strings = append(text.Strings, s)
+			}
+		}
+		return true
+	})
+	return text
+}
+
+func handleGoSearchDocs(ctx context.Context, h *Handler, req *mcp.CallToolRequest, input api.ISearchDocsParams) (*mcp.CallToolResult, *api.OSearchDocsResult, error) {
+	if len(queryTerms(input.Query)) == 0 {
+		return nil, nil, fmt.Errorf("query is required: describe what you are looking for, e.g. \"retry backoff\"")
+	}
+	maxResults := input.MaxResults
+	if maxResults <= 0 {
+		maxResults = 10
+	}
+
+	snapshot, release, err := h.snapshotForDir(input.Cwd)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer release()
+
+	var overlays []file.Handle
+	for _, o := range snapshot.Overlays() {
+		overlays = append(overlays, o)
+	}
+	matches, err := h.symbolIndex(snapshot).searchDocs(ctx, overlays, input.Query)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to search docs: %v", err)
+	}
+
+	// Drop the matches in ignored files, and annotate the best ones with
+	// their package.
+	ignored := make(map[string]bool)
+	var results []api.DocSearchResult
+	for _, m := range matches {
+		path := m.doc.sym.FilePath
+		ignore, ok := ignored[path]
+		if !ok {
+			ignore = snapshot.IgnoredFile(protocol.URIFromPath(path))
+			ignored[path] = ignore
+		}
+		if ignore {
+			continue
+		}
+		sym := *m.doc.sym
+		sym.Doc = m.doc.text.Doc
+		results = append(results, api.DocSearchResult{
+			Symbol:  &sym,
+			Score:   math.Round(m.score*100) / 100,
+			Snippet: m.doc.snippet(input.Query),
+		})
+		if len(results) == maxResults {
+			break
+		}
+	}
+	if md, err := snapshot.LoadMetadataGraph(ctx); err == nil {
+		packages := filePackages(md)
+		symbols := make([]*api.Symbol, len(results))
+		for i, r := range results {
+			r.Symbol.PackagePath = packages[r.Symbol.FilePath].path
+			symbols[i] = r.Symbol
+		}
+		setSymbolHandles(ctx, snapshot, symbols)
+	}
+
+	var summary strings.Builder
+	if len(results) == 0 {
+		fmt.Fprintf(&summary, "No symbols found for %q.", input.Query)
+	} else {
+		fmt.Fprintf(&summary, "Found %d symbol(s) for %q, best first:\n", len(results), input.Query)
+		for _, r := range results {
+			fmt.Fprintf(&summary, "  - %s (%s in %s:%d, score %.2f)\n", qualifiedSymbolName(r.Symbol), r.Symbol.Kind, r.Symbol.FilePath, r.Symbol.Line, r.Score)
+			if r.Snippet != "" {
+				fmt.Fprintf(&summary, "      %s\n", r.Snippet)
+			}
+		}
+	}
+
+	result := &api.OSearchDocsResult{
+		Summary: summary.String(),
+		Results: results,
+	}
+	return &mcp.CallToolResult{Content: []mcp.Content{&mcp.TextContent{Text: result.Summary}}}, result, nil
+}
+
+// searchDocs returns the symbols matching the query under the index root,
+// best first, after bringing the index and its inverted index up to date.
+func (idx *symbolIndex) searchDocs(ctx context.Context, overlays []file.Handle, query string) ([]docMatch, error) {
+	idx.mu.Lock()
+	defer idx.mu.Unlock()
+
+	files, err := idx.currentFiles(ctx, overlays)
+	if err != nil {
+		return nil, err
+	}
+	if idx.docs == nil {
+		idx.docs = newDocIndex()
+	}
+	idx.docs.sync(files)
+	return idx.docs.search(query), nil
+}
+
+// docIndex is a BM25 inverted index over the symbols of a set of files.
+type docIndex struct {
+	indexed  map[string]*indexedFile       // the entry indexed for each path
+	docs     map[string][]*searchDoc       // the documents of each indexed path
+	postings map[string]map[*searchDoc]int // weighted term frequencies, by term and document
+	numDocs  int
+	totalLen int // sum of the document lengths
+}
+
+// searchDoc is a document of a docIndex: a symbol.
+type searchDoc struct {
+	sym   *api.Symbol
+	text  *symbolText
+	terms map[string]int // weighted term frequencies
+	len   int            // sum of the weighted term frequencies
+}
+
+// docMatch is a document matching a query, with its score.
+type docMatch struct {
+	doc   *searchDoc
+	score float64
+}
+
+func newDocIndex() *docIndex {
+	return &docIndex{
+		indexed:  make(map[string]*indexedFile),
+		docs:     make(map[string][]*searchDoc),
+		postings: make(map[string]map[*searchDoc]int),
+	}
+}
+
+// sync updates the index to contain the documents of exactly the given
+// files: the files that were removed or replaced by a new entry since the
+// last sync are removed, and the new entries are added.
+func (x *docIndex) sync(files map[string]*indexedFile) {
+	for path, f := range x.indexed {
+		if files[path] != f {
+			x.remove(path)
+		}
+	}
+	for path, f := range files {
+		if _, ok := x.indexed[path]; !ok {
+			x.add(path, f)
+		}
+	}
+}
+
+// add adds the documents of the file at path.
+func (x *docIndex) add(path string, f *indexedFile) {
+	x.indexed[path] = f
+	docs := make([]*searchDoc, 0, len(f.Symbols))
+	for i, sym := range f.Symbols {
+		doc := &searchDoc{sym: sym, terms: make(map[string]int)}
+		if i < len(f.Texts) {
+			doc.text = &f.Texts[i]
+		} else {
+			doc.text = &symbolText{}
+		}
+		addTerms := func(text string, weight int) {
+			forEachTerm(text, func(term string) {
+				doc.terms[term] += weight
+				doc.len += weight
+			})
+		}
+		addTerms(sym.Name, nameWeight)
+		addTerms(symbolParent(sym), 1)
+		addTerms(doc.text.Doc, 1)
+		for _, s := range slices.Concat(doc.text.Identifiers, doc.text.Strings) {
+			addTerms(s, 1)
+		}
+		for term, tf := range doc.terms {
+			postings := x.postings[term]
+			if postings == nil {
+				postings = make(map[*searchDoc]int)
+				x.postings[term] = postings
+			}
+			postings[doc] = tf
+		}
+		x.numDocs++
+		x.totalLen += doc.len
+		docs = append(docs, doc)
+	}
+	x.docs[path] = docs
+}
+
+// remove removes the documents of the file at path.
+func (x *docIndex) remove(path string) {
+	for _, doc := range x.docs[path] {
+		for term := range doc.terms {
+			postings := x.postings[term]
+			delete(postings, doc)
+			if len(postings) == 0 {
+				delete(x.postings, term)
+			}
+		}
+		x.numDocs--
+		x.totalLen -= doc.len
+	}
+	delete(x.docs, path)
+	delete(x.indexed, path)
+}
+
+// search returns the documents containing terms of the query, by
+// decreasing BM25 score.
+func (x *docIndex) search(query string) []docMatch {
+	if x.numDocs == 0 {
+		return nil
+	}
+	avgLen := float64(x.totalLen) / float64(x.numDocs)
+	scores := make(map[*searchDoc]float64)
+	for _, term := range queryTerms(query) {
+		postings := x.postings[term]
+		df := float64(len(postings))
+		idf := math.Log(1 + (float64(x.numDocs)-df+0.5)/(df+0.5))
+		for doc, tf := range postings {
+			norm := bm25K1 * (1 - bm25B + bm25B*float64(doc.len)/avgLen)
+			scores[doc] += idf * float64(tf) * (bm25K1 + 1) / (float64(tf) + norm)
+		}
+	}
+
+	matches := make([]docMatch, 0, len(scores))
+	for doc, score := range scores {
+		matches = append(matches, docMatch{doc, score})
+	}
+	slices.SortFunc(matches, func(a, b docMatch) int {
+		return cmp.Or(
+			cmp.Compare(b.score, a.score),
+			cmp.Compare(a.doc.sym.Name, b.doc.sym.Name),
+			cmp.Compare(a.doc.sym.FilePath, b.doc.sym.FilePath),
+			cmp.Compare(a.doc.sym.Line, b.doc.sym.Line),
+		)
+	})
+	return matches
+}
+
+// snippet returns the sentence of the doc comment or the string literal
+// that contains the most terms of the query, preferring the doc comment,
+// or else the first sentence of the doc comment.
+func (doc *searchDoc) snippet(query string) string {
+	terms := make(map[string]bool)
+	for _, term := range queryTerms(query) {
+		terms[term] = true
+	}
+	hits := func(text string) int {
+		n := 0
+		forEachTerm(text, func(term string) {
+			if terms[term] {
+				n++
+			}
+		})
+		return n
+	}
+
+	var best string
+	bestHits := 0
+	for sentence := range strings.SplitSeq(strings.ReplaceAll(doc.text.Doc, "\n", " "), ". ") {
+		if n := hits(sentence); n > bestHits {
+			best, bestHits = sentence, n
+		}
+	}
+	for _, s := range doc.text.Strings {
+		if n := hits(s); n > bestHits {
+			best, bestHits = strconv.Quote(s), n
+		}
+	}
+	if best == "" && doc.text.Doc != "" {
+		best, _, _ = strings.Cut(doc.text.Doc, "\n")
+	}
+	best = strings.TrimSpace(best)
+	if len(best) > 160 {
+		cut := 157
+		for cut > 0 && !utf8.RuneStart(best[cut]) {
+			cut--
+		}
+		best = best[:cut] + "..."
+	}
+	return best
+}
+
+// queryTerms returns the distinct terms of a query.
+func queryTerms(query string) []string {
+	var terms []string
+	forEachTerm(query, func(term string) {
+		if !slices.Contains(terms, term) {
+			terms = append(terms, term)
+		}
+	})
+	return terms
+}
+
+// forEachTerm calls fn for each term of text: its words, split on
+// non-alphanumeric characters and camelCase boundaries ("parseHTTPRequest"
+// is "parse", "http" and "request"), lower-cased and stemmed, except stop
+// words.
+func forEachTerm(text string, fn func(term string)) {
+	emit := func(word string) {
+		word = strings.ToLower(word)
+		if len(word) > 1 && !stopWords[word] {
+			fn(stem(word))
+		}
+	}
+	runes := []rune(text)
+	start := -1
+	for i, r := range runes {
+		if !unicode.IsLetter(r) && !unicode.IsDigit(r) {
+			if start >= 0 {
+				emit(string(runes[start:i]))
+				start = -1
+			}
+			continue
+		}
+		if start >= 0 && i > start && unicode.IsUpper(r) {
+			prev := runes[i-1]
+			// "fooBar" -> foo|Bar; "HTTPServer" -> HTTP|Server
+			if unicode.IsLower(prev) || unicode.IsDigit(prev) ||
+				unicode.IsUpper(prev) && i+1 < len(runes) && unicode.IsLower(runes[i+1]) {
+				emit(string(runes[start:i]))
+				start = i
+			}
+		}
+		if start < 0 {
+			start = i
+		}
+	}
+	if start >= 0 {
+		emit(string(runes[start:]))
+	}
+}
+
+// stem maps the common English inflections of a lower-case word to the same
+// term: "retries", "retried" and "retrying" to "retry", "caches",
+// "cached" and "caching" to "cach".
+func stem(word string) string {
+	switch {
+	case len(word) > 4 && (strings.HasSuffix(word, "ies") || strings.HasSuffix(word, "ied")):
+		return word[:len(word)-3] + "y"
+	case len(word) > 5 && strings.HasSuffix(word, "ing"):
+		word = word[:len(word)-3]
+	case len(word) > 4 && strings.HasSuffix(word, "ed"):
+		word = word[:len(word)-2]
+	case len(word) > 3 && strings.HasSuffix(word, "s") && !strings.HasSuffix(word, "ss"):
+		word = word[:len(word)-1]
+	}
+	if len(word) > 4 && strings.HasSuffix(word, "e") {
+		word = word[:len(word)-1]
+	}
+	return word
+}
+
+// stopWords are the common English words that are not indexed.
+var stopWords = map[string]bool{
+	"a": true, "an": true, "and": true, "are": true, "as": true, "at": true, "be": true,
+	"by": true, "for": true, "from": true, "if": true, "in": true, "is": true, "it": true,
+	"its": true, "of": true, "on": true, "or": true, "that": true, "the": true, "this": true,
+	"to": true, "was": true, "which": true, "will": true, "with": true,
+}
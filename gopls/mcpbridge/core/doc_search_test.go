@@ -0,0 +1,56 @@
+package core
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestForEachTerm(t *testing.T) {
+	for _, test := range []struct {
+		text string
+		want []string
+	}{
+		{"", nil},
+		{"parseHTTPRequest", []string{"pars", "http", "request"}},
+		{"HTTPServer", []string{"http", "server"}},
+		{"utf8Decode", []string{"utf8", "decod"}},
+		{"snake_case-words", []string{"snak", "case", "word"}},
+		{"the retries of a cache", []string{"retry", "cach"}},
+		{"x y2 go", []string{"y2", "go"}}, // single letters are dropped
+		{"Größe ändern", []string{"größ", "ändern"}},
+	} {
+		var got []string
+		forEachTerm(test.text, func(term string) { got = append(got, term) })
+		if !slices.Equal(got, test.want) {
+			t.Errorf("forEachTerm(%q) = %q, want %q", test.text, got, test.want)
+		}
+	}
+}
+
+func TestStem(t *testing.T) {
+	for _, test := range []struct {
+		word, want string
+	}{
+		{"retry", "retry"},
+		{"retries", "retry"},
+		{"retried", "retry"},
+		{"retrying", "retry"},
+		{"cache", "cach"},
+		{"caches", "cach"},
+		{"cached", "cach"},
+		{"caching", "cach"},
+		{"parse", "pars"},
+		{"parses", "pars"},
+		{"parsed", "pars"},
+		{"parsing", "pars"},
+		{"class", "class"},
+		{"lies", "lie"},
+		{"bus", "bus"},
+		{"used", "used"},
+		{"go", "go"},
+	} {
+		if got := stem(test.word); got != test.want {
+			t.Errorf("stem(%q) = %q, want %q", test.word, got, test.want)
+		}
+	}
+}
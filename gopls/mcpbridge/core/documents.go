@@ -0,0 +1,121 @@
+package core
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+	"golang.org/x/tools/gopls/internal/file"
+	"golang.org/x/tools/gopls/internal/protocol"
+	"golang.org/x/tools/gopls/mcpbridge/api"
+)
+
+// ===== go_sync_document =====
+// Client-pushed documents: editor-integrated agents push the content of their
+// unsaved buffers, which are stored as overlays in the cache.Session exactly
+// like textDocument/didOpen, didChange and didClose in gopls. All other tools
+// then answer against the buffers instead of the files on disk.
+
+func handleGoSyncDocument(ctx context.Context, h *Handler, req *mcp.CallToolRequest, input api.ISyncDocumentParams) (*mcp.CallToolResult, *api.OSyncDocumentResult, error) {
+	if !filepath.IsAbs(input.Path) {
+		return nil, nil, fmt.Errorf("path must be absolute: %q", input.Path)
+	}
+	if input.Cwd != "" {
+		// Make sure a view exists for the document before modifying it.
+		if _, err := h.viewForDir(input.Cwd); err != nil {
+			return nil, nil, err
+		}
+	}
+
+	// Hold the lock until the document is recorded, so that concurrent
+	// syncs of a document see each other's versions.
+	uri := protocol.URIFromPath(input.Path)
+	h.openDocumentsMu.Lock()
+	mod, err := h.documentModificationLocked(uri, strings.ToLower(input.Action), input.Content, input.Version)
+	if err == nil {
+		if _, err = h.session.DidModifyFiles(ctx, []file.Modification{mod}); err != nil {
+			err = fmt.Errorf("failed to %s document: %v", input.Action, err)
+		}
+	}
+	if err != nil {
+		h.openDocumentsMu.Unlock()
+		return nil, nil, err
+	}
+	if mod.Action == file.Close {
+		delete(h.openDocuments, uri)
+	} else {
+		h.openDocuments[uri] = mod.Version
+	}
+	openDocs := h.listOpenDocumentsLocked()
+	h.openDocumentsMu.Unlock()
+
+	var summary strings.Builder
+	switch mod.Action {
+	case file.Close:
+		fmt.Fprintf(&summary, "Closed %s. Tools now read it from disk.\n", input.Path)
+	default:
+		fmt.Fprintf(&summary, "Synchronized %s (version %d, %d bytes). Tools now use this buffer instead of the file on disk.\n", input.Path, mod.Version, len(mod.Text))
+	}
+	fmt.Fprintf(&summary, "Open documents: %d\n", len(openDocs))
+	for _, doc := range openDocs {
+		fmt.Fprintf(&summary, "  - %s (version %d)\n", doc.Path, doc.Version)
+	}
+
+	result := &api.OSyncDocumentResult{
+		Summary:       summary.String(),
+		OpenDocuments: openDocs,
+	}
+	return &mcp.CallToolResult{Content: []mcp.Content{&mcp.TextContent{Text: summary.String()}}}, result, nil
+}
+
+// documentModificationLocked converts a document sync request into a
+// session file modification. A change to a document that is not open is
+// treated as an open, so clients don't have to track which buffers were
+// already pushed. h.openDocumentsMu must be held until the modification is
+// applied and recorded in h.openDocuments.
+func (h *Handler) documentModificationLocked(uri protocol.DocumentURI, action, content string, version int32) (file.Modification, error) {
+	prevVersion, isOpen := h.openDocuments[uri]
+
+	if version == 0 {
+		version = prevVersion + 1
+	}
+
+	switch action {
+	case "open", "change":
+		fileAction := file.Change
+		if !isOpen {
+			fileAction = file.Open
+		}
+		return file.Modification{
+			URI:     uri,
+			Action:  fileAction,
+			Version: version,
+			Text:    []byte(content),
+		}, nil
+	case "close":
+		if !isOpen {
+			return file.Modification{}, fmt.Errorf("document is not open: %s", uri.Path())
+		}
+		return file.Modification{
+			URI:     uri,
+			Action:  file.Close,
+			Version: -1,
+		}, nil
+	default:
+		return file.Modification{}, fmt.Errorf("invalid action %q: must be one of open, change, close", action)
+	}
+}
+
+// listOpenDocumentsLocked returns the open documents sorted by path.
+// h.openDocumentsMu must be held.
+func (h *Handler) listOpenDocumentsLocked() []api.OpenDocument {
+	docs := make([]api.OpenDocument, 0, len(h.openDocuments))
+	for uri, version := range h.openDocuments {
+		docs = append(docs, api.OpenDocument{Path: uri.Path(), Version: version})
+	}
+	sort.Slice(docs, func(i, j int) bool { return docs[i].Path < docs[j].Path })
+	return docs
+}
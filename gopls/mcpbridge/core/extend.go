@@ -0,0 +1,206 @@
+package core
+
+import (
+	"context"
+	"fmt"
+	"go/ast"
+	"go/token"
+	"go/types"
+
+	"golang.org/x/tools/gopls/internal/cache"
+	"golang.org/x/tools/gopls/internal/golang"
+	"golang.org/x/tools/gopls/internal/protocol"
+	"golang.org/x/tools/gopls/internal/util/safetoken"
+	"golang.org/x/tools/gopls/mcpbridge/api"
+)
+
+// Custom tools: programs embedding gopls-mcp (see pkg.NewServer) can serve
+// their own tools next to the built-in ones. A custom tool is usually a
+// GenericTool with Doc set; like the built-in tools, its handler receives
+// the Handler, which gives access to the workspace through Snapshot, and
+// its responses are limited to max_response_bytes. Snapshot wraps the
+// internal gopls snapshot, which custom tools cannot import.
+
+// WithTools adds custom tools to the handler. They are registered by
+// RegisterTools and listed by go_list_tools. A custom tool with the name of
+// a built-in tool replaces it.
+func WithTools(tools ...Tool) HandlerOption {
+	return func(h *Handler) {
+		h.customTools = append(h.customTools, tools...)
+	}
+}
+
+// allTools returns the built-in tools, except those replaced by custom
+// tools, followed by the custom tools.
+func (h *Handler) allTools() []Tool {
+	if len(h.customTools) == 0 {
+		return getTools()
+	}
+	custom := make(map[string]bool)
+	for _, tool := range h.customTools {
+		name, _ := tool.Details()
+		custom[name] = true
+	}
+	var all []Tool
+	for _, tool := range getTools() {
+		if name, _ := tool.Details(); !custom[name] {
+			all = append(all, tool)
+		}
+	}
+	return append(all, h.customTools...)
+}
+
+// toolCategory returns the go_list_tools category of a tool: the Category
+// of a GenericTool, if set, or a category derived from its name.
+func toolCategory(tool Tool) string {
+	if c, ok := tool.(interface{ category() string }); ok {
+		if category := c.category(); category != "" {
+			return category
+		}
+	}
+	name, _ := tool.Details()
+	return categorizeTool(name)
+}
+
+// Config returns the gopls-mcp configuration of the handler.
+func (h *Handler) Config() *MCPConfig {
+	return h.config
+}
+
+// Snapshot is an immutable view of a workspace, for custom tools: its
+// files, including the unsaved changes of the session, and the packages
+// type-checked from them.
+type Snapshot struct {
+	snapshot *cache.Snapshot
+}
+
+// Snapshot returns the current snapshot of the view containing dir, or of
+// the default view if dir is empty. The snapshot must be released by calling
+// the returned function when it is no longer used.
+func (h *Handler) Snapshot(dir string) (*Snapshot, func(), error) {
+	snapshot, release, err := h.snapshotForDir(dir)
+	if err != nil {
+		return nil, nil, err
+	}
+	return &Snapshot{snapshot: snapshot}, release, nil
+}
+
+// Dir returns the root directory of the snapshot's workspace.
+func (s *Snapshot) Dir() string {
+	return s.snapshot.Folder().Path()
+}
+
+// ReadFile returns the content of a file, with the unsaved changes of the
+// session.
+func (s *Snapshot) ReadFile(ctx context.Context, path string) ([]byte, error) {
+	fh, err := s.snapshot.ReadFile(ctx, protocol.URIFromPath(path))
+	if err != nil {
+		return nil, err
+	}
+	return fh.Content()
+}
+
+// Package is a parsed and type-checked package.
+type Package struct {
+	// PkgPath is the import path of the package.
+	PkgPath string
+	// Fset is the file set of the package's syntax.
+	Fset *token.FileSet
+	// Syntax are the parsed files of the package.
+	Syntax []*ast.File
+	// Types is the type-checked package.
+	Types *types.Package
+	// TypesInfo is the type information of the package's syntax.
+	TypesInfo *types.Info
+}
+
+// Package returns the package containing the Go file at path. If the file
+// belongs to several packages (e.g. a package and its test variant), it is
+// the narrowest one.
+func (s *Snapshot) Package(ctx context.Context, path string) (*Package, error) {
+	pkg, _, err := golang.NarrowestPackageForFile(ctx, s.snapshot, protocol.URIFromPath(path))
+	if err != nil {
+		return nil, fmt.Errorf("failed to get package: %w", err)
+	}
+	return &Package{
+		PkgPath:   string(pkg.Metadata().PkgPath),
+		Fset:      pkg.FileSet(),
+		Syntax:    pkg.Syntax(),
+		Types:     pkg.Types(),
+		TypesInfo: pkg.TypesInfo(),
+	}, nil
+}
+
+// Diagnostics returns the diagnostics of the workspace packages, as
+// reported by go_build_check.
+func (s *Snapshot) Diagnostics(ctx context.Context) ([]api.Diagnostic, error) {
+	diagnostics, _, err := collectWorkspaceDiagnostics(ctx, s.snapshot)
+	return diagnostics, err
+}
+
+// ResolvedSymbol is a symbol resolved from an api.SymbolLocator.
+type ResolvedSymbol struct {
+	// Node is the AST node of the symbol (e.g. *ast.Ident, *ast.FuncDecl).
+	Node ast.Node
+	// Object is the type-checker object of the symbol, or nil if the
+	// package could not be type-checked.
+	Object types.Object
+	// Position is the position of the symbol in its file.
+	Position token.Position
+	// EnclosingFunc is the name of the function containing the symbol, if
+	// any.
+	EnclosingFunc string
+	// IsDefinition reports whether the node defines the symbol (rather
+	// than referring to it).
+	IsDefinition bool
+	// Candidates are the distinct symbols an ambiguous locator matched,
+	// this one first.
+	Candidates []api.LocatorCandidate
+}
+
+// ResolveSymbol resolves a semantic symbol locator, the same way the
+// built-in tools taking a locator do. If no symbol matches, the error
+// suggests the most similar symbols.
+func (s *Snapshot) ResolveSymbol(ctx context.Context, locator api.SymbolLocator) (*ResolvedSymbol, error) {
+	snapshot := s.snapshot
+	locator, _, err := golang.ResolveLocator(ctx, snapshot, locator)
+	if err != nil {
+		return nil, err
+	}
+	uri := protocol.URIFromPath(locator.ContextFile)
+	fh, err := snapshot.ReadFile(ctx, uri)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read file %s: %v", locator.ContextFile, err)
+	}
+	node, err := golang.ResolveNode(ctx, snapshot, fh, locator)
+	if err != nil {
+		return nil, fmt.Errorf("failed to resolve symbol '%s': %v", locator.SymbolName, err)
+	}
+	pkg, _, err := golang.NarrowestPackageForFile(ctx, snapshot, uri)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get package: %w", err)
+	}
+	return &ResolvedSymbol{
+		Node:          node.Node,
+		Object:        node.Object,
+		Position:      safetoken.StartPosition(pkg.FileSet(), node.Pos),
+		EnclosingFunc: node.EnclosingFunc,
+		IsDefinition:  node.IsDefinition,
+		Candidates:    node.Candidates,
+	}, nil
+}
+
+// References returns the references to the symbol of a locator in the
+// workspace, excluding its declaration, as reported by
+// go_symbol_references.
+func (s *Snapshot) References(ctx context.Context, locator api.SymbolLocator) ([]api.Reference, error) {
+	locator, _, err := golang.ResolveLocator(ctx, s.snapshot, locator)
+	if err != nil {
+		return nil, err
+	}
+	found, err := findReferences(ctx, s.snapshot, locator)
+	if err != nil {
+		return nil, err
+	}
+	return found.refs, nil
+}
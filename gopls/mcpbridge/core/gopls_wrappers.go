@@ -1,22 +1,21 @@
 package core
 
 import (
+	"cmp"
 	"context"
 	"fmt"
 	"go/ast"
 	"go/token"
 	"go/types"
-	"log"
-	"os"
 	"path/filepath"
-	"runtime"
+	"slices"
 	"strings"
-	"sync"
 
 	"github.com/modelcontextprotocol/go-sdk/mcp"
 	"golang.org/x/tools/gopls/internal/cache"
 	"golang.org/x/tools/gopls/internal/cache/metadata"
 	"golang.org/x/tools/gopls/internal/cache/parsego"
+	"golang.org/x/tools/gopls/internal/file"
 	"golang.org/x/tools/gopls/internal/golang"
 	"golang.org/x/tools/gopls/internal/protocol"
 	"golang.org/x/tools/gopls/internal/util/safetoken"
@@ -37,28 +36,11 @@ func handleGetPackageSymbolDetail(ctx context.Context, h *Handler, req *mcp.Call
 	if len(input.SymbolFilters) == 0 {
 		return nil, nil, fmt.Errorf("symbol_filters is required for get_package_symbol_detail (this is a precision tool). Use list_package_symbols to get all symbols in a package")
 	}
-	var snapshot *cache.Snapshot
-	var release func()
-	var err error
-
-	// Use Cwd if provided, otherwise use default view
-	if input.Cwd != "" {
-		view, err := h.viewForDir(input.Cwd)
-		if err != nil {
-			return nil, nil, err
-		}
-		snapshot, release, err = view.Snapshot()
-		if err != nil {
-			return nil, nil, err
-		}
-		defer release()
-	} else {
-		snapshot, release, err = h.snapshot()
-		if err != nil {
-			return nil, nil, err
-		}
-		defer release()
+	snapshot, release, err := h.snapshotForDir(input.Cwd)
+	if err != nil {
+		return nil, nil, err
 	}
+	defer release()
 
 	md, err := snapshot.LoadMetadataGraph(ctx)
 	if err != nil {
@@ -97,6 +79,12 @@ func handleGetPackageSymbolDetail(ctx context.Context, h *Handler, req *mcp.Call
 			continue
 		}
 
+		// The type-checked package of the file, for the symbol handles
+		typed, typedFile, err := golang.NarrowestPackageForFile(ctx, snapshot, uri)
+		if err != nil {
+			typed = nil
+		}
+
 		// Build a map of symbol positions to docs/bodies from AST
 		docMap := make(map[string]string)
 		bodyMap := make(map[string]string)
@@ -181,6 +169,7 @@ func handleGetPackageSymbolDetail(ctx context.Context, h *Handler, req *mcp.Call
 			}
 
 			converted := convertDocumentSymbol(sym, uri.Path(), input.PackagePath)
+			converted.Handle = documentSymbolHandle(typed, typedFile, sym, converted.Name)
 
 			// Add documentation from AST
 			if includeDocs {
@@ -352,32 +341,38 @@ func formatPackageSymbolsForAPI(result *api.OListPackageSymbols, includeDocs, in
 // Origin: gopls/internal/mcp/workspace_diagnostics.go workspaceDiagnosticsHandler()
 
 func handleGoDiagnostics(ctx context.Context, h *Handler, req *mcp.CallToolRequest, input api.IDiagnosticsParams) (*mcp.CallToolResult, *api.ODiagnosticsResult, error) {
-	var snapshot *cache.Snapshot
-	var release func()
-	var err error
+	snapshot, release, err := h.snapshotForDir(input.Cwd)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer release()
 
-	// Use Cwd if provided, otherwise use default view
-	if input.Cwd != "" {
-		view, err := h.viewForDir(input.Cwd)
-		if err != nil {
-			return nil, nil, err
-		}
-		snapshot, release, err = view.Snapshot()
-		if err != nil {
-			return nil, nil, err
-		}
-		defer release()
+	diagnostics, numPackages, err := collectWorkspaceDiagnostics(ctx, snapshot)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var summary strings.Builder
+	if len(diagnostics) == 0 {
+		summary.WriteString(fmt.Sprintf("Workspace diagnostics checked for %d packages. No issues found.", numPackages))
 	} else {
-		snapshot, release, err = h.snapshot()
-		if err != nil {
-			return nil, nil, err
-		}
-		defer release()
+		writeDiagnosticsList(&summary, diagnostics)
 	}
 
+	result := &api.ODiagnosticsResult{
+		Summary:     summary.String(),
+		Diagnostics: diagnostics,
+	}
+	return &mcp.CallToolResult{Content: []mcp.Content{&mcp.TextContent{Text: summary.String()}}}, result, nil
+}
+
+// collectWorkspaceDiagnostics type-checks all workspace packages of the
+// snapshot and returns their deduplicated diagnostics, along with the
+// number of packages checked.
+func collectWorkspaceDiagnostics(ctx context.Context, snapshot *cache.Snapshot) ([]api.Diagnostic, int, error) {
 	// Ensure metadata is loaded. This is critical for populating the workspace.
 	if _, err := snapshot.LoadMetadataGraph(ctx); err != nil {
-		return nil, nil, fmt.Errorf("failed to load metadata: %v", err)
+		return nil, 0, fmt.Errorf("failed to load metadata: %v", err)
 	}
 
 	// Get workspace package IDs
@@ -390,14 +385,13 @@ func handleGoDiagnostics(ctx context.Context, h *Handler, req *mcp.CallToolReque
 	// Get diagnostics (returns map[URI][]diagnostics)
 	reports, err := snapshot.PackageDiagnostics(ctx, ids...)
 	if err != nil {
-		return nil, nil, fmt.Errorf("diagnostics failed: %v", err)
+		return nil, 0, fmt.Errorf("diagnostics failed: %v", err)
 	}
 
 	// Deduplicate diagnostics using native gopls hash
 	// This matches the exact deduplication behavior of native gopls
 	seen := make(map[string]struct{})
 	var diagnostics []api.Diagnostic
-	var summary strings.Builder
 
 	// Iterate by file URI (like native gopls)
 	for _, diags := range reports {
@@ -451,26 +445,20 @@ func handleGoDiagnostics(ctx context.Context, h *Handler, req *mcp.CallToolReque
 		}
 	}
 
-	// Format summary (per file, like native gopls)
-	if len(diagnostics) == 0 {
-		summary.WriteString(fmt.Sprintf("Workspace diagnostics checked for %d packages. No issues found.", len(ids)))
-	} else {
-		summary.WriteString(fmt.Sprintf("Found %d unique diagnostic(s):\n", len(diagnostics)))
-		for _, diag := range diagnostics {
-			locInfo := fmt.Sprintf("%s:%d:%d", diag.File, diag.Line, diag.Column)
-			if diag.CodeSnippet != "" {
-				summary.WriteString(fmt.Sprintf("- %s: %s\n  Code: %s\n  [%s]\n", locInfo, diag.Message, diag.CodeSnippet, diag.Severity))
-			} else {
-				summary.WriteString(fmt.Sprintf("- %s: %s (%s)\n", locInfo, diag.Message, diag.Severity))
-			}
-		}
-	}
+	return diagnostics, len(ids), nil
+}
 
-	result := &api.ODiagnosticsResult{
-		Summary:     summary.String(),
-		Diagnostics: diagnostics,
+// writeDiagnosticsList formats diagnostics per file, like native gopls.
+func writeDiagnosticsList(summary *strings.Builder, diagnostics []api.Diagnostic) {
+	summary.WriteString(fmt.Sprintf("Found %d unique diagnostic(s):\n", len(diagnostics)))
+	for _, diag := range diagnostics {
+		locInfo := fmt.Sprintf("%s:%d:%d", diag.File, diag.Line, diag.Column)
+		if diag.CodeSnippet != "" {
+			summary.WriteString(fmt.Sprintf("- %s: %s\n  Code: %s\n  [%s]\n", locInfo, diag.Message, diag.CodeSnippet, diag.Severity))
+		} else {
+			summary.WriteString(fmt.Sprintf("- %s: %s (%s)\n", locInfo, diag.Message, diag.Severity))
+		}
 	}
-	return &mcp.CallToolResult{Content: []mcp.Content{&mcp.TextContent{Text: summary.String()}}}, result, nil
 }
 
 // ===== go_search =====
@@ -486,36 +474,25 @@ func handleGoSearch(ctx context.Context, h *Handler, req *mcp.CallToolRequest, i
 		return &mcp.CallToolResult{Content: []mcp.Content{&mcp.TextContent{Text: result.Summary}}}, result, nil
 	}
 
-	// Validate query: go_search only accepts symbol names (no spaces or dots)
+	// Validate query: go_search only accepts symbol names, optionally
+	// qualified by a type or package name (no spaces).
 	// This prevents natural language queries which don't work with symbol search
-	if strings.Contains(input.Query, " ") || strings.Contains(input.Query, ".") {
-		return nil, nil, fmt.Errorf("invalid query: go_search accepts only a single symbol name (no spaces or dots). query=%q", input.Query)
+	if strings.Contains(input.Query, " ") || !validSearchQuery(input.Query) {
+		return nil, nil, fmt.Errorf("invalid query: go_search accepts only a symbol name or a qualified name such as Server.Start (no spaces). query=%q", input.Query)
 	}
 
-	var snapshot *cache.Snapshot
-	var release func()
-	var err error
+	snapshot, release, err := h.snapshotForDir(input.Cwd)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer release()
 
-	// Use Cwd if provided, otherwise use default view
-	if input.Cwd != "" {
-		view, err := h.viewForDir(input.Cwd)
-		if err != nil {
-			return nil, nil, err
-		}
-		snapshot, release, err = view.Snapshot()
-		if err != nil {
-			return nil, nil, err
-		}
-		defer release()
-	} else {
-		snapshot, release, err = h.snapshot()
-		if err != nil {
-			return nil, nil, err
-		}
-		defer release()
+	filter, err := newSearchFilter(input, snapshot.View().Root().Path())
+	if err != nil {
+		return nil, nil, err
 	}
 
-	symbols, err := searchProjectFiles(ctx, snapshot, input.Query, input.MaxResults)
+	symbols, err := searchProjectFiles(ctx, h, snapshot, input.Query, filter, input.IncludeDependencies, input.MaxResults)
 	if err != nil {
 		return nil, nil, fmt.Errorf("failed to search project files: %v", err)
 	}
@@ -529,163 +506,351 @@ func handleGoSearch(ctx context.Context, h *Handler, req *mcp.CallToolRequest, i
 	}, nil
 }
 
-// searchProjectFiles searches for symbols in workspace files by parsing them directly.
-// This bypasses the LSP server and parseCache for better memory efficiency.
+// validSearchQuery reports whether query is a name, or a name qualified by
+// a single non-empty qualifier ("Server.Start").
+func validSearchQuery(query string) bool {
+	qualifier, name, ok := strings.Cut(query, ".")
+	return !ok || qualifier != "" && name != "" && !strings.Contains(name, ".")
+}
+
+// searchProjectFiles searches for symbols in workspace files, and in the
+// dependencies if includeDeps is set.
 //
 // Strategy:
-// 1. Get workspace packages from snapshot (metadata graph already filtered)
-// 2. Collect Go files from workspace packages only (no dependencies)
-// 3. Filter out ignored files (testdata, hidden files, etc.)
-// 4. Parse each file directly using cache.ParseGoImpl (no caching)
-// 5. Extract symbols and fuzzy match against query
+// 1. Get the symbols of the workspace files from the symbol index of the
+// view root (see symbol_index.go), which only parses new and changed files
+// 2. Fuzzy match symbols against query
+// 3. Filter out ignored files (testdata, hidden files, directory filters)
+// 4. Annotate the matches with their package, and apply the qualifier of
+// the query and the filters
+// 5. Likewise for the files of the dependencies, whose matches are listed
+// after those of the workspace
 // 6. Return top matches
-func searchProjectFiles(ctx context.Context, snapshot *cache.Snapshot, query string, maxResults int) ([]*api.Symbol, error) {
+func searchProjectFiles(ctx context.Context, h *Handler, snapshot *cache.Snapshot, query string, filter *searchFilter, includeDeps bool, maxResults int) ([]*api.Symbol, error) {
 	if maxResults <= 0 {
 		maxResults = 10
 	}
+	qualifier, name, ok := strings.Cut(query, ".")
+	if !ok {
+		qualifier, name = "", query
+	}
 
-	root := snapshot.View().Root().Path()
-	fileURIs := collectGoFilesFromFS(root)
-	filteredURIs := filterIgnoredFiles(snapshot, fileURIs)
-	symbols := extractSymbolsFromFiles(ctx, snapshot, filteredURIs)
-	matched := fuzzyMatchSymbols(symbols, query)
-	matched = sortAndLimitSymbols(matched, maxResults)
-
-	return matched, nil
-}
+	var overlays []file.Handle
+	for _, o := range snapshot.Overlays() {
+		overlays = append(overlays, o)
+	}
+	symbols, err := h.symbolIndex(snapshot).symbols(ctx, overlays)
+	if err != nil {
+		return nil, err
+	}
+	md, err := snapshot.LoadMetadataGraph(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load metadata graph: %v", err)
+	}
+	packages := filePackages(md)
 
-// collectGoFilesFromFS walks the filesystem to find all .go files in the workspace.
-// This bypasses the gopls snapshot's lazy-loaded metadata graph.
-// Note: Filtering is done later by filterIgnoredFiles() which uses snapshot.IgnoredFile().
-func collectGoFilesFromFS(rootPath string) map[protocol.DocumentURI]bool {
-	seenFiles := make(map[protocol.DocumentURI]bool)
+	matched := fuzzyMatchSymbols(symbols, name)
+	matched = filterIgnoredSymbols(snapshot, matched)
+	matched = filter.apply(withPackages(matched, packages), packages, qualifier)
+	sortSymbols(matched)
 
-	err := filepath.Walk(rootPath, func(path string, info os.FileInfo, err error) error {
+	if includeDeps {
+		deps, err := searchDependencies(ctx, h, snapshot, md, name)
 		if err != nil {
-			return nil
+			return nil, err
 		}
+		deps = filter.apply(withPackages(deps, packages), packages, qualifier)
+		sortSymbols(deps)
+		matched = append(matched, deps...)
+	}
 
-		if info.Mode()&os.ModeSymlink != 0 {
-			if info.IsDir() {
-				return filepath.SkipDir
-			}
-			return nil
-		}
+	if len(matched) > maxResults {
+		matched = matched[:maxResults]
+	}
+	setSymbolHandles(ctx, snapshot, matched)
+	return matched, nil
+}
 
-		if info.IsDir() {
-			return nil
+// searchDependencies returns the symbols of the packages outside of the
+// workspace (dependencies and standard library) whose name matches query.
+func searchDependencies(ctx context.Context, h *Handler, snapshot *cache.Snapshot, md *metadata.Graph, query string) ([]*api.Symbol, error) {
+	var (
+		paths []string
+		seen  = make(map[metadata.PackagePath]bool)
+	)
+	for _, mp := range md.Packages {
+		if mp.ForTest != "" || seen[mp.PkgPath] || snapshot.IsWorkspacePackage(mp.ID) {
+			continue
 		}
-
-		if filepath.Ext(path) == ".go" {
-			uri := protocol.URIFromPath(path)
-			seenFiles[uri] = true
+		seen[mp.PkgPath] = true
+		for _, uri := range mp.GoFiles {
+			paths = append(paths, uri.Path())
 		}
+	}
 
-		return nil
-	})
-
+	files, err := h.depSymbols.symbols(ctx, paths)
 	if err != nil {
-		log.Printf("warning: error walking directory tree: %v", err)
+		return nil, err
 	}
-
-	return seenFiles
+	var symbols []*api.Symbol
+	for _, fileSymbols := range files {
+		symbols = append(symbols, fileSymbols...)
+	}
+	return fuzzyMatchSymbols(symbols, query), nil
 }
 
-// filterIgnoredFiles filters out files that should be ignored per Go conventions:
-// - Files in testdata directories
-// - Files starting with '.' or '_' in any path segment
-// - Files filtered by user's directory filters
-func filterIgnoredFiles(snapshot *cache.Snapshot, uris map[protocol.DocumentURI]bool) []protocol.DocumentURI {
-	var filtered []protocol.DocumentURI
+// symbolPackage describes the package of a file.
+type symbolPackage struct {
+	path    string // package path
+	forTest string // package path of the package under test, for test variants
+	name    string // package name
+}
 
-	for uri := range uris {
-		if snapshot.IgnoredFile(uri) {
-			continue
+// filePackages maps the paths of the files of the packages of the metadata
+// graph to their package, preferring the non-test variant of packages.
+func filePackages(md *metadata.Graph) map[string]symbolPackage {
+	packages := make(map[string]symbolPackage)
+	for _, mp := range md.Packages {
+		pkg := symbolPackage{path: string(mp.PkgPath), forTest: string(mp.ForTest), name: string(mp.Name)}
+		for _, uri := range slices.Concat(mp.CompiledGoFiles, mp.GoFiles) {
+			path := filepath.Clean(uri.Path())
+			if existing, ok := packages[path]; ok && existing.forTest == "" {
+				continue
+			}
+			packages[path] = pkg
 		}
+	}
+	return packages
+}
 
-		filtered = append(filtered, uri)
+// withPackages returns copies of the symbols, which may be shared with the
+// symbol indexes, with their package path set.
+func withPackages(symbols []*api.Symbol, packages map[string]symbolPackage) []*api.Symbol {
+	result := make([]*api.Symbol, len(symbols))
+	for i, sym := range symbols {
+		c := *sym
+		c.PackagePath = packages[sym.FilePath].path
+		result[i] = &c
 	}
+	return result
+}
 
-	return filtered
+// searchFilter holds the go_search filters other than the query.
+type searchFilter struct {
+	kinds        map[api.SymbolKind]bool // if non-nil, the kinds to return
+	pkgPath      string                  // if set, the import path of the package tree to return
+	dir          string                  // if set, the directory of the files to return
+	recursive    bool                    // whether dir includes its subdirectories
+	exportedOnly bool
+	tests        string // "exclude" or "only" symbols of test files, or "" for all
 }
 
-// extractSymbolsFromFiles parses files and extracts symbols.
-// Each file is parsed, symbols extracted, then AST is discarded (GC-friendly).
-// Processes files concurrently using a worker pool for better performance.
-func extractSymbolsFromFiles(ctx context.Context, snapshot *cache.Snapshot, uris []protocol.DocumentURI) []*api.Symbol {
-	numWorkers := runtime.NumCPU()
-	if numWorkers > 8 {
-		numWorkers = 8
-	}
+// searchKinds are the symbol kinds reported by go_search.
+var searchKinds = []api.SymbolKind{
+	api.SymbolKindFunction,
+	api.SymbolKindMethod,
+	api.SymbolKindStruct,
+	api.SymbolKindInterface,
+	api.SymbolKindType,
+	api.SymbolKindField,
+	api.SymbolKindVariable,
+	api.SymbolKindConstant,
+}
 
-	type result struct {
-		symbols []*api.Symbol
+// newSearchFilter validates the filters of a go_search input. Relative
+// package directories are relative to root.
+func newSearchFilter(input api.ISearchParams, root string) (*searchFilter, error) {
+	filter := &searchFilter{exportedOnly: input.ExportedOnly}
+
+	if len(input.Kinds) > 0 {
+		filter.kinds = make(map[api.SymbolKind]bool)
+		for _, kind := range input.Kinds {
+			if !slices.Contains(searchKinds, kind) {
+				return nil, fmt.Errorf("invalid kind %q: must be one of %v", kind, searchKinds)
+			}
+			filter.kinds[kind] = true
+		}
 	}
 
-	results := make(chan result, len(uris))
-	urisChan := make(chan protocol.DocumentURI, len(uris))
+	switch input.Tests {
+	case "", "include":
+	case "exclude", "only":
+		filter.tests = input.Tests
+	default:
+		return nil, fmt.Errorf("invalid tests %q: must be include, exclude or only", input.Tests)
+	}
 
-	for _, uri := range uris {
-		urisChan <- uri
+	if pkg := input.Package; pkg != "" {
+		pkg, filter.recursive = strings.CutSuffix(pkg, "/...")
+		if pkg == "." || strings.HasPrefix(pkg, "./") || strings.HasPrefix(pkg, "../") || filepath.IsAbs(pkg) {
+			if !filepath.IsAbs(pkg) {
+				pkg = filepath.Join(root, pkg)
+			}
+			filter.dir = filepath.Clean(pkg)
+		} else {
+			filter.pkgPath = pkg
+			filter.recursive = true
+		}
 	}
-	close(urisChan)
+	return filter, nil
+}
 
-	var wg sync.WaitGroup
-	for i := 0; i < numWorkers; i++ {
-		wg.Add(1)
-		go func() {
-			defer wg.Done()
-			fset := token.NewFileSet()
+// apply returns the symbols that match the filter and whose type or
+// package name matches qualifier, if set.
+func (f *searchFilter) apply(symbols []*api.Symbol, packages map[string]symbolPackage, qualifier string) []*api.Symbol {
+	var filtered []*api.Symbol
+	for _, sym := range symbols {
+		pkg := packages[sym.FilePath]
+		if qualifier != "" && !matchesQualifier(sym, pkg, qualifier) {
+			continue
+		}
+		if f.kinds != nil && !f.kinds[sym.Kind] {
+			continue
+		}
+		if f.exportedOnly && !isExportedSymbol(sym) {
+			continue
+		}
+		if isTest := strings.HasSuffix(sym.FilePath, "_test.go"); f.tests == "exclude" && isTest || f.tests == "only" && !isTest {
+			continue
+		}
+		if !f.matchesFile(sym.FilePath, pkg) {
+			continue
+		}
+		filtered = append(filtered, sym)
+	}
+	return filtered
+}
 
-			for uri := range urisChan {
-				if ctx.Err() != nil {
-					return
-				}
+// matchesFile reports whether the file at path, of the given package, is
+// selected by the package and directory filters.
+func (f *searchFilter) matchesFile(path string, pkg symbolPackage) bool {
+	if f.pkgPath != "" && !underImportPath(pkg.path, f.pkgPath) && !underImportPath(pkg.forTest, f.pkgPath) {
+		return false
+	}
+	if f.dir != "" {
+		dir := filepath.Dir(path)
+		if !(dir == f.dir || f.recursive && isUnder(dir, f.dir)) {
+			return false
+		}
+	}
+	return true
+}
 
-				fh, err := snapshot.ReadFile(ctx, uri)
-				if err != nil {
-					continue
-				}
+// matchesQualifier reports whether the qualifier of a query matches the
+// type a symbol belongs to, or its package name. Like names, qualifiers
+// match case-insensitively by substring.
+func matchesQualifier(sym *api.Symbol, pkg symbolPackage, qualifier string) bool {
+	qualifier = strings.ToLower(qualifier)
+	if parent := symbolParent(sym); parent != "" {
+		return strings.Contains(strings.ToLower(parent), qualifier)
+	}
+	return pkg.name != "" && strings.Contains(strings.ToLower(pkg.name), qualifier)
+}
 
-				pgf, err := cache.ParseGoImpl(ctx, fset, fh, 0, false)
-				if err != nil {
-					continue
-				}
+// symbolParent returns the type a method or field belongs to, or "".
+func symbolParent(sym *api.Symbol) string {
+	if sym.Receiver != "" {
+		return strings.TrimPrefix(sym.Receiver, "*")
+	}
+	return sym.Parent
+}
 
-				fileSymbols := extractTopLevelSymbols(pgf)
-				results <- result{symbols: fileSymbols}
+// setSymbolHandles sets the handles of the symbols (see golang.SymbolHandle)
+// from the objects they declare, in the type-checked packages of their
+// files. The handle of a symbol whose object is not found is left empty.
+func setSymbolHandles(ctx context.Context, snapshot *cache.Snapshot, symbols []*api.Symbol) {
+	type typedFile struct {
+		pkg *cache.Package
+		pgf *parsego.File
+	}
+	files := make(map[string]*typedFile)
+	for _, sym := range symbols {
+		f, ok := files[sym.FilePath]
+		if !ok {
+			if pkg, pgf, err := golang.NarrowestPackageForFile(ctx, snapshot, protocol.URIFromPath(sym.FilePath)); err == nil {
+				f = &typedFile{pkg, pgf}
 			}
-		}()
+			files[sym.FilePath] = f
+		}
+		if f != nil {
+			sym.Handle = golang.SymbolHandle(golang.DefinedObject(f.pkg, f.pgf, sym.Line, sym.Name))
+		}
 	}
+}
 
-	go func() {
-		wg.Wait()
-		close(results)
-	}()
-
-	var allSymbols []*api.Symbol
-	for res := range results {
-		allSymbols = append(allSymbols, res.symbols...)
+// documentSymbolHandle returns the handle of the object declared by a
+// document symbol of pgf, a file of pkg, or "" if pkg is nil or the
+// object has no handle. name is the name of the symbol without receiver.
+func documentSymbolHandle(pkg *cache.Package, pgf *parsego.File, ds protocol.DocumentSymbol, name string) string {
+	if pkg == nil {
+		return ""
 	}
+	return golang.SymbolHandle(golang.DefinedObject(pkg, pgf, int(ds.SelectionRange.Start.Line)+1, name))
+}
 
-	return allSymbols
+// isExportedSymbol reports whether a symbol is exported, and for methods
+// and fields, whether its type is exported too.
+func isExportedSymbol(sym *api.Symbol) bool {
+	parent := symbolParent(sym)
+	return token.IsExported(sym.Name) && (parent == "" || token.IsExported(parent))
 }
 
-// extractTopLevelSymbols walks the AST and extracts top-level declarations.
-// This includes functions, types, variables, and constants.
-func extractTopLevelSymbols(pgf *parsego.File) []*api.Symbol {
-	var symbols []*api.Symbol
+// underImportPath reports whether the package path is prefix or below it.
+func underImportPath(path, prefix string) bool {
+	return path != "" && (path == prefix || strings.HasPrefix(path, prefix+"/"))
+}
 
-	for _, decl := range pgf.File.Decls {
+// filterIgnoredSymbols filters out symbols of files that should be ignored
+// per Go conventions:
+// - Files in testdata directories
+// - Files starting with '.' or '_' in any path segment
+// - Files filtered by user's directory filters
+func filterIgnoredSymbols(snapshot *cache.Snapshot, symbols []*api.Symbol) []*api.Symbol {
+	ignored := make(map[string]bool)
+	var filtered []*api.Symbol
+	for _, sym := range symbols {
+		ignore, ok := ignored[sym.FilePath]
+		if !ok {
+			ignore = snapshot.IgnoredFile(protocol.URIFromPath(sym.FilePath))
+			ignored[sym.FilePath] = ignore
+		}
+		if !ignore {
+			filtered = append(filtered, sym)
+		}
+	}
+	return filtered
+}
+
+// extractFileSymbols walks the AST of the file at path and extracts its
+// symbols: the top-level declarations (functions, methods, types, variables
+// and constants), the fields of struct types, and the methods of interface
+// types. It also returns the searchable text of each symbol, by index (see
+// go_search_docs).
+func extractFileSymbols(file *ast.File, tokFile *token.File, path string) ([]*api.Symbol, []symbolText) {
+	var (
+		symbols []*api.Symbol
+		texts   []symbolText
+	)
+	add := func(sym *api.Symbol, doc *ast.CommentGroup, node ast.Node) {
+		symbols = append(symbols, sym)
+		texts = append(texts, newSymbolText(doc, node))
+	}
+
+	for _, decl := range file.Decls {
 		switch d := decl.(type) {
 		case *ast.FuncDecl:
-			// Extract function
-			symbols = append(symbols, &api.Symbol{
+			// Extract function or method
+			sym := &api.Symbol{
 				Name:     d.Name.Name,
 				Kind:     api.SymbolKindFunction,
-				FilePath: pgf.URI.Path(),
-				Line:     int(pgf.Tok.Line(d.Pos())),
-			})
+				FilePath: path,
+				Line:     tokFile.Line(d.Pos()),
+			}
+			if d.Recv != nil && len(d.Recv.List) > 0 {
+				sym.Kind = api.SymbolKindMethod
+				sym.Receiver = receiverTypeName(d.Recv.List[0].Type)
+			}
+			add(sym, d.Doc, d)
 
 		case *ast.GenDecl:
 			// Extract types, variables, constants
@@ -695,12 +860,15 @@ func extractTopLevelSymbols(pgf *parsego.File) []*api.Symbol {
 				case *ast.TypeSpec:
 					// Type declaration - determine kind based on type
 					kind := determineTypeKind(s)
-					symbols = append(symbols, &api.Symbol{
+					add(&api.Symbol{
 						Name:     s.Name.Name,
 						Kind:     kind,
-						FilePath: pgf.URI.Path(),
-						Line:     int(pgf.Tok.Line(s.Pos())),
-					})
+						FilePath: path,
+						Line:     tokFile.Line(s.Pos()),
+					}, specDoc(d, s.Doc), s)
+					members, memberTexts := extractMemberSymbols(s, tokFile, path)
+					symbols = append(symbols, members...)
+					texts = append(texts, memberTexts...)
 
 				case *ast.ValueSpec:
 					// Variable or constant declaration
@@ -710,19 +878,100 @@ func extractTopLevelSymbols(pgf *parsego.File) []*api.Symbol {
 					}
 
 					for _, name := range s.Names {
-						symbols = append(symbols, &api.Symbol{
+						add(&api.Symbol{
 							Name:     name.Name,
 							Kind:     kind,
-							FilePath: pgf.URI.Path(),
-							Line:     int(pgf.Tok.Line(name.Pos())),
-						})
+							FilePath: path,
+							Line:     tokFile.Line(name.Pos()),
+						}, specDoc(d, s.Doc), s)
 					}
 				}
 			}
 		}
 	}
 
-	return symbols
+	return symbols, texts
+}
+
+// specDoc returns the doc comment of a spec: its own, or else that of its
+// declaration if the spec is not in a group.
+func specDoc(decl *ast.GenDecl, doc *ast.CommentGroup) *ast.CommentGroup {
+	if doc == nil && !decl.Lparen.IsValid() {
+		return decl.Doc
+	}
+	return doc
+}
+
+// extractMemberSymbols returns the fields of a struct type declaration, or
+// the methods of an interface type declaration, with the type as parent,
+// and their searchable text. Embedded fields are named after their type;
+// embedded interfaces are skipped.
+func extractMemberSymbols(spec *ast.TypeSpec, tokFile *token.File, path string) ([]*api.Symbol, []symbolText) {
+	var (
+		symbols []*api.Symbol
+		texts   []symbolText
+	)
+	add := func(kind api.SymbolKind, name *ast.Ident, field *ast.Field) {
+		symbols = append(symbols, &api.Symbol{
+			Name:     name.Name,
+			Kind:     kind,
+			Parent:   spec.Name.Name,
+			FilePath: path,
+			Line:     tokFile.Line(name.Pos()),
+		})
+		doc := field.Doc
+		if doc == nil {
+			doc = field.Comment
+		}
+		texts = append(texts, newSymbolText(doc, field))
+	}
+	switch t := spec.Type.(type) {
+	case *ast.StructType:
+		for _, field := range t.Fields.List {
+			names := field.Names
+			if len(names) == 0 {
+				if name := receiverTypeName(field.Type); name != "" {
+					names = []*ast.Ident{{Name: strings.TrimPrefix(name, "*"), NamePos: field.Type.Pos()}}
+				}
+			}
+			for _, name := range names {
+				add(api.SymbolKindField, name, field)
+			}
+		}
+	case *ast.InterfaceType:
+		for _, method := range t.Methods.List {
+			if _, ok := method.Type.(*ast.FuncType); !ok {
+				continue
+			}
+			for _, name := range method.Names {
+				add(api.SymbolKindMethod, name, method)
+			}
+		}
+	}
+	return symbols, texts
+}
+
+// receiverTypeName returns the name of the type of a method receiver or an
+// embedded field, without type parameters or package qualifier: "*Server"
+// for *Server, "List" for List[T], "Mutex" for sync.Mutex.
+func receiverTypeName(expr ast.Expr) string {
+	switch t := expr.(type) {
+	case *ast.StarExpr:
+		if name := receiverTypeName(t.X); name != "" {
+			return "*" + name
+		}
+	case *ast.ParenExpr:
+		return receiverTypeName(t.X)
+	case *ast.IndexExpr:
+		return receiverTypeName(t.X)
+	case *ast.IndexListExpr:
+		return receiverTypeName(t.X)
+	case *ast.SelectorExpr:
+		return t.Sel.Name
+	case *ast.Ident:
+		return t.Name
+	}
+	return ""
 }
 
 // determineTypeKind determines the SymbolKind for a TypeSpec
@@ -761,32 +1010,26 @@ func fuzzyMatchSymbols(symbols []*api.Symbol, query string) []*api.Symbol {
 	return matched
 }
 
-// sortAndLimitSymbols sorts symbols by relevance and limits to maxResults.
-// For now, simple alphabetical sorting (can be enhanced with relevance scoring).
-// Returns the sorted and limited slice.
-func sortAndLimitSymbols(symbols []*api.Symbol, maxResults int) []*api.Symbol {
-	sortSymbols(symbols)
-
-	if len(symbols) > maxResults {
-		// Clear references to symbols beyond maxResults for GC
-		for i := maxResults; i < len(symbols); i++ {
-			symbols[i] = nil
-		}
-		return symbols[:maxResults]
-	}
-	return symbols
+// sortSymbols sorts symbols by name, then by qualified name, and then by
+// location, so that the order does not depend on the order of the index.
+func sortSymbols(symbols []*api.Symbol) {
+	slices.SortStableFunc(symbols, func(a, b *api.Symbol) int {
+		return cmp.Or(
+			cmp.Compare(a.Name, b.Name),
+			cmp.Compare(qualifiedSymbolName(a), qualifiedSymbolName(b)),
+			cmp.Compare(a.FilePath, b.FilePath),
+			cmp.Compare(a.Line, b.Line),
+		)
+	})
 }
 
-func sortSymbols(symbols []*api.Symbol) {
-	for i := 1; i < len(symbols); i++ {
-		key := symbols[i]
-		j := i - 1
-		for j >= 0 && symbols[j].Name > key.Name {
-			symbols[j+1] = symbols[j]
-			j--
-		}
-		symbols[j+1] = key
+// qualifiedSymbolName returns the name of a symbol qualified by its type,
+// for methods and fields ("Server.Start").
+func qualifiedSymbolName(sym *api.Symbol) string {
+	if parent := symbolParent(sym); parent != "" {
+		return parent + "." + sym.Name
 	}
+	return sym.Name
 }
 
 // buildSearchSummary builds a human-readable summary of search results.
@@ -803,7 +1046,11 @@ func buildSearchSummary(symbols []*api.Symbol, maxResults int) string {
 	}
 
 	for _, sym := range symbols {
-		summary += fmt.Sprintf("  - %s (%s in %s:%d)\n", sym.Name, sym.Kind, sym.FilePath, sym.Line)
+		summary += fmt.Sprintf("  - %s (%s in %s:%d)", qualifiedSymbolName(sym), sym.Kind, sym.FilePath, sym.Line)
+		if sym.Handle != "" {
+			summary += fmt.Sprintf(" [handle: %s]", sym.Handle)
+		}
+		summary += "\n"
 	}
 
 	if len(symbols) > maxResults {
@@ -817,20 +1064,36 @@ func buildSearchSummary(symbols []*api.Symbol, maxResults int) string {
 // Origin: gopls/internal/golang/definition.go Definition()
 
 func handleGoDefinition(ctx context.Context, h *Handler, req *mcp.CallToolRequest, input api.IDefinitionParams) (*mcp.CallToolResult, *api.ODefinitionResult, error) {
-	// Get the view for the directory containing the context file
-	// This is critical for cross-file definitions to work correctly
-	dir := filepath.Dir(input.Locator.ContextFile)
-	view, err := h.viewForDir(dir)
-	if err != nil {
-		return nil, nil, fmt.Errorf("failed to get view for %s: %w", dir, err)
-	}
+	var snapshot *cache.Snapshot
+	var release func()
+	var err error
 
-	snapshot, release, err := view.Snapshot()
-	if err != nil {
-		return nil, nil, err
+	if input.Locator.ContextFile != "" {
+		// Get the view for the directory containing the context file
+		// This is critical for cross-file definitions to work correctly
+		dir := filepath.Dir(input.Locator.ContextFile)
+		view, err := h.viewForDir(dir)
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to get view for %s: %w", dir, err)
+		}
+		snapshot, release, err = view.Snapshot()
+		if err != nil {
+			return nil, nil, err
+		}
+	} else {
+		// A symbol given by its handle or symbol path is resolved in the default view.
+		snapshot, release, err = h.snapshot()
+		if err != nil {
+			return nil, nil, err
+		}
 	}
 	defer release()
 
+	var resolution *api.LocatorResolution
+	if input.Locator, resolution, err = golang.ResolveLocator(ctx, snapshot, input.Locator); err != nil {
+		return locatorError(err, resolution, &api.ODefinitionResult{Summary: err.Error(), Resolution: resolution})
+	}
+
 	// Use the unified ResolveSymbol to get both locations and rich definition info
 	info, err := golang.ResolveSymbol(ctx, snapshot, input.Locator, golang.ResolveOptions{
 		FindDefinitions:   true,
@@ -842,7 +1105,8 @@ func handleGoDefinition(ctx context.Context, h *Handler, req *mcp.CallToolReques
 
 	if len(info.Locations) == 0 {
 		summary := fmt.Sprintf("No definition found for symbol '%s' in %s", input.Locator.SymbolName, input.Locator.ContextFile)
-		result := &api.ODefinitionResult{Summary: summary}
+		summary = golang.DescribeResolution(resolution) + summary
+		result := &api.ODefinitionResult{Summary: summary, Resolution: resolution}
 		return &mcp.CallToolResult{Content: []mcp.Content{&mcp.TextContent{Text: summary}}}, result, nil
 	}
 
@@ -857,6 +1121,7 @@ func handleGoDefinition(ctx context.Context, h *Handler, req *mcp.CallToolReques
 			Doc:       srcCtx.DocComment,
 			FilePath:  srcCtx.File,
 			Line:      srcCtx.StartLine,
+			Handle:    srcCtx.Handle,
 		}
 		// Include body (snippet) if requested
 		if input.IncludeBody {
@@ -874,13 +1139,26 @@ func handleGoDefinition(ctx context.Context, h *Handler, req *mcp.CallToolReques
 		summary += golang.FormatSymbolSummary(sym)
 	}
 
+	summary = golang.DescribeResolution(resolution) + summary
 	result := &api.ODefinitionResult{
-		Symbol:  sym,
-		Summary: summary,
+		Symbol:     sym,
+		Summary:    summary,
+		Resolution: resolution,
 	}
 	return &mcp.CallToolResult{Content: []mcp.Content{&mcp.TextContent{Text: summary}}}, result, nil
 }
 
+// locatorError returns the result of a tool whose locator did not resolve
+// to a symbol: if there are suggestions of symbols it may have meant, a tool
+// error with out as structured output, or else the error itself.
+func locatorError[Out any](err error, resolution *api.LocatorResolution, out Out) (*mcp.CallToolResult, Out, error) {
+	if resolution == nil {
+		var zero Out
+		return nil, zero, err
+	}
+	return &mcp.CallToolResult{IsError: true, Content: []mcp.Content{&mcp.TextContent{Text: err.Error()}}}, out, nil
+}
+
 // ===== go_symbol_references =====
 // Origin: gopls/internal/mcp/symbol_references.go symbolReferencesHandler()
 
@@ -891,42 +1169,21 @@ func handleGoSymbolReferences(ctx context.Context, h *Handler, req *mcp.CallTool
 	}
 	defer release()
 
-	// Read the context file
-	uri := protocol.URIFromPath(input.Locator.ContextFile)
-	fh, err := snapshot.ReadFile(ctx, uri)
-	if err != nil {
-		return nil, nil, fmt.Errorf("failed to read file %s: %v", input.Locator.ContextFile, err)
-	}
-
-	// Resolve the symbol using the semantic bridge
-	nodeResult, err := golang.ResolveNode(ctx, snapshot, fh, input.Locator)
-	if err != nil {
-		return nil, nil, fmt.Errorf("failed to resolve symbol '%s': %v", input.Locator.SymbolName, err)
-	}
-
-	// Get the package for the file to access the file set
-	pkg, _, err := golang.NarrowestPackageForFile(ctx, snapshot, uri)
+	filter, err := newReferenceFilter(input)
 	if err != nil {
-		return nil, nil, fmt.Errorf("failed to get package: %w", err)
-	}
-
-	// Convert token.Pos to protocol.Position
-	posn := safetoken.StartPosition(pkg.FileSet(), nodeResult.Pos)
-	if !posn.IsValid() {
-		return nil, nil, fmt.Errorf("invalid position for symbol '%s'", input.Locator.SymbolName)
+		return nil, nil, err
 	}
 
-	position := protocol.Position{
-		Line:      uint32(posn.Line - 1),
-		Character: uint32(posn.Column - 1),
+	var resolution *api.LocatorResolution
+	if input.Locator, resolution, err = golang.ResolveLocator(ctx, snapshot, input.Locator); err != nil {
+		return locatorError(err, resolution, &api.OSymbolReferencesResult{Summary: err.Error(), Resolution: resolution})
 	}
 
-	// Call gopls's References function
-	// includeDeclaration=false to exclude the definition itself
-	locations, err := golang.References(ctx, snapshot, fh, position, false)
+	found, err := findReferences(ctx, snapshot, input.Locator)
 	if err != nil {
-		return nil, nil, fmt.Errorf("failed to find references: %v", err)
+		return nil, nil, err
 	}
+	fh, position, locations := found.fh, found.position, found.locations
 
 	// Extract rich Symbol information for the referenced symbol
 	// Use the original symbol's definition to get signature, docs, etc.
@@ -938,34 +1195,45 @@ func handleGoSymbolReferences(ctx context.Context, h *Handler, req *mcp.CallTool
 		}
 	}
 
+	// Classify the references, then filter them
+	var refs []api.Reference
+	for _, ref := range found.refs {
+		if filter.matches(ref) {
+			refs = append(refs, ref)
+		}
+	}
+
 	// Build summary
 	var summary strings.Builder
+	summary.WriteString(golang.DescribeResolution(resolution))
 	if len(locations) == 0 {
 		summary.WriteString(fmt.Sprintf("No references found for %q in %s",
 			input.Locator.SymbolName, input.Locator.ContextFile))
+	} else if len(refs) == 0 {
+		summary.WriteString(fmt.Sprintf("None of the %d reference(s) to %q match %s",
+			len(locations), input.Locator.SymbolName, describeReferenceFilter(input)))
 	} else {
-		summary.WriteString(fmt.Sprintf("Found %d reference(s) to %q:\n",
-			len(locations), input.Locator.SymbolName))
-		for i, loc := range locations {
-			summary.WriteString(fmt.Sprintf("%d. %s:%d:%d",
-				i+1, loc.URI.Path(), loc.Range.Start.Line+1, loc.Range.Start.Character+1))
-
-			// Try to get context by reading the file at this location
-			fh, err := snapshot.ReadFile(ctx, loc.URI)
-			if err == nil {
-				// Get the line of code containing the reference
-				content, err := fh.Content()
-				if err == nil {
-					lines := strings.Split(string(content), "\n")
-					lineIdx := int(loc.Range.Start.Line)
-					if lineIdx >= 0 && lineIdx < len(lines) {
-						line := strings.TrimSpace(lines[lineIdx])
-						// Show the line of code for context
-						if len(line) > 0 && len(line) < 100 {
-							summary.WriteString(fmt.Sprintf("\n   %s", line))
-						}
-					}
-				}
+		if filter.active() {
+			summary.WriteString(fmt.Sprintf("Found %d of %d reference(s) to %q matching %s:\n",
+				len(refs), len(locations), input.Locator.SymbolName, describeReferenceFilter(input)))
+		} else {
+			summary.WriteString(fmt.Sprintf("Found %d reference(s) to %q:\n",
+				len(refs), input.Locator.SymbolName))
+		}
+		summary.WriteString(countReferenceKinds(refs) + "\n")
+		for i, ref := range refs {
+			summary.WriteString(fmt.Sprintf("%d. %s:%d:%d (%s",
+				i+1, ref.File, ref.Line, ref.Column, ref.Kind))
+			if ref.EnclosingFunction != "" {
+				summary.WriteString(" in " + ref.EnclosingFunction)
+			}
+			if ref.InTestFile {
+				summary.WriteString(", test")
+			}
+			summary.WriteString(")")
+			// Show the line of code for context
+			if len(ref.Text) > 0 && len(ref.Text) < 100 {
+				summary.WriteString(fmt.Sprintf("\n   %s", ref.Text))
 			}
 			summary.WriteString("\n")
 		}
@@ -984,14 +1252,72 @@ func handleGoSymbolReferences(ctx context.Context, h *Handler, req *mcp.CallTool
 	result := &api.OSymbolReferencesResult{
 		Summary:    summary.String(),
 		Symbols:    symbols,
+		References: refs,
 		TotalCount: len(locations),
-		Returned:   len(locations),
+		Returned:   len(refs),
 		Truncated:  false,
+		Resolution: resolution,
 	}
 
 	return &mcp.CallToolResult{Content: []mcp.Content{&mcp.TextContent{Text: summary.String()}}}, result, nil
 }
 
+// symbolReferences are the references to a symbol.
+type symbolReferences struct {
+	fh        file.Handle         // the context file of the locator
+	position  protocol.Position   // the position of the symbol in fh
+	locations []protocol.Location // the references, excluding the declaration
+	refs      []api.Reference     // the references, classified by kind of use
+}
+
+// findReferences finds the references to the symbol of a resolved locator
+// in the snapshot.
+func findReferences(ctx context.Context, snapshot *cache.Snapshot, locator api.SymbolLocator) (*symbolReferences, error) {
+	// Read the context file
+	uri := protocol.URIFromPath(locator.ContextFile)
+	fh, err := snapshot.ReadFile(ctx, uri)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read file %s: %v", locator.ContextFile, err)
+	}
+
+	// Resolve the symbol using the semantic bridge
+	nodeResult, err := golang.ResolveNode(ctx, snapshot, fh, locator)
+	if err != nil {
+		return nil, fmt.Errorf("failed to resolve symbol '%s': %v", locator.SymbolName, err)
+	}
+
+	// Get the package for the file to access the file set
+	pkg, _, err := golang.NarrowestPackageForFile(ctx, snapshot, uri)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get package: %w", err)
+	}
+
+	// Convert token.Pos to protocol.Position
+	posn := safetoken.StartPosition(pkg.FileSet(), nodeResult.Pos)
+	if !posn.IsValid() {
+		return nil, fmt.Errorf("invalid position for symbol '%s'", locator.SymbolName)
+	}
+
+	position := protocol.Position{
+		Line:      uint32(posn.Line - 1),
+		Character: uint32(posn.Column - 1),
+	}
+
+	// Call gopls's References function
+	// includeDeclaration=false to exclude the definition itself
+	locations, err := golang.References(ctx, snapshot, fh, position, false)
+	if err != nil {
+		return nil, fmt.Errorf("failed to find references: %v", err)
+	}
+
+	return &symbolReferences{
+		fh:        fh,
+		position:  position,
+		locations: locations,
+		refs:      classifyReferences(ctx, snapshot, nodeResult.Object, locations),
+	}, nil
+}
+
 // ===== go_dryrun_rename_symbol =====
 // Origin: gopls/internal/mcp/rename_symbol.go renameSymbolHandler()
 //
@@ -1004,6 +1330,11 @@ func handleGoRenameSymbol(ctx context.Context, h *Handler, req *mcp.CallToolRequ
 	}
 	defer release()
 
+	var resolution *api.LocatorResolution
+	if input.Locator, resolution, err = golang.ResolveLocator(ctx, snapshot, input.Locator); err != nil {
+		return locatorError(err, resolution, &api.ORenameSymbolResult{Summary: err.Error(), Resolution: resolution})
+	}
+
 	// Use the semantic bridge to generate both unified diff and line changes
 	unifiedDiff, lineChanges, err := golang.LLMRename(ctx, snapshot, input.Locator, input.NewName)
 	if err != nil {
@@ -1012,12 +1343,14 @@ func handleGoRenameSymbol(ctx context.Context, h *Handler, req *mcp.CallToolRequ
 
 	// Build summary
 	var summary strings.Builder
+	summary.WriteString(golang.DescribeResolution(resolution))
 	summary.WriteString(fmt.Sprintf("DRY RUN: Preview rename %q to %q\n\n", input.Locator.SymbolName, input.NewName))
 	summary.WriteString(unifiedDiff)
 
 	result := &api.ORenameSymbolResult{
-		Summary: summary.String(),
-		Changes: lineChanges,
+		Summary:    summary.String(),
+		Changes:    lineChanges,
+		Resolution: resolution,
 	}
 
 	return &mcp.CallToolResult{Content: []mcp.Content{&mcp.TextContent{Text: summary.String()}}}, result, nil
@@ -1035,6 +1368,11 @@ func handleGoImplementation(ctx context.Context, h *Handler, req *mcp.CallToolRe
 	}
 	defer release()
 
+	var resolution *api.LocatorResolution
+	if input.Locator, resolution, err = golang.ResolveLocator(ctx, snapshot, input.Locator); err != nil {
+		return locatorError(err, resolution, &api.OImplementationResult{Summary: err.Error(), Resolution: resolution})
+	}
+
 	// Use the semantic bridge to find implementations
 	// LLMImplementation directly returns SourceContext with rich information
 	sourceContexts, err := golang.LLMImplementation(ctx, snapshot, input.Locator)
@@ -1053,6 +1391,7 @@ func handleGoImplementation(ctx context.Context, h *Handler, req *mcp.CallToolRe
 			FilePath:  srcCtx.File,
 			Line:      srcCtx.StartLine,
 			Doc:       srcCtx.DocComment,
+			Handle:    srcCtx.Handle,
 		}
 		// Include body (snippet) if requested
 		if input.IncludeBody {
@@ -1077,9 +1416,11 @@ func handleGoImplementation(ctx context.Context, h *Handler, req *mcp.CallToolRe
 		}
 	}
 
+	summary = golang.DescribeResolution(resolution) + summary
 	result := &api.OImplementationResult{
-		Symbols: symbols,
-		Summary: summary,
+		Symbols:    symbols,
+		Summary:    summary,
+		Resolution: resolution,
 	}
 
 	return &mcp.CallToolResult{Content: []mcp.Content{&mcp.TextContent{Text: summary}}}, result, nil
@@ -1091,7 +1432,7 @@ func handleGoImplementation(ctx context.Context, h *Handler, req *mcp.CallToolRe
 // CRITICAL: This uses snapshot.ReadFile() instead of os.ReadFile to ensure:
 // 1. Content matches what gopls used for AST/type analysis
 // 2. Line numbers match other tools (implementation, diagnostics, etc.)
-// Note: Overlays (unsaved editor changes) are not currently supported by the MCP server
+// 3. Unsaved buffers pushed with go_sync_document (overlays) are returned instead of disk content
 
 func handleGoReadFile(ctx context.Context, h *Handler, req *mcp.CallToolRequest, input api.IReadFileParams) (*mcp.CallToolResult, *api.OReadFileResult, error) {
 	uri := protocol.URIFromPath(input.File)
@@ -1165,9 +1506,15 @@ func handleListTools(ctx context.Context, h *Handler, req *mcp.CallToolRequest,
 	// Get all registered tools
 	toolDocs := []api.ToolDocumentation{}
 
-	for _, tool := range tools {
+	for _, tool := range h.allTools() {
+		if !h.toolEnabled(tool) {
+			continue
+		}
 		// Extract tool details using reflection-like interface
 		name, description := tool.Details()
+		if !toolAllowed(req, name) {
+			continue
+		}
 
 		doc := api.ToolDocumentation{
 			Name:        name,
@@ -1175,7 +1522,7 @@ func handleListTools(ctx context.Context, h *Handler, req *mcp.CallToolRequest,
 		}
 
 		// Determine category based on tool name
-		category := categorizeTool(name)
+		category := toolCategory(tool)
 		doc.Category = category
 
 		// Apply category filter if specified
@@ -1217,7 +1564,15 @@ func handleListTools(ctx context.Context, h *Handler, req *mcp.CallToolRequest,
 	summary.WriteString(summaryHeader)
 
 	// List tools by category
-	categoryOrder := []string{"meta", "environment", "analysis", "navigation", "refactoring", "information"}
+	categoryOrder := []string{"meta", "environment", "analysis", "navigation", "refactoring", "information", "workspace"}
+	var otherCategories []string // e.g. of custom tools
+	for cat := range categories {
+		if !slices.Contains(categoryOrder, cat) {
+			otherCategories = append(otherCategories, cat)
+		}
+	}
+	slices.Sort(otherCategories)
+	categoryOrder = append(categoryOrder, otherCategories...)
 	for _, cat := range categoryOrder {
 		if tools, ok := categories[cat]; ok && len(tools) > 0 {
 			summary.WriteString(fmt.Sprintf("%s:\n", strings.ToTitle(cat)))
@@ -1249,12 +1604,16 @@ func categorizeTool(name string) string {
 
 	// Analysis
 	case name == "go_build_check",
+		name == "go_check_edit",
 		name == "go_analyze_workspace",
 		name == "go_get_dependency_graph":
 		return "analysis"
 
 	// Navigation
 	case name == "go_search",
+		name == "go_search_by_signature",
+		name == "go_search_docs",
+		name == "go_structural_search",
 		name == "go_symbol_references",
 		name == "go_implementation",
 		name == "go_definition",
@@ -1262,9 +1621,15 @@ func categorizeTool(name string) string {
 		return "navigation"
 
 	// Refactoring
-	case name == "go_dryrun_rename_symbol":
+	case name == "go_dryrun_rename_symbol",
+		name == "go_structural_replace":
 		return "refactoring"
 
+	// Workspace state
+	case name == "go_sync_document",
+		name == "go_server_status":
+		return "workspace"
+
 	// Information
 	case strings.HasPrefix(name, "list_") || strings.HasPrefix(name, "fetch_"),
 		strings.HasPrefix(name, "go_list_"),
@@ -1491,27 +1856,15 @@ func getToolSchemas(toolName string) map[string]map[string]any {
 // Refactored to use SymbolLocator + semantic bridge (ResolveNode)
 
 func handleGoCallHierarchy(ctx context.Context, h *Handler, req *mcp.CallToolRequest, input api.ICallHierarchyParams) (*mcp.CallToolResult, *api.OCallHierarchyResult, error) {
-	var snapshot *cache.Snapshot
-	var release func()
-	var err error
+	snapshot, release, err := h.snapshotForDir(input.Cwd)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer release()
 
-	// Use Cwd if provided, otherwise use default view
-	if input.Cwd != "" {
-		view, err := h.viewForDir(input.Cwd)
-		if err != nil {
-			return nil, nil, err
-		}
-		snapshot, release, err = view.Snapshot()
-		if err != nil {
-			return nil, nil, err
-		}
-		defer release()
-	} else {
-		snapshot, release, err = h.snapshot()
-		if err != nil {
-			return nil, nil, err
-		}
-		defer release()
+	var resolution *api.LocatorResolution
+	if input.Locator, resolution, err = golang.ResolveLocator(ctx, snapshot, input.Locator); err != nil {
+		return locatorError(err, resolution, &api.OCallHierarchyResult{Summary: err.Error(), Resolution: resolution})
 	}
 
 	// Read the context file
@@ -1599,12 +1952,15 @@ func handleGoCallHierarchy(ctx context.Context, h *Handler, req *mcp.CallToolReq
 			symbol.PackagePath = richSymbol.PackagePath
 		}
 	}
+	setSymbolHandles(ctx, snapshot, []*api.Symbol{&symbol})
 
 	result := &api.OCallHierarchyResult{
-		Symbol: symbol,
+		Symbol:     symbol,
+		Resolution: resolution,
 	}
 
 	var summary strings.Builder
+	summary.WriteString(golang.DescribeResolution(resolution))
 	summary.WriteString(fmt.Sprintf("Call hierarchy for %s at %s:%d\n\n", symbol.Name, symbol.FilePath, symbol.Line))
 
 	// Get incoming calls (what calls this function)
@@ -1646,6 +2002,7 @@ func handleGoCallHierarchy(ctx context.Context, h *Handler, req *mcp.CallToolReq
 						from.PackagePath = richSymbol.PackagePath
 					}
 				}
+				setSymbolHandles(ctx, snapshot, []*api.Symbol{&from})
 
 				callRanges := make([]api.CallRange, 0, len(call.FromRanges))
 				for _, rng := range call.FromRanges {
@@ -1705,6 +2062,7 @@ func handleGoCallHierarchy(ctx context.Context, h *Handler, req *mcp.CallToolReq
 						to.PackagePath = richSymbol.PackagePath
 					}
 				}
+				setSymbolHandles(ctx, snapshot, []*api.Symbol{&to})
 
 				callRanges := make([]api.CallRange, 0, len(call.FromRanges))
 				for _, rng := range call.FromRanges {
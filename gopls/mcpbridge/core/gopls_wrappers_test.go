@@ -0,0 +1,39 @@
+package core
+
+import (
+	"fmt"
+	"slices"
+	"testing"
+
+	"golang.org/x/tools/gopls/mcpbridge/api"
+)
+
+func TestSortSymbols(t *testing.T) {
+	symbols := []*api.Symbol{
+		{Name: "Start", Receiver: "*Server", FilePath: "b/server.go", Line: 10},
+		{Name: "New", FilePath: "b/new.go", Line: 3},
+		{Name: "New", FilePath: "a/new.go", Line: 7},
+		{Name: "Start", Parent: "Client", FilePath: "a/client.go", Line: 4},
+		{Name: "New", FilePath: "a/new.go", Line: 2},
+	}
+	want := []string{
+		"New a/new.go:2",
+		"New a/new.go:7",
+		"New b/new.go:3",
+		"Client.Start a/client.go:4",
+		"Server.Start b/server.go:10",
+	}
+
+	// The order does not depend on the input order.
+	for range 2 {
+		sortSymbols(symbols)
+		var got []string
+		for _, sym := range symbols {
+			got = append(got, fmt.Sprintf("%s %s:%d", qualifiedSymbolName(sym), sym.FilePath, sym.Line))
+		}
+		if !slices.Equal(got, want) {
+			t.Errorf("sortSymbols() = %q, want %q", got, want)
+		}
+		slices.Reverse(symbols)
+	}
+}
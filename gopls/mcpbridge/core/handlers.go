@@ -41,6 +41,28 @@ type Handler struct {
 	// Maps directory path to release function.
 	dynamicViews   map[string]func()
 	dynamicViewsMu sync.Mutex
+	// defaultDir is the project directory whose view serves the tools
+	// called without a Cwd, if the session has views of other projects.
+	defaultDir string
+	// openDocuments tracks client-pushed documents (see go_sync_document)
+	// that are currently held as overlays in the session.
+	// Maps document URI to its latest version.
+	openDocuments   map[protocol.DocumentURI]int32
+	openDocumentsMu sync.Mutex
+	// watcher is the file watcher keeping the session up to date, if any.
+	watcher FileWatcher
+	// spans records tool calls to a trace file, if any.
+	spans *SpanWriter
+	// audit records tool calls in the audit log, if any.
+	audit *AuditLog
+	// customTools are served in addition to the built-in tools (see WithTools).
+	customTools []Tool
+	// symbolIndexes are the go_search symbol indexes, by view root.
+	symbolIndexes   map[string]*symbolIndex
+	symbolIndexesMu sync.Mutex
+	// depSymbols caches the symbols of the dependencies searched by
+	// go_search.
+	depSymbols fileSymbolCache
 }
 
 // HandlerOption configures the Handler behavior.
@@ -72,6 +94,16 @@ func WithDynamicViews(allow bool) HandlerOption {
 	}
 }
 
+// WithDefaultDir sets the project directory of the handler: tools called
+// without a Cwd use the view containing dir, rather than the session's
+// first view. It matters when the session is shared with clients that add
+// views of other projects.
+func WithDefaultDir(dir string) HandlerOption {
+	return func(h *Handler) {
+		h.defaultDir = dir
+	}
+}
+
 type Symbler interface {
 	Symbol(ctx context.Context, params *protocol.WorkspaceSymbolParams) ([]protocol.SymbolInformation, error)
 }
@@ -91,16 +123,38 @@ func NewHandler(session *cache.Session, symbler Symbler, opts ...HandlerOption)
 		config:            DefaultConfig(),           // Default gopls-mcp config
 		allowDynamicViews: false,                     // Production mode: no dynamic views
 		dynamicViews:      make(map[string]func()),
+		openDocuments:     make(map[protocol.DocumentURI]int32),
+		symbolIndexes:     make(map[string]*symbolIndex),
 	}
 	for _, opt := range opts {
 		opt(h)
 	}
+	if h.watcher != nil {
+		h.watcher.Subscribe(h.didChangeFiles)
+	}
 	return h
 }
 
+// Close releases the initial snapshots of the views created on demand by
+// the handler, so that the session can shut down, and saves the persisted
+// symbol indexes.
+func (h *Handler) Close() {
+	h.closeSymbolIndexes()
+
+	h.dynamicViewsMu.Lock()
+	defer h.dynamicViewsMu.Unlock()
+	for dir, release := range h.dynamicViews {
+		release()
+		delete(h.dynamicViews, dir)
+	}
+}
+
 // snapshot returns the best default snapshot for workspace queries.
 // Based on: gopls/internal/mcp/mcp.go snapshot() method (line 316-322)
 func (h *Handler) snapshot() (*cache.Snapshot, func(), error) {
+	if h.defaultDir != "" {
+		return h.snapshotForDir(h.defaultDir)
+	}
 	views := h.session.Views()
 	if len(views) == 0 {
 		return nil, nil, fmt.Errorf("no active views")
@@ -108,6 +162,20 @@ func (h *Handler) snapshot() (*cache.Snapshot, func(), error) {
 	return views[0].Snapshot()
 }
 
+// snapshotForDir returns the current snapshot of the view containing dir,
+// or the default snapshot if dir is empty. It selects the snapshot of the
+// tools taking a Cwd parameter.
+func (h *Handler) snapshotForDir(dir string) (*cache.Snapshot, func(), error) {
+	if dir == "" {
+		return h.snapshot()
+	}
+	view, err := h.viewForDir(dir)
+	if err != nil {
+		return nil, nil, err
+	}
+	return view.Snapshot()
+}
+
 // viewForDir finds the view that contains the given directory.
 // This is needed for tools that take a Cwd parameter.
 //
@@ -159,7 +227,7 @@ func (h *Handler) viewForDir(dir string) (*cache.View, error) {
 		return nil, fmt.Errorf("failed to create view for %s: %w", dir, err)
 	}
 
-	// Track the view for cleanup (though we never call these in test mode)
+	// Track the view for cleanup by Close
 	h.dynamicViewsMu.Lock()
 	h.dynamicViews[dir] = releaseView
 	h.dynamicViewsMu.Unlock()
@@ -170,6 +238,9 @@ func (h *Handler) viewForDir(dir string) (*cache.View, error) {
 // getView returns a view for the given directory, or the first available view if dir is empty.
 // This is a convenience wrapper for handlers that accept an optional Cwd parameter.
 func (h *Handler) getView(dir string) (*cache.View, error) {
+	if dir == "" {
+		dir = h.defaultDir
+	}
 	if dir != "" {
 		return h.viewForDir(dir)
 	}
@@ -504,6 +575,12 @@ func handleListPackageSymbols(ctx context.Context, h *Handler, req *mcp.CallTool
 			continue
 		}
 
+		// The type-checked package of the file, for the symbol handles
+		typed, typedFile, err := golang.NarrowestPackageForFile(ctx, snapshot, uri)
+		if err != nil {
+			typed = nil
+		}
+
 		// Build a map of symbol positions to docs/bodies from AST
 		docMap := make(map[string]string)
 		bodyMap := make(map[string]string)
@@ -588,6 +665,7 @@ func handleListPackageSymbols(ctx context.Context, h *Handler, req *mcp.CallTool
 			}
 
 			converted := convertDocumentSymbol(sym, uri.Path(), input.PackagePath)
+			converted.Handle = documentSymbolHandle(typed, typedFile, sym, converted.Name)
 
 			// Add documentation from AST
 			if includeDocs {
@@ -1349,7 +1427,7 @@ func convertDocumentSymbol(ds protocol.DocumentSymbol, filePath, packagePath str
 		Parent:      parent,
 		FilePath:    filePath,
 		Line:        int(ds.Range.Start.Line),
-		// Note: Doc and Body are set separately from AST in handleListPackageSymbols
+		// Note: Doc, Body and Handle are set separately in handleListPackageSymbols
 	}
 
 	return sym
@@ -0,0 +1,171 @@
+package core
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"sync"
+	"time"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+	"golang.org/x/tools/internal/event"
+	"golang.org/x/tools/internal/event/export/metric"
+	"golang.org/x/tools/internal/event/keys"
+	"golang.org/x/tools/internal/event/label"
+)
+
+// Tool call instrumentation: GenericTool.Register records a metric event for
+// every tool call through gopls' event package, the same way jsonrpc2 does
+// for LSP requests (see gopls/internal/debug/metrics.go). The metrics are
+// aggregated by the debug instance and exported in the Prometheus format.
+// Optionally, each call is also written as a span to a trace file.
+
+var (
+	toolName   = keys.NewString("tool", "MCP tool name")
+	toolStatus = keys.NewString("status", "tool call status: ok or error")
+
+	toolLatency       = keys.NewFloat64("tool_latency", "tool call latency in milliseconds")
+	toolResponseBytes = keys.NewInt64("tool_response_bytes", "size of the tool response in bytes")
+	toolFailed        = keys.NewTag("tool_failed", "the tool call failed")
+	toolTruncated     = keys.NewInt64("tool_truncated_bytes", "size of a tool response before truncation")
+)
+
+var (
+	// the distributions we use for histograms
+	responseBytesDistribution = []int64{1 << 8, 1 << 10, 1 << 12, 1 << 14, 1 << 15, 1 << 16, 1 << 18, 1 << 20}
+	latencyDistribution       = []float64{1, 5, 10, 50, 100, 250, 500, 1000, 2500, 5000, 10000, 30000, 60000}
+
+	toolCallsMetric = metric.Scalar{
+		Name:        "mcp_tool_calls",
+		Description: "Count of tool calls by tool and status.",
+		Keys:        []label.Key{toolName, toolStatus},
+	}
+
+	toolErrorsMetric = metric.Scalar{
+		Name:        "mcp_tool_errors",
+		Description: "Count of failed tool calls by tool.",
+		Keys:        []label.Key{toolName},
+	}
+
+	toolLatencyMetric = metric.HistogramFloat64{
+		Name:        "mcp_tool_latency",
+		Description: "Distribution of tool call latency in milliseconds, by tool.",
+		Keys:        []label.Key{toolName},
+		Buckets:     latencyDistribution,
+	}
+
+	toolResponseBytesMetric = metric.HistogramInt64{
+		Name:        "mcp_tool_response_bytes",
+		Description: "Distribution of tool response sizes in bytes (after truncation), by tool.",
+		Keys:        []label.Key{toolName},
+		Buckets:     responseBytesDistribution,
+	}
+
+	toolTruncationsMetric = metric.Scalar{
+		Name:        "mcp_tool_truncations",
+		Description: "Count of tool responses truncated to max_response_bytes, by tool.",
+		Keys:        []label.Key{toolName},
+	}
+)
+
+// RegisterMetrics registers the tool call metrics with a metric config.
+func RegisterMetrics(m *metric.Config) {
+	toolCallsMetric.Count(m, toolLatency)
+	toolErrorsMetric.Count(m, toolFailed)
+	toolLatencyMetric.Record(m, toolLatency)
+	toolResponseBytesMetric.Record(m, toolResponseBytes)
+	toolTruncationsMetric.Count(m, toolTruncated)
+}
+
+// toolCall holds the measurements of a single tool call.
+type toolCall struct {
+	tool          string
+	req           *mcp.CallToolRequest
+	start         time.Time
+	duration      time.Duration
+	err           error // error returned by the tool, if any
+	isError       bool  // the call failed (err != nil or an error result)
+	responseBytes int   // response size after truncation
+	truncatedFrom int   // response size before truncation, or 0
+}
+
+// record records the tool call as a metric event and, if configured, as a
+// span in the handler's trace file.
+func (c *toolCall) record(ctx context.Context, h *Handler) {
+	status := "ok"
+	if c.isError {
+		status = "error"
+	}
+	labels := []label.Label{
+		toolName.Of(c.tool),
+		toolStatus.Of(status),
+		toolLatency.Of(float64(c.duration) / float64(time.Millisecond)),
+		toolResponseBytes.Of(int64(c.responseBytes)),
+	}
+	if c.isError {
+		labels = append(labels, toolFailed.New())
+	}
+	if c.truncatedFrom > 0 {
+		labels = append(labels, toolTruncated.Of(int64(c.truncatedFrom)))
+	}
+	event.Metric(ctx, labels...)
+
+	if h.spans != nil {
+		span := toolSpan{
+			Tool:          c.tool,
+			Start:         c.start,
+			DurationMs:    float64(c.duration) / float64(time.Millisecond),
+			Status:        status,
+			ResponseBytes: c.responseBytes,
+			TruncatedFrom: c.truncatedFrom,
+		}
+		if c.err != nil {
+			span.Error = c.err.Error()
+		}
+		h.spans.write(span)
+	}
+}
+
+// resultSize returns the size of the text content of a tool result.
+func resultSize(result *mcp.CallToolResult) int {
+	if result == nil {
+		return 0
+	}
+	return estimateResultSize(result)
+}
+
+// SpanWriter writes a span per tool call, as a line of JSON, to a trace
+// file. It is safe for concurrent use.
+type SpanWriter struct {
+	mu  sync.Mutex
+	enc *json.Encoder
+}
+
+// NewSpanWriter returns a SpanWriter writing to w.
+func NewSpanWriter(w io.Writer) *SpanWriter {
+	return &SpanWriter{enc: json.NewEncoder(w)}
+}
+
+// WithSpanWriter records a span for every tool call of the handler.
+func WithSpanWriter(s *SpanWriter) HandlerOption {
+	return func(h *Handler) {
+		h.spans = s
+	}
+}
+
+// toolSpan is the trace file record of a tool call.
+type toolSpan struct {
+	Tool          string    `json:"tool"`
+	Start         time.Time `json:"start"`
+	DurationMs    float64   `json:"duration_ms"`
+	Status        string    `json:"status"`
+	Error         string    `json:"error,omitempty"`
+	ResponseBytes int       `json:"response_bytes"`
+	TruncatedFrom int       `json:"truncated_from,omitempty"`
+}
+
+func (s *SpanWriter) write(span toolSpan) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.enc.Encode(span) // best effort: tracing must not fail tool calls
+}
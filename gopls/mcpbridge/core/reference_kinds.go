@@ -0,0 +1,320 @@
+package core
+
+// Classification of the references of go_symbol_references by how they use
+// the symbol.
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"go/ast"
+	"go/token"
+	"go/types"
+	"slices"
+	"strings"
+
+	"golang.org/x/tools/go/ast/edge"
+	"golang.org/x/tools/go/ast/inspector"
+	"golang.org/x/tools/gopls/internal/cache"
+	"golang.org/x/tools/gopls/internal/cache/parsego"
+	"golang.org/x/tools/gopls/internal/golang"
+	"golang.org/x/tools/gopls/internal/protocol"
+	"golang.org/x/tools/gopls/mcpbridge/api"
+)
+
+// referenceKinds are the kinds of references, in the order of the counts
+// of the go_symbol_references summary.
+var referenceKinds = []api.ReferenceKind{
+	api.ReferenceCall,
+	api.ReferenceRead,
+	api.ReferenceWrite,
+	api.ReferenceAddress,
+	api.ReferenceType,
+	api.ReferenceEmbedding,
+	api.ReferenceInterface,
+	api.ReferenceFieldKey,
+	api.ReferenceDeclaration,
+}
+
+// referenceFilter holds the go_symbol_references filters.
+type referenceFilter struct {
+	kinds map[api.ReferenceKind]bool // if non-nil, the kinds to return
+	tests string                     // "exclude" or "only" the references of test files, or "" for all
+}
+
+// newReferenceFilter validates the filters of go_symbol_references.
+func newReferenceFilter(input api.ISymbolReferencesParams) (*referenceFilter, error) {
+	filter := &referenceFilter{}
+	if len(input.Kinds) > 0 {
+		filter.kinds = make(map[api.ReferenceKind]bool)
+		for _, kind := range input.Kinds {
+			if !slices.Contains(referenceKinds, kind) {
+				var valid []string
+				for _, k := range referenceKinds {
+					valid = append(valid, string(k))
+				}
+				return nil, fmt.Errorf("invalid reference kind %q: must be one of %s", kind, strings.Join(valid, ", "))
+			}
+			filter.kinds[kind] = true
+		}
+	}
+	switch input.Tests {
+	case "", "include":
+	case "exclude", "only":
+		filter.tests = input.Tests
+	default:
+		return nil, fmt.Errorf("invalid tests %q: must be include, exclude or only", input.Tests)
+	}
+	return filter, nil
+}
+
+// active reports whether the filter selects some references only.
+func (f *referenceFilter) active() bool {
+	return f.kinds != nil || f.tests != ""
+}
+
+// matches reports whether the filter selects ref.
+func (f *referenceFilter) matches(ref api.Reference) bool {
+	if f.kinds != nil && !f.kinds[ref.Kind] {
+		return false
+	}
+	return f.tests == "" || (f.tests == "only") == ref.InTestFile
+}
+
+// describeReferenceFilter describes the filters of go_symbol_references,
+// such as "kinds call, write and tests only".
+func describeReferenceFilter(input api.ISymbolReferencesParams) string {
+	var parts []string
+	if len(input.Kinds) > 0 {
+		var kinds []string
+		for _, kind := range input.Kinds {
+			kinds = append(kinds, string(kind))
+		}
+		parts = append(parts, "kinds "+strings.Join(kinds, ", "))
+	}
+	if input.Tests == "exclude" || input.Tests == "only" {
+		parts = append(parts, "tests "+input.Tests)
+	}
+	return strings.Join(parts, " and ")
+}
+
+// countReferenceKinds returns the number of references of each kind, such
+// as "By kind: 2 call, 1 write".
+func countReferenceKinds(refs []api.Reference) string {
+	counts := make(map[api.ReferenceKind]int)
+	for _, ref := range refs {
+		counts[ref.Kind]++
+	}
+	var parts []string
+	for _, kind := range referenceKinds {
+		if n := counts[kind]; n > 0 {
+			parts = append(parts, fmt.Sprintf("%d %s", n, kind))
+		}
+	}
+	return "By kind: " + strings.Join(parts, ", ")
+}
+
+// classifyReferences returns the references to target at the given
+// locations, with how each one uses it, and the enclosing function and
+// line of code of each. A reference that can't be analyzed is a read.
+func classifyReferences(ctx context.Context, snapshot *cache.Snapshot, target types.Object, locations []protocol.Location) []api.Reference {
+	type file struct {
+		pkg *cache.Package
+		pgf *parsego.File
+	}
+	files := make(map[protocol.DocumentURI]*file)
+
+	refs := make([]api.Reference, 0, len(locations))
+	for _, loc := range locations {
+		ref := api.Reference{
+			File:       loc.URI.Path(),
+			Line:       int(loc.Range.Start.Line) + 1,
+			Column:     int(loc.Range.Start.Character) + 1,
+			Kind:       api.ReferenceRead,
+			InTestFile: strings.HasSuffix(loc.URI.Path(), "_test.go"),
+		}
+		f, ok := files[loc.URI]
+		if !ok {
+			if pkg, pgf, err := golang.NarrowestPackageForFile(ctx, snapshot, loc.URI); err == nil {
+				f = &file{pkg, pgf}
+			}
+			files[loc.URI] = f
+		}
+		if f == nil {
+			refs = append(refs, ref)
+			continue
+		}
+		if offset, err := f.pgf.Mapper.PositionOffset(loc.Range.Start); err == nil {
+			pos := f.pgf.Tok.Pos(offset)
+			if cur, ok := f.pgf.Cursor().FindByPos(pos, pos); ok {
+				if _, ok := cur.Node().(*ast.Ident); ok {
+					ref.Kind = classifyReference(f.pkg.TypesInfo(), cur, target)
+				}
+				for decl := range cur.Enclosing((*ast.FuncDecl)(nil)) {
+					ref.EnclosingFunction = funcDeclName(decl.Node().(*ast.FuncDecl))
+				}
+			}
+			if start, end := lineBounds(f.pgf.Src, offset); start < end {
+				ref.Text = strings.TrimSpace(string(f.pgf.Src[start:end]))
+			}
+		}
+		refs = append(refs, ref)
+	}
+	return refs
+}
+
+// lineBounds returns the offsets of the start and end of the line of src
+// containing offset.
+func lineBounds(src []byte, offset int) (start, end int) {
+	start = bytes.LastIndexByte(src[:offset], '\n') + 1
+	end = len(src)
+	if i := bytes.IndexByte(src[offset:], '\n'); i >= 0 {
+		end = offset + i
+	}
+	return start, end
+}
+
+// classifyReference returns how the identifier of cur, a reference to
+// target (nil if unknown), uses it.
+func classifyReference(info *types.Info, cur inspector.Cursor, target types.Object) api.ReferenceKind {
+	id := cur.Node().(*ast.Ident)
+
+	// The expression denoting the symbol: x, pkg.x or v.x.
+	expr := cur
+	if ek, _ := cur.ParentEdge(); ek == edge.SelectorExpr_Sel {
+		expr = cur.Parent()
+	}
+
+	if isEmbeddedType(expr) {
+		return api.ReferenceEmbedding
+	}
+	if obj := info.Defs[id]; obj != nil {
+		// The declaration of an interface method implemented by the
+		// method target, or of a method implementing it.
+		if _, ok := obj.(*types.Func); ok && target != nil && !sameSymbol(obj, target) {
+			return api.ReferenceInterface
+		}
+		return api.ReferenceDeclaration
+	}
+
+	obj := info.Uses[id]
+	if _, ok := obj.(*types.TypeName); ok {
+		if assignedToInterface(info, expr) {
+			return api.ReferenceInterface
+		}
+		return api.ReferenceType
+	}
+
+	// Generic functions may be instantiated explicitly: f[int](x).
+	fun := expr
+	if ek, _ := fun.ParentEdge(); ek == edge.IndexExpr_X || ek == edge.IndexListExpr_X {
+		fun = fun.Parent()
+	}
+	if ek, _ := fun.ParentEdge(); ek == edge.CallExpr_Fun {
+		if _, ok := obj.(*types.Func); ok {
+			return api.ReferenceCall
+		}
+	}
+
+	switch ek, _ := expr.ParentEdge(); ek {
+	case edge.KeyValueExpr_Key:
+		if v, ok := obj.(*types.Var); ok && v.IsField() {
+			return api.ReferenceFieldKey
+		}
+	case edge.UnaryExpr_X:
+		if expr.Parent().Node().(*ast.UnaryExpr).Op == token.AND {
+			return api.ReferenceAddress
+		}
+	}
+
+	// Writes to x, or to an element of x: x = v, x[i] = v, (x)++.
+	lhs := expr
+	for {
+		ek, _ := lhs.ParentEdge()
+		if ek != edge.ParenExpr_X && ek != edge.IndexExpr_X {
+			break
+		}
+		lhs = lhs.Parent()
+	}
+	switch ek, _ := lhs.ParentEdge(); ek {
+	case edge.AssignStmt_Lhs, edge.IncDecStmt_X:
+		return api.ReferenceWrite
+	case edge.RangeStmt_Key, edge.RangeStmt_Value:
+		if lhs.Parent().Node().(*ast.RangeStmt).Tok == token.ASSIGN {
+			return api.ReferenceWrite
+		}
+	}
+	return api.ReferenceRead
+}
+
+// isEmbeddedType reports whether expr, possibly *expr, is the type of an
+// embedded field or an embedded interface.
+func isEmbeddedType(expr inspector.Cursor) bool {
+	if ek, _ := expr.ParentEdge(); ek == edge.StarExpr_X {
+		expr = expr.Parent()
+	}
+	if ek, _ := expr.ParentEdge(); ek != edge.Field_Type {
+		return false
+	}
+	field := expr.Parent()
+	if len(field.Node().(*ast.Field).Names) > 0 {
+		return false
+	}
+	switch field.Parent().Parent().Node().(type) { // Field -> FieldList -> type
+	case *ast.StructType, *ast.InterfaceType:
+		return true
+	}
+	return false
+}
+
+// assignedToInterface reports whether expr, a type name, denotes the type
+// of a value assigned to an interface (var _ I = T{}, var _ I = (*T)(nil),
+// f(&T{}) where f takes an I), so that the type must satisfy it.
+func assignedToInterface(info *types.Info, expr inspector.Cursor) bool {
+	// The value of type T: T{}, &T{}, T(x), (*T)(x).
+	value := expr
+	for {
+		ek, _ := value.ParentEdge()
+		if ek != edge.StarExpr_X && ek != edge.ParenExpr_X && ek != edge.CompositeLit_Type && ek != edge.CallExpr_Fun && ek != edge.UnaryExpr_X {
+			break
+		}
+		value = value.Parent()
+	}
+	if value == expr || types.IsInterface(info.TypeOf(value.Node().(ast.Expr))) {
+		return false
+	}
+
+	var dest types.Type // the type the value is assigned to
+	ek, idx := value.ParentEdge()
+	switch parent := value.Parent().Node().(type) {
+	case *ast.ValueSpec:
+		if ek == edge.ValueSpec_Values && parent.Type != nil {
+			dest = info.TypeOf(parent.Type)
+		}
+	case *ast.AssignStmt:
+		if ek == edge.AssignStmt_Rhs && len(parent.Lhs) == len(parent.Rhs) {
+			dest = info.TypeOf(parent.Lhs[idx])
+		}
+	case *ast.CallExpr:
+		if sig, ok := types.Unalias(info.TypeOf(parent.Fun)).(*types.Signature); ok && ek == edge.CallExpr_Args {
+			params := sig.Params()
+			switch {
+			case sig.Variadic() && idx >= params.Len()-1 && !parent.Ellipsis.IsValid():
+				dest = params.At(params.Len() - 1).Type().(*types.Slice).Elem()
+			case idx < params.Len():
+				dest = params.At(idx).Type()
+			}
+		}
+	}
+	return dest != nil && types.IsInterface(dest)
+}
+
+// sameSymbol reports whether a and b, possibly from different type-checked
+// packages, are the same symbol.
+func sameSymbol(a, b types.Object) bool {
+	if a == b {
+		return true
+	}
+	handle := golang.SymbolHandle(a)
+	return handle != "" && handle == golang.SymbolHandle(b)
+}
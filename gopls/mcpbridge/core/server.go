@@ -21,12 +21,19 @@ const (
 	// Integrated gopls MCP tools
 	ToolGetPackageSymbolDetail = "go_get_package_symbol_detail"
 	ToolGoBuildCheck           = "go_build_check"
+	ToolGoCheckEdit            = "go_check_edit"
 	ToolGoSearch               = "go_search"
+	ToolGoSearchBySignature    = "go_search_by_signature"
+	ToolGoSearchDocs           = "go_search_docs"
+	ToolGoStructuralSearch     = "go_structural_search"
+	ToolGoStructuralReplace    = "go_structural_replace"
 	ToolGoSymbolReferences     = "go_symbol_references"
 	ToolGoDryrunRenameSymbol   = "go_dryrun_rename_symbol"
 	ToolGoImplementation       = "go_implementation"
 	ToolGoReadFile             = "go_read_file"
 	ToolGoDefinition           = "go_definition"
+	ToolGoSyncDocument         = "go_sync_document"
+	ToolGoServerStatus         = "go_server_status"
 
 	// Call hierarchy tools
 	ToolGetCallHierarchy = "go_get_call_hierarchy"
@@ -50,16 +57,19 @@ var tools = []Tool{
 		Name:        ToolListModules,
 		Description: "List current module and direct dependencies only. Returns module paths only (no packages). By default, transitive dependencies are excluded. Set direct_only=false to show all dependencies including transitive ones. Use this to understand the module structure before exploring packages.",
 		Handler:     handleListModules, // implemented in handlers.go
+		ReadOnly:    true,
 	},
 	GenericTool[api.IListModulePackages, *api.OListModulePackages]{
 		Name:        ToolListModulePackages,
 		Description: "List all packages in a given module. Returns package names and optionally documentation. Use this to discover packages within a module before exploring symbols.",
 		Handler:     handleListModulePackages, // implemented in handlers.go
+		ReadOnly:    true,
 	},
 	GenericTool[api.IListPackageSymbols, *api.OListPackageSymbols]{
 		Name:        ToolListPackageSymbols,
 		Description: "List all exported symbols (types, functions, constants, variables) in a package. Returns Symbol objects with name, kind, signature, receiver, documentation, and optional bodies. Use include_docs=true for documentation and include_bodies=true for function implementations. Use this to explore a package's API surface before diving into specific symbols with get_package_symbol_detail.",
 		Handler:     handleListPackageSymbols, // implemented in handlers.go
+		ReadOnly:    true,
 	},
 
 	// ===== Integrated gopls MCP Tools =====
@@ -70,30 +80,69 @@ var tools = []Tool{
 		Name:        ToolGetPackageSymbolDetail,
 		Description: "Get detailed symbol information from a package. Returns Symbol objects with name, kind, signature, receiver (for methods), parent (for fields), documentation, and optional bodies. Symbol filters are REQUIRED - provide symbol_filters to retrieve specific symbols by name and receiver (e.g., [{name: \"Start\", receiver: \"*Server\"}]). For methods, receiver matching uses exact string match (e.g., \"*Server\" != \"Server\"). Use include_docs=true for documentation and include_bodies=true for function implementations. Use list_package_symbols to get all symbols in a package.",
 		Handler:     handleGetPackageSymbolDetail, // wrapper for outlineHandler()
+		ReadOnly:    true,
 	},
 
 	GenericTool[api.IDiagnosticsParams, *api.ODiagnosticsResult]{
 		Name:        ToolGoBuildCheck,
 		Description: "Check for compilation and type errors. FAST: uses incremental type checking (faster than 'go build'). Use this to verify code correctness and populate the workspace cache for other tools. Returns detailed error information with file/line/column.",
 		Handler:     handleGoDiagnostics, // wrapper for workspaceDiagnosticsHandler()
+		ReadOnly:    true,
+	},
+
+	GenericTool[api.ICheckEditParams, *api.OCheckEditResult]{
+		Name:        ToolGoCheckEdit,
+		Description: "Check whether proposed file changes compile BEFORE writing them to disk. Accepts full file contents or exact text replacements, type-checks the workspace against an isolated in-memory copy, then discards the changes. Returns the resulting errors with file/line/column.",
+		Handler:     handleGoCheckEdit, // speculative overlays via cache.Snapshot.CloneWithOverlays
+		ReadOnly:    true,
 	},
 
 	GenericTool[api.ISearchParams, *api.OSearchResult]{
 		Name:        ToolGoSearch,
-		Description: "Find symbols (functions, types, constants) by name with fuzzy matching. Use this when user knows part of a symbol name but not the full name or location. Returns rich symbol information (name, kind, file, line) for fast exploration.",
+		Description: "Find symbols (functions, methods, types, fields, constants, variables) by name with fuzzy matching, optionally qualified (Server.Start). Use this when user knows part of a symbol name but not the full name or location. Filters by kind, package, exported-only and test files, and can include dependencies and the standard library. Returns rich symbol information (name, kind, package, file, line) for fast exploration.",
 		Handler:     handleGoSearch, // wrapper for searchHandler()
+		ReadOnly:    true,
+	},
+
+	GenericTool[api.ISearchBySignatureParams, *api.OSearchBySignatureResult]{
+		Name:        ToolGoSearchBySignature,
+		Description: "Find functions and methods by the shape of their signature, e.g. func(io.Reader) (*Config, error). Types are matched by assignability using type information, with _ matching any type and ... any number of parameters or results. Use this when you know what a function should take and return, but not its name. Can include dependencies and the standard library.",
+		Handler:     handleGoSearchBySignature,
+		ReadOnly:    true,
+	},
+
+	GenericTool[api.ISearchDocsParams, *api.OSearchDocsResult]{
+		Name:        ToolGoSearchDocs,
+		Description: "Full-text search of the workspace symbols by concept, e.g. \"retry backoff\". Matches names (split on camelCase), doc comments, identifiers and string literals, ranked with BM25, and returns each symbol with the best matching doc snippet. Use this when you know what code does but not what it is called; use go_search when you know part of the name.",
+		Handler:     handleGoSearchDocs,
+		ReadOnly:    true,
+	},
+
+	GenericTool[api.IStructuralSearchParams, *api.OStructuralSearchResult]{
+		Name:        ToolGoStructuralSearch,
+		Description: "Find code by its syntax with a Go pattern containing metavariables, e.g. $db.Query($q, $*_) or if $err != nil { return $*_ }. $x matches any expression or statement (repeated $x must match the same code), $*x any number of list elements. Constraints check the types of metavariables (\"string\", \"implements:error\", \"!const\"). Returns each match with its bindings and enclosing function. Use this instead of regex grep for code patterns.",
+		Handler:     handleGoStructuralSearch,
+		ReadOnly:    true,
+	},
+
+	GenericTool[api.IStructuralReplaceParams, *api.OStructuralReplaceResult]{
+		Name:        ToolGoStructuralReplace,
+		Description: "Replace every match of a go_structural_search pattern with a template using its metavariables, e.g. errors.Wrap($e, $m) -> fmt.Errorf($m+\": %w\", $e), across a package tree. Fixes imports and formats the changed files, and returns a unified diff. Previews by default; set apply to write the changes. Use this for deterministic codebase-wide API migrations instead of editing files one by one.",
+		Handler:     handleGoStructuralReplace,
 	},
 
 	GenericTool[api.ISymbolReferencesParams, *api.OSymbolReferencesResult]{
 		Name:        ToolGoSymbolReferences,
 		Description: "Find all usages of a symbol across the codebase using semantic location (symbol name, package, scope). Use this before refactoring to assess impact or to understand how a symbol is used. REPLACES: grep + manual file reading for finding references.",
 		Handler:     handleGoSymbolReferences, // uses semantic bridge (golang.ResolveNode)
+		ReadOnly:    true,
 	},
 
 	GenericTool[api.IRenameSymbolParams, *api.ORenameSymbolResult]{
 		Name:        ToolGoDryrunRenameSymbol,
 		Description: "Preview a symbol rename operation across all files (DRY RUN - no changes are applied). Use go_symbol_references first to assess impact, then use this to preview the exact changes that would be made. Returns a unified diff showing all proposed modifications.",
 		Handler:     handleGoRenameSymbol, // wrapper for renameSymbolHandler()
+		ReadOnly:    true,
 	},
 
 	// todo: let's rethink about the location, can LLM give us a correct location?
@@ -103,6 +152,7 @@ var tools = []Tool{
 		Name:        ToolGoImplementation,
 		Description: "Find all implementations of an interface or all interfaces implemented by a type using semantic location (symbol name, package, scope). Use this to understand type hierarchies, find all implementations of an interface, or discover design patterns in the codebase. REPLACES: grep + manual file reading for interface implementations.",
 		Handler:     handleGoImplementation, // uses semantic bridge (golang.LLMImplementation)
+		ReadOnly:    true,
 	},
 
 	// todo: is it necessary to support partially read?
@@ -110,8 +160,9 @@ var tools = []Tool{
 	// it will greatly reduce token cost and attention dilution.
 	GenericTool[api.IReadFileParams, *api.OReadFileResult]{
 		Name:        ToolGoReadFile,
-		Description: "Read file content through gopls. SLOWER: reads full file from disk. Use this when you need to see actual code or implementation details. Note: unsaved editor changes not included.",
+		Description: "Read file content through gopls. SLOWER: reads full file from disk. Use this when you need to see actual code or implementation details. Note: unsaved editor changes are included only if pushed with go_sync_document.",
 		Handler:     handleGoReadFile, // wrapper for snapshot.ReadFile()
+		ReadOnly:    true,
 	},
 
 	// Navigation tools
@@ -120,6 +171,22 @@ var tools = []Tool{
 		// TODO: description is too long, don't use one line.
 		Description: "Jump to the definition of a symbol using semantic location (symbol name, package, scope). REPLACES: grep + manual file reading. Use this when you see a function call or type reference and need to find where it's defined. Faster and more accurate than text search - uses type information from gopls.",
 		Handler:     handleGoDefinition, // wrapper for golang.Definition()
+		ReadOnly:    true,
+	},
+
+	// ===== Workspace State Tools =====
+
+	GenericTool[api.ISyncDocumentParams, *api.OSyncDocumentResult]{
+		Name:        ToolGoSyncDocument,
+		Description: "Open, change or close an editor document buffer. Pushed buffers are stored as unsaved overlays so that ALL tools answer against the current editor content instead of the saved files. Use action=open/change with the full content, and action=close to fall back to the file on disk.",
+		Handler:     handleGoSyncDocument, // session overlays via cache.Session.DidModifyFiles
+	},
+
+	GenericTool[api.IServerStatusParams, *api.OServerStatusResult]{
+		Name:        ToolGoServerStatus,
+		Description: "Report what gopls-mcp thinks the workspace is: each view (root, type, GOOS/GOARCH, build flags, Go version), snapshot sequence number, loaded and type-checked package counts, pending file watcher events, memory usage and uptime. Use this to diagnose unexpected or stale tool results.",
+		Handler:     handleGoServerStatus, // introspection of cache.Session and cache.View
+		ReadOnly:    true,
 	},
 
 	// ===== Call Hierarchy Tools =====
@@ -128,6 +195,7 @@ var tools = []Tool{
 		Name:        ToolGetCallHierarchy,
 		Description: "Get the call hierarchy for a function using semantic location (symbol name, package, scope). Returns both incoming calls (what functions call this one) and outgoing calls (what functions this one calls). Use this to understand code flow, debug call chains, and trace execution paths through the codebase. REPLACES: grep + manual file reading for call graph analysis.",
 		Handler:     handleGoCallHierarchy, // uses semantic bridge (golang.ResolveNode)
+		ReadOnly:    true,
 	},
 
 	// ===== New Discovery Tools =====
@@ -136,12 +204,14 @@ var tools = []Tool{
 		Name:        ToolAnalyzeWorkspace,
 		Description: "Analyze the entire workspace to discover packages, entry points, and dependencies. Use this when exploring a new codebase to understand the project structure, find main packages, API endpoints, and get a comprehensive overview of the codebase.",
 		Handler:     handleAnalyzeWorkspace, // new tool for workspace discovery
+		ReadOnly:    true,
 	},
 
 	GenericTool[api.IGetStarted, *api.OGetStarted]{
 		Name:        ToolGetStarted,
 		Description: "Get a beginner-friendly guide to start exploring the Go project. Returns project identity, quick stats, entry points, package categories, and recommended next steps. Use this when you're new to a codebase and want to understand where to start.",
 		Handler:     handleGetStarted, // new tool for getting started
+		ReadOnly:    true,
 	},
 
 	// ===== Dependency Analysis Tools =====
@@ -150,6 +220,7 @@ var tools = []Tool{
 		Name:        ToolGetDependencyGraph,
 		Description: "Get the dependency graph for a package. Returns both dependencies (packages it imports) and dependents (packages that import it). Use this to understand architectural relationships, analyze coupling, and visualize the package's place in the codebase.",
 		Handler:     handleGetDependencyGraph, // new tool for dependency graph analysis
+		ReadOnly:    true,
 	},
 }
 
@@ -158,16 +229,25 @@ var tools = []Tool{
 // Integration point: called from gopls/internal/cmd/mcp.go or gopls/internal/mcp/mcp.go
 func RegisterTools(server *mcp.Server, handler *Handler) {
 	// Register the list_tools meta-tool first (special case to avoid init cycle)
-	GenericTool[api.IListToolsParams, *api.OListToolsResult]{
+	listTools := GenericTool[api.IListToolsParams, *api.OListToolsResult]{
 		Name:        ToolListTools,
 		Description: "List all available gopls-mcp tools with documentation and parameter schemas. These semantic analysis tools are more accurate (type-aware vs text matching), faster after warm-up (17x-587x per operation, breaks even after 3 queries), and save tokens compared to text-based search by leveraging gopls's cached type information.",
 		Handler:     handleListTools,
-	}.Register(server, handler)
+		ReadOnly:    true,
+	}
+	handler.checkToolsConfig(append([]Tool{listTools}, handler.allTools()...))
+	if handler.toolEnabled(listTools) {
+		listTools.Register(server, handler)
+	}
 
-	// Register all other tools
-	for _, tool := range getTools() {
-		tool.Register(server, handler)
+	// Register all other tools, including the handler's custom tools,
+	// unless disabled by the tools config
+	for _, tool := range handler.allTools() {
+		if handler.toolEnabled(tool) {
+			tool.Register(server, handler)
+		}
 	}
+	server.AddReceivingMiddleware(filterListedTools)
 }
 
 // GenerateReference writes the complete tool reference documentation to the provided writer.
@@ -197,6 +277,9 @@ These tools are type-aware, fast, and token-efficient compared to text-based alt
 ### Key Concepts
 
 - **Symbol Locator**: Most tools use symbol_name + context_file to identify symbols semantically
+- **Symbol Handle**: Symbols in results carry a stable handle (e.g. "example.com/app/server#Server.Start"); pass it as locator.handle instead of symbol_name + context_file. A handle whose symbol was renamed or deleted is reported as stale
+- **Symbol Path**: Without a context file, pass the fully-qualified path of a symbol as locator.symbol_path: "net/http.Get", "example.com/app/store.(*DB).Query" (workspace, dependencies and standard library)
+- **Locator Resolution**: A locator that matches several symbols uses the best one and lists all of them, with their parent_scope, line and handle, in resolution.candidates; one that matches none fails with "did you mean" suggestions in resolution.suggestions
 - **JSON Schema**: Each tool's input schema is available via the MCP protocol (not duplicated here)
 - **Tool Relationships**: Tools cross-reference each other - see "See also" sections
 
@@ -221,11 +304,11 @@ func GenerateCLAUDEToolReference() (string, error) {
 	var buf strings.Builder
 
 	// Group tools by category
-	discovery := []string{"go_get_started", "go_analyze_workspace", "go_list_modules", "go_list_module_packages", "go_list_package_symbols", "go_search"}
+	discovery := []string{"go_get_started", "go_analyze_workspace", "go_list_modules", "go_list_module_packages", "go_list_package_symbols", "go_search", "go_search_by_signature", "go_search_docs", "go_structural_search"}
 	reading := []string{"go_definition", "go_symbol_references", "go_implementation", "go_read_file", "go_get_package_symbol_detail", "go_get_call_hierarchy"}
-	analysis := []string{"go_get_dependency_graph", "go_dryrun_rename_symbol"}
-	verification := []string{"go_build_check"}
-	meta := []string{"go_list_tools"}
+	analysis := []string{"go_get_dependency_graph", "go_dryrun_rename_symbol", "go_structural_replace"}
+	verification := []string{"go_build_check", "go_check_edit"}
+	meta := []string{"go_list_tools", "go_sync_document", "go_server_status"}
 
 	buf.WriteString("### Discovery & Navigation\n\n")
 	for _, name := range discovery {
@@ -0,0 +1,154 @@
+package core
+
+import (
+	"context"
+	"fmt"
+	"runtime"
+	"strings"
+	"time"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+	"golang.org/x/tools/gopls/internal/debug"
+	"golang.org/x/tools/gopls/internal/filecache"
+	"golang.org/x/tools/gopls/internal/protocol"
+	"golang.org/x/tools/gopls/mcpbridge/api"
+)
+
+// ===== go_server_status =====
+// Server introspection: reports what gopls-mcp currently thinks the
+// workspace is, from the cache.Session and its views, plus process-level
+// information in the spirit of gopls' debug pages (internal/debug).
+
+// startTime is the time the server process started.
+var startTime = time.Now()
+
+// FileWatcher is the file watcher keeping a session up to date, as reported
+// by go_server_status.
+type FileWatcher interface {
+	// Dir returns the watched directory.
+	Dir() string
+	// Pending returns the number of file events not yet delivered to gopls.
+	Pending() int
+	// Subscribe registers a function to be called with each batch of file
+	// events, once delivered to gopls.
+	Subscribe(func([]protocol.FileEvent))
+}
+
+// WithWatcher sets the file watcher reported by go_server_status, whose
+// events also keep the go_search symbol index up to date.
+func WithWatcher(w FileWatcher) HandlerOption {
+	return func(h *Handler) {
+		h.watcher = w
+	}
+}
+
+func handleGoServerStatus(ctx context.Context, h *Handler, req *mcp.CallToolRequest, input api.IServerStatusParams) (*mcp.CallToolResult, *api.OServerStatusResult, error) {
+	result := &api.OServerStatusResult{
+		GoplsVersion: debug.VersionInfo().Version,
+		Uptime:       time.Since(startTime).Round(time.Second).String(),
+	}
+
+	for _, view := range h.session.Views() {
+		snapshot, release, err := view.Snapshot()
+		if err != nil {
+			continue // view was shut down concurrently
+		}
+		env := view.Folder().Env
+		status := api.ViewStatus{
+			ID:                view.ID(),
+			Root:              view.Root().Path(),
+			Type:              view.Type().String(),
+			GOOS:              env.GOOS,
+			GOARCH:            env.GOARCH,
+			GoVersion:         view.GoVersionString(),
+			BuildFlags:        view.Folder().Options.BuildFlags,
+			Snapshot:          snapshot.SequenceID(),
+			Initialized:       view.Initialized(),
+			WorkspacePackages: snapshot.WorkspacePackages().Len(),
+		}
+		if initErr := snapshot.InitializationError(); initErr != nil {
+			status.InitError = initErr.MainError.Error()
+		}
+		status.LoadedPackages, status.TypeCheckedPackages = snapshot.PackageStats()
+		release()
+		result.Views = append(result.Views, status)
+	}
+
+	h.openDocumentsMu.Lock()
+	result.OpenDocuments = len(h.openDocuments)
+	h.openDocumentsMu.Unlock()
+
+	if h.watcher != nil {
+		result.Watcher = &api.WatcherStatus{
+			Dir:           h.watcher.Dir(),
+			PendingEvents: h.watcher.Pending(),
+		}
+	}
+
+	if input.IncludeFileCache {
+		dir, files, bytes, err := filecache.Usage()
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to read file cache: %v", err)
+		}
+		result.FileCache = &api.FileCacheStatus{
+			Dir:         dir,
+			Files:       files,
+			Bytes:       bytes,
+			BudgetBytes: filecache.Budget(),
+		}
+	}
+
+	var mem runtime.MemStats
+	runtime.ReadMemStats(&mem)
+	result.Memory = api.MemoryStatus{
+		HeapAllocBytes: mem.HeapAlloc,
+		SysBytes:       mem.Sys,
+		NumGC:          mem.NumGC,
+		Goroutines:     runtime.NumGoroutine(),
+	}
+
+	result.Summary = formatServerStatus(result)
+	return &mcp.CallToolResult{Content: []mcp.Content{&mcp.TextContent{Text: result.Summary}}}, result, nil
+}
+
+// formatServerStatus formats the server status for LLM consumption.
+func formatServerStatus(status *api.OServerStatusResult) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "gopls-mcp server status (gopls %s, up %s)\n\n", status.GoplsVersion, status.Uptime)
+
+	fmt.Fprintf(&b, "Views: %d\n", len(status.Views))
+	for _, v := range status.Views {
+		fmt.Fprintf(&b, "  - %s (%s, view %s)\n", v.Root, v.Type, v.ID)
+		fmt.Fprintf(&b, "    Go: %s, GOOS=%s GOARCH=%s\n", v.GoVersion, v.GOOS, v.GOARCH)
+		if len(v.BuildFlags) > 0 {
+			fmt.Fprintf(&b, "    Build flags: %s\n", strings.Join(v.BuildFlags, " "))
+		}
+		fmt.Fprintf(&b, "    Snapshot: %d", v.Snapshot)
+		if !v.Initialized {
+			b.WriteString(" (initial workspace load in progress)")
+		}
+		b.WriteString("\n")
+		if v.InitError != "" {
+			fmt.Fprintf(&b, "    Initialization error: %s\n", v.InitError)
+		}
+		fmt.Fprintf(&b, "    Packages: %d workspace, %d loaded, %d type-checked in memory\n", v.WorkspacePackages, v.LoadedPackages, v.TypeCheckedPackages)
+	}
+	b.WriteString("\n")
+
+	fmt.Fprintf(&b, "Open documents: %d\n", status.OpenDocuments)
+	if status.Watcher != nil {
+		fmt.Fprintf(&b, "File watcher: %s (%d pending events)\n", status.Watcher.Dir, status.Watcher.PendingEvents)
+	} else {
+		b.WriteString("File watcher: not running (file changes on disk are not detected)\n")
+	}
+	if fc := status.FileCache; fc != nil {
+		fmt.Fprintf(&b, "File cache: %s (%d files, %.1f MB of %.1f MB budget)\n", fc.Dir, fc.Files, megabytes(uint64(fc.Bytes)), megabytes(uint64(fc.BudgetBytes)))
+	}
+	fmt.Fprintf(&b, "Memory: %.1f MB heap, %.1f MB from OS, %d GCs, %d goroutines\n",
+		megabytes(status.Memory.HeapAllocBytes), megabytes(status.Memory.SysBytes), status.Memory.NumGC, status.Memory.Goroutines)
+	return b.String()
+}
+
+func megabytes(n uint64) float64 {
+	return float64(n) / (1 << 20)
+}
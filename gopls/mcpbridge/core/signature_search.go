@@ -0,0 +1,571 @@
+package core
+
+import (
+	"cmp"
+	"context"
+	"fmt"
+	"go/ast"
+	"go/constant"
+	"go/parser"
+	"go/token"
+	"go/types"
+	"regexp"
+	"slices"
+	"strings"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+	"golang.org/x/tools/gopls/internal/cache"
+	"golang.org/x/tools/gopls/internal/cache/metadata"
+	"golang.org/x/tools/gopls/internal/golang"
+	"golang.org/x/tools/gopls/internal/util/safetoken"
+	"golang.org/x/tools/gopls/mcpbridge/api"
+)
+
+// ===== go_search_by_signature =====
+// Search for functions and methods by the shape of their signature, using
+// the type information of the snapshot. A pattern is a function type whose
+// parameter and result types are resolved in the scope of each searched
+// package, and matched by assignability:
+//
+//   - a pattern parameter type matches if it is assignable to the
+//     function's parameter type (the function accepts such an argument);
+//   - a function result type matches if it is assignable to the pattern
+//     result type (the result can be used as such a value).
+//
+// "_" matches any type, also within composite types ("[]_", "map[string]_"),
+// and "..." as a parameter or result matches any number of them.
+
+// signatureRestName is the identifier that stands for a "..." parameter or
+// result of a pattern, which is not valid Go syntax.
+const signatureRestName = "__rest__"
+
+// signatureRestRx matches the "..." parameters and results of a pattern,
+// but not variadic parameters ("...T").
+var signatureRestRx = regexp.MustCompile(`\.\.\.(\s*[,)]|\s*$)`)
+
+func handleGoSearchBySignature(ctx context.Context, h *Handler, req *mcp.CallToolRequest, input api.ISearchBySignatureParams) (*mcp.CallToolResult, *api.OSearchBySignatureResult, error) {
+	pattern, err := parseSignaturePattern(input.Signature)
+	if err != nil {
+		return nil, nil, err
+	}
+	maxResults := input.MaxResults
+	if maxResults <= 0 {
+		maxResults = 20
+	}
+
+	snapshot, release, err := h.snapshotForDir(input.Cwd)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer release()
+
+	symbols, err := searchBySignature(ctx, snapshot, pattern, input.IncludeDependencies)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to search by signature: %v", err)
+	}
+	total := len(symbols)
+	if total > maxResults {
+		symbols = symbols[:maxResults]
+	}
+
+	var summary strings.Builder
+	if total == 0 {
+		fmt.Fprintf(&summary, "No functions or methods found matching %s.", pattern.text)
+	} else {
+		fmt.Fprintf(&summary, "Found %d function(s) or method(s) matching %s:\n", total, pattern.text)
+		for _, sym := range symbols {
+			fmt.Fprintf(&summary, "  - %s.%s %s (%s:%d)\n", sym.PackagePath, qualifiedSymbolName(sym), sym.Signature, sym.FilePath, sym.Line)
+		}
+		if total > len(symbols) {
+			fmt.Fprintf(&summary, "... and %d more (use max_results for more)\n", total-len(symbols))
+		}
+	}
+
+	result := &api.OSearchBySignatureResult{
+		Summary: summary.String(),
+		Symbols: symbols,
+		Total:   total,
+	}
+	return &mcp.CallToolResult{Content: []mcp.Content{&mcp.TextContent{Text: result.Summary}}}, result, nil
+}
+
+// signaturePattern is a parsed go_search_by_signature pattern.
+type signaturePattern struct {
+	text    string     // the pattern, as given
+	params  []ast.Expr // parameter types; signatureRestName stands for "..."
+	results []ast.Expr // result types
+}
+
+// parseSignaturePattern parses a function type pattern. The "func" keyword
+// may be omitted.
+func parseSignaturePattern(text string) (*signaturePattern, error) {
+	text = strings.TrimSpace(text)
+	if text == "" {
+		return nil, fmt.Errorf("signature is required, e.g. func(context.Context, *http.Request) error")
+	}
+	src := text
+	if !strings.HasPrefix(src, "func") {
+		src = "func" + src
+	}
+	src = signatureRestRx.ReplaceAllString(src, signatureRestName+"$1")
+
+	expr, err := parser.ParseExpr(src)
+	if err != nil {
+		return nil, fmt.Errorf("invalid signature %q: %v", text, err)
+	}
+	fn, ok := expr.(*ast.FuncType)
+	if !ok {
+		return nil, fmt.Errorf("invalid signature %q: expected a function type such as func(io.Reader) (*Config, error)", text)
+	}
+
+	pattern := &signaturePattern{text: text}
+	pattern.params = fieldTypes(fn.Params)
+	pattern.results = fieldTypes(fn.Results)
+	for i, t := range slices.Concat(pattern.params, pattern.results) {
+		if err := checkPatternType(t, i == len(pattern.params)-1); err != nil {
+			return nil, fmt.Errorf("invalid signature %q: %v", text, err)
+		}
+	}
+	return pattern, nil
+}
+
+// fieldTypes returns the types of the fields of a parameter or result list,
+// one per name.
+func fieldTypes(fields *ast.FieldList) []ast.Expr {
+	if fields == nil {
+		return nil
+	}
+	var types []ast.Expr
+	for _, field := range fields.List {
+		for range max(len(field.Names), 1) {
+			types = append(types, field.Type)
+		}
+	}
+	return types
+}
+
+// checkPatternType reports an error if t is not a supported type
+// expression. A variadic type is allowed if lastParam is set.
+func checkPatternType(t ast.Expr, lastParam bool) error {
+	var err error
+	ast.Inspect(t, func(n ast.Node) bool {
+		if err != nil {
+			return false
+		}
+		switch n := n.(type) {
+		case nil, *ast.Ident, *ast.StarExpr, *ast.ParenExpr, *ast.MapType, *ast.ChanType, *ast.FuncType, *ast.FieldList, *ast.Field:
+		case *ast.Ellipsis:
+			if n != t || !lastParam {
+				err = fmt.Errorf("a variadic parameter must be the last parameter")
+			}
+		case *ast.SelectorExpr:
+			if _, ok := n.X.(*ast.Ident); !ok {
+				err = fmt.Errorf("unsupported type %s: expected a package-qualified name such as io.Reader", types.ExprString(n))
+			}
+			return false
+		case *ast.ArrayType:
+			if n.Len != nil {
+				if lit, ok := n.Len.(*ast.BasicLit); !ok || lit.Kind != token.INT {
+					err = fmt.Errorf("unsupported array length in %s", types.ExprString(n))
+				}
+			}
+		case *ast.BasicLit:
+			// array length, checked above
+		case *ast.InterfaceType:
+			if n.Methods != nil && len(n.Methods.List) > 0 {
+				err = fmt.Errorf("unsupported type %s: use a named interface type", types.ExprString(n))
+			}
+			return false
+		default:
+			err = fmt.Errorf("unsupported type %s", types.ExprString(t))
+		}
+		return true
+	})
+	return err
+}
+
+// isRest reports whether t stands for a "..." parameter or result.
+func isRest(t ast.Expr) bool {
+	id, ok := t.(*ast.Ident)
+	return ok && id.Name == signatureRestName
+}
+
+// searchBySignature returns the functions and methods of the workspace
+// packages, and of their dependencies if includeDeps is set, that match the
+// pattern: those of the workspace first, then by package path and name.
+func searchBySignature(ctx context.Context, snapshot *cache.Snapshot, pattern *signaturePattern, includeDeps bool) ([]*api.Symbol, error) {
+	mps, err := snapshot.WorkspaceMetadata(ctx)
+	if err != nil {
+		return nil, err
+	}
+	var ids []metadata.PackageID
+	for _, mp := range mps {
+		if mp.ForTest == "" && !metadata.IsCommandLineArguments(mp.ID) {
+			ids = append(ids, mp.ID)
+		}
+	}
+	pkgs, err := snapshot.TypeCheck(ctx, ids...)
+	if err != nil {
+		return nil, err
+	}
+
+	var (
+		workspace = make(map[*types.Package]bool)
+		fsets     = make(map[*types.Package]*token.FileSet) // FileSet describing each package's positions
+	)
+	for _, pkg := range pkgs {
+		workspace[pkg.Types()] = true
+		fsets[pkg.Types()] = pkg.FileSet()
+	}
+	if includeDeps {
+		for _, pkg := range pkgs {
+			for _, dep := range importClosure(pkg.Types()) {
+				if _, ok := fsets[dep]; !ok {
+					fsets[dep] = pkg.FileSet()
+				}
+			}
+		}
+	}
+
+	var wsSymbols, depSymbols []*api.Symbol
+	for pkg, fset := range fsets {
+		if ctx.Err() != nil {
+			return nil, ctx.Err()
+		}
+		m := &signatureMatcher{pattern: pattern, pkg: pkg, scope: importClosure(pkg)}
+		for _, fn := range packageFuncs(pkg, !workspace[pkg]) {
+			if m.matches(fn.Signature()) {
+				sym := funcSymbol(fset, fn)
+				if workspace[pkg] {
+					wsSymbols = append(wsSymbols, sym)
+				} else {
+					depSymbols = append(depSymbols, sym)
+				}
+			}
+		}
+	}
+	for _, symbols := range [][]*api.Symbol{wsSymbols, depSymbols} {
+		slices.SortFunc(symbols, func(a, b *api.Symbol) int {
+			return cmp.Or(
+				cmp.Compare(a.PackagePath, b.PackagePath),
+				cmp.Compare(qualifiedSymbolName(a), qualifiedSymbolName(b)),
+			)
+		})
+	}
+	return append(wsSymbols, depSymbols...), nil
+}
+
+// importClosure returns pkg and the packages it transitively imports, in
+// breadth-first order.
+func importClosure(pkg *types.Package) []*types.Package {
+	seen := map[*types.Package]bool{pkg: true}
+	closure := []*types.Package{pkg}
+	for i := 0; i < len(closure); i++ {
+		for _, imp := range closure[i].Imports() {
+			if !seen[imp] {
+				seen[imp] = true
+				closure = append(closure, imp)
+			}
+		}
+	}
+	return closure
+}
+
+// packageFuncs returns the package-level functions of pkg and the methods
+// declared on its named types, or only the exported ones of exported types
+// if exportedOnly is set.
+func packageFuncs(pkg *types.Package, exportedOnly bool) []*types.Func {
+	var funcs []*types.Func
+	scope := pkg.Scope()
+	for _, name := range scope.Names() {
+		if exportedOnly && !token.IsExported(name) {
+			continue
+		}
+		switch obj := scope.Lookup(name).(type) {
+		case *types.Func:
+			funcs = append(funcs, obj)
+		case *types.TypeName:
+			if named, ok := obj.Type().(*types.Named); ok && !obj.IsAlias() {
+				for method := range named.Methods() {
+					if !exportedOnly || method.Exported() {
+						funcs = append(funcs, method)
+					}
+				}
+			}
+		}
+	}
+	return funcs
+}
+
+// funcSymbol returns the symbol of a function or method.
+func funcSymbol(fset *token.FileSet, fn *types.Func) *api.Symbol {
+	pkg := fn.Pkg()
+	qualifier := func(p *types.Package) string {
+		if p == pkg {
+			return ""
+		}
+		return p.Name()
+	}
+	sig := fn.Signature()
+	sym := &api.Symbol{
+		Name:        fn.Name(),
+		Kind:        api.SymbolKindFunction,
+		Signature:   types.TypeString(sig, qualifier),
+		PackagePath: pkg.Path(),
+		Handle:      golang.SymbolHandle(fn),
+	}
+	if recv := sig.Recv(); recv != nil {
+		sym.Kind = api.SymbolKindMethod
+		t := recv.Type()
+		ptr := ""
+		if p, ok := t.(*types.Pointer); ok {
+			ptr, t = "*", p.Elem()
+		}
+		if named, ok := types.Unalias(t).(*types.Named); ok {
+			sym.Receiver = ptr + named.Obj().Name()
+		}
+	}
+	if posn := safetoken.StartPosition(fset, fn.Pos()); posn.IsValid() {
+		sym.FilePath = posn.Filename
+		sym.Line = posn.Line
+	}
+	return sym
+}
+
+// signatureMatcher matches signatures against a pattern, resolving the
+// pattern's types in the scope of a package.
+type signatureMatcher struct {
+	pattern *signaturePattern
+	pkg     *types.Package
+	scope   []*types.Package // the packages whose names qualified types refer to
+
+	resolved map[ast.Expr]types.Type // cache of resolve; nil values for unresolved types
+}
+
+// matches reports whether sig matches the pattern.
+func (m *signatureMatcher) matches(sig *types.Signature) bool {
+	return m.matchList(m.pattern.params, sig.Params(), sig.Variadic(), true) &&
+		m.matchList(m.pattern.results, sig.Results(), false, false)
+}
+
+// matchList reports whether the pattern types match the types of a
+// parameter (if params is set) or result list. "..." in the pattern matches
+// any number of types, and the last parameter of a variadic function any
+// number of arguments.
+func (m *signatureMatcher) matchList(pattern []ast.Expr, tuple *types.Tuple, variadic, params bool) bool {
+	var match func(pi, ti int) bool
+	match = func(pi, ti int) bool {
+		if pi == len(pattern) {
+			return ti == tuple.Len() || variadic && ti == tuple.Len()-1
+		}
+		p := pattern[pi]
+		if isRest(p) {
+			return match(pi+1, ti) || ti < tuple.Len() && match(pi, ti+1)
+		}
+		if ti == tuple.Len() {
+			return false
+		}
+		t := tuple.At(ti).Type()
+		if variadic && ti == tuple.Len()-1 {
+			if _, ok := p.(*ast.Ellipsis); ok {
+				return m.matchType(p, t, params) && match(pi+1, ti+1)
+			}
+			elem := t.(*types.Slice).Elem()
+			return m.matchType(p, elem, params) && match(pi+1, ti)
+		}
+		return m.matchType(p, t, params) && match(pi+1, ti+1)
+	}
+	return match(0, 0)
+}
+
+// matchType reports whether the pattern type p matches t: if param is set,
+// whether a value of type p is assignable to t, and otherwise whether a
+// value of type t is assignable to p. Pattern types with wildcards match
+// structurally.
+func (m *signatureMatcher) matchType(p ast.Expr, t types.Type, param bool) bool {
+	if hasWildcard(p) {
+		return m.matchStructure(p, t)
+	}
+	pt := m.resolve(p)
+	if pt == nil {
+		return false
+	}
+	if tparam, ok := types.Unalias(t).(*types.TypeParam); ok {
+		// A type parameter may be instantiated with the pattern type.
+		iface, ok := tparam.Constraint().Underlying().(*types.Interface)
+		return !param || ok && types.Satisfies(pt, iface)
+	}
+	if param {
+		return types.AssignableTo(pt, t)
+	}
+	return types.AssignableTo(t, pt)
+}
+
+// hasWildcard reports whether the pattern type contains "_", or "..." in
+// a function type.
+func hasWildcard(p ast.Expr) bool {
+	found := false
+	ast.Inspect(p, func(n ast.Node) bool {
+		if id, ok := n.(*ast.Ident); ok && (id.Name == "_" || id.Name == signatureRestName) {
+			found = true
+		}
+		return !found
+	})
+	return found
+}
+
+// matchStructure reports whether t has the structure of the pattern type p,
+// where "_" matches any type and the parts without wildcards must be
+// identical.
+func (m *signatureMatcher) matchStructure(p ast.Expr, t types.Type) bool {
+	if !hasWildcard(p) {
+		pt := m.resolve(p)
+		return pt != nil && types.Identical(pt, t)
+	}
+	t = types.Unalias(t)
+	switch p := p.(type) {
+	case *ast.Ident: // "_"
+		return true
+	case *ast.ParenExpr:
+		return m.matchStructure(p.X, t)
+	case *ast.StarExpr:
+		ptr, ok := t.(*types.Pointer)
+		return ok && m.matchStructure(p.X, ptr.Elem())
+	case *ast.Ellipsis:
+		slice, ok := t.(*types.Slice)
+		return ok && m.matchStructure(p.Elt, slice.Elem())
+	case *ast.ArrayType:
+		if p.Len == nil {
+			slice, ok := t.(*types.Slice)
+			return ok && m.matchStructure(p.Elt, slice.Elem())
+		}
+		array, ok := t.(*types.Array)
+		return ok && arrayLen(p) == array.Len() && m.matchStructure(p.Elt, array.Elem())
+	case *ast.MapType:
+		mt, ok := t.(*types.Map)
+		return ok && m.matchStructure(p.Key, mt.Key()) && m.matchStructure(p.Value, mt.Elem())
+	case *ast.ChanType:
+		ch, ok := t.(*types.Chan)
+		return ok && chanDir(p) == ch.Dir() && m.matchStructure(p.Value, ch.Elem())
+	case *ast.FuncType:
+		sig, ok := t.(*types.Signature)
+		return ok && m.matchList(fieldTypes(p.Params), sig.Params(), sig.Variadic(), true) &&
+			m.matchList(fieldTypes(p.Results), sig.Results(), false, false)
+	}
+	return false
+}
+
+// resolve returns the type denoted by the pattern type p, which has no
+// wildcards, in the scope of the package, or nil if it does not denote a
+// type in that scope.
+func (m *signatureMatcher) resolve(p ast.Expr) types.Type {
+	if t, ok := m.resolved[p]; ok {
+		return t
+	}
+	t := m.doResolve(p)
+	if m.resolved == nil {
+		m.resolved = make(map[ast.Expr]types.Type)
+	}
+	m.resolved[p] = t
+	return t
+}
+
+func (m *signatureMatcher) doResolve(p ast.Expr) types.Type {
+	switch p := p.(type) {
+	case *ast.Ident:
+		if obj, ok := m.pkg.Scope().Lookup(p.Name).(*types.TypeName); ok {
+			return obj.Type()
+		}
+		if obj, ok := types.Universe.Lookup(p.Name).(*types.TypeName); ok {
+			return obj.Type()
+		}
+	case *ast.SelectorExpr:
+		pkgName := p.X.(*ast.Ident).Name
+		for _, pkg := range m.scope {
+			if pkg.Name() != pkgName {
+				continue
+			}
+			if obj, ok := pkg.Scope().Lookup(p.Sel.Name).(*types.TypeName); ok && obj.Exported() {
+				return obj.Type()
+			}
+		}
+	case *ast.ParenExpr:
+		return m.resolve(p.X)
+	case *ast.StarExpr:
+		if elem := m.resolve(p.X); elem != nil {
+			return types.NewPointer(elem)
+		}
+	case *ast.Ellipsis:
+		if elem := m.resolve(p.Elt); elem != nil {
+			return types.NewSlice(elem)
+		}
+	case *ast.ArrayType:
+		if elem := m.resolve(p.Elt); elem != nil {
+			if p.Len == nil {
+				return types.NewSlice(elem)
+			}
+			return types.NewArray(elem, arrayLen(p))
+		}
+	case *ast.MapType:
+		key, elem := m.resolve(p.Key), m.resolve(p.Value)
+		if key != nil && elem != nil {
+			return types.NewMap(key, elem)
+		}
+	case *ast.ChanType:
+		if elem := m.resolve(p.Value); elem != nil {
+			return types.NewChan(chanDir(p), elem)
+		}
+	case *ast.InterfaceType:
+		return types.NewInterfaceType(nil, nil).Complete()
+	case *ast.FuncType:
+		params, variadic, ok := m.resolveTuple(p.Params)
+		if !ok {
+			return nil
+		}
+		results, _, ok := m.resolveTuple(p.Results)
+		if !ok {
+			return nil
+		}
+		return types.NewSignatureType(nil, nil, nil, params, results, variadic)
+	}
+	return nil
+}
+
+// resolveTuple resolves the types of a parameter or result list of a
+// function type without "..." parameters or results.
+func (m *signatureMatcher) resolveTuple(fields *ast.FieldList) (*types.Tuple, bool, bool) {
+	var (
+		vars     []*types.Var
+		variadic bool
+	)
+	for _, p := range fieldTypes(fields) {
+		if isRest(p) {
+			return nil, false, false
+		}
+		_, variadic = p.(*ast.Ellipsis)
+		t := m.resolve(p)
+		if t == nil {
+			return nil, false, false
+		}
+		vars = append(vars, types.NewParam(token.NoPos, m.pkg, "", t))
+	}
+	return types.NewTuple(vars...), variadic, true
+}
+
+// arrayLen returns the length of an array type of a pattern, which was
+// checked to be an integer literal.
+func arrayLen(p *ast.ArrayType) int64 {
+	n, _ := constant.Int64Val(constant.MakeFromLiteral(p.Len.(*ast.BasicLit).Value, token.INT, 0))
+	return n
+}
+
+// chanDir returns the direction of a channel type of a pattern.
+func chanDir(p *ast.ChanType) types.ChanDir {
+	switch p.Dir {
+	case ast.SEND:
+		return types.SendOnly
+	case ast.RECV:
+		return types.RecvOnly
+	}
+	return types.SendRecv
+}
@@ -0,0 +1,133 @@
+package core
+
+import (
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"go/types"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestParseSignaturePattern(t *testing.T) {
+	for _, test := range []struct {
+		pattern string
+		params  []string // the parameter types; __rest__ for "..."
+		results []string
+		err     string // a substring of the error, if any
+	}{
+		{pattern: "func(int) error", params: []string{"int"}, results: []string{"error"}},
+		{pattern: "(a, b string) (int, bool)", params: []string{"string", "string"}, results: []string{"int", "bool"}},
+		{pattern: "func(context.Context, ...) error", params: []string{"context.Context", "__rest__"}, results: []string{"error"}},
+		{pattern: "func(...string)", params: []string{"...string"}},
+		{pattern: "func(...)", params: []string{"__rest__"}},
+		{pattern: "func() (*_, ...)", results: []string{"*_", "__rest__"}},
+		{pattern: "func(map[string][]byte, chan<- int, [4]T)", params: []string{"map[string][]byte", "chan<- int", "[4]T"}},
+		{pattern: "", err: "signature is required"},
+		{pattern: "func(", err: "invalid signature"},
+		{pattern: "int", err: "expected a function type"},
+		{pattern: "func(...int, string)", err: "can only use ... with final parameter"},
+		{pattern: "func(a.b.C)", err: "invalid signature"},
+		{pattern: "func([n]int)", err: "unsupported array length"},
+		{pattern: "func(interface{ M() })", err: "use a named interface type"},
+		{pattern: "func(struct{})", err: "unsupported type"},
+	} {
+		pattern, err := parseSignaturePattern(test.pattern)
+		if test.err != "" {
+			if err == nil || !strings.Contains(err.Error(), test.err) {
+				t.Errorf("parseSignaturePattern(%q) = %v, want error containing %q", test.pattern, err, test.err)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("parseSignaturePattern(%q) failed: %v", test.pattern, err)
+			continue
+		}
+		if got := exprStrings(pattern.params); !slices.Equal(got, test.params) {
+			t.Errorf("parseSignaturePattern(%q).params = %q, want %q", test.pattern, got, test.params)
+		}
+		if got := exprStrings(pattern.results); !slices.Equal(got, test.results) {
+			t.Errorf("parseSignaturePattern(%q).results = %q, want %q", test.pattern, got, test.results)
+		}
+	}
+}
+
+// exprStrings returns the source of the expressions.
+func exprStrings(exprs []ast.Expr) []string {
+	var strs []string
+	for _, e := range exprs {
+		strs = append(strs, types.ExprString(e))
+	}
+	return strs
+}
+
+const signatureSrc = `package p
+
+type Reader interface{ Read([]byte) (int, error) }
+
+type File struct{}
+
+func (*File) Read([]byte) (int, error) { return 0, nil }
+
+func Open(name string) (*File, error)                { return nil, nil }
+func Copy(dst *File, src Reader) (int64, error)      { return 0, nil }
+func Printf(format string, args ...any)              {}
+func Join(elems ...string) string                    { return "" }
+func Walk(root string, fn func(string, error) error) {}
+func Send(ch chan<- int, v [2]int)                   {}
+func Index[T comparable](s []T, v T) int             { return 0 }
+`
+
+func TestSignatureMatcher(t *testing.T) {
+	fset := token.NewFileSet()
+	f, err := parser.ParseFile(fset, "p.go", signatureSrc, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	pkg, err := new(types.Config).Check("example.com/p", fset, []*ast.File{f}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, test := range []struct {
+		pattern string
+		want    []string // the matching functions
+	}{
+		{"func(string) (*File, error)", []string{"Open"}},
+		{"func(string) (Reader, error)", []string{"Open"}}, // *File is assignable to Reader
+		{"func(*File, *File) (int64, error)", []string{"Copy"}},
+		{"func(...) error", nil},
+		{"func(...) (..., error)", []string{"Copy", "Open"}},
+		{"func(string, ...)", []string{"Printf", "Walk"}}, // no results
+		{"func(string, ...) (...)", []string{"Join", "Open", "Printf", "Walk"}},
+		{"func(string)", []string{"Printf"}}, // with no variadic argument
+		{"func(string) string", []string{"Join"}},
+		{"func(string, string, string) string", []string{"Join"}},
+		{"func() string", []string{"Join"}},
+		{"func(...string) string", []string{"Join"}},
+		{"func(string, func(string, error) error)", []string{"Printf", "Walk"}}, // a func is assignable to any
+		{"func(_, func(...) error)", []string{"Walk"}},
+		{"func(chan<- int, [2]int)", []string{"Send"}},
+		{"func(chan int, [2]int)", []string{"Send"}}, // a bidirectional channel is assignable to a send-only one
+		{"func(chan<- _, [3]_)", nil},
+		{"func(_, int) int", []string{"Index"}}, // int satisfies comparable
+		{"func([]int, int) int", nil},
+		{"func(Unknown) error", nil},
+	} {
+		pattern, err := parseSignaturePattern(test.pattern)
+		if err != nil {
+			t.Fatalf("parseSignaturePattern(%q) failed: %v", test.pattern, err)
+		}
+		m := &signatureMatcher{pattern: pattern, pkg: pkg, scope: []*types.Package{pkg}}
+		var got []string
+		for _, name := range pkg.Scope().Names() {
+			if fn, ok := pkg.Scope().Lookup(name).(*types.Func); ok && m.matches(fn.Type().(*types.Signature)) {
+				got = append(got, name)
+			}
+		}
+		if !slices.Equal(got, test.want) {
+			t.Errorf("%s matches %q, want %q", test.pattern, got, test.want)
+		}
+	}
+}
@@ -0,0 +1,466 @@
+package core
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"go/ast"
+	"go/token"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+	"golang.org/x/tools/gopls/internal/cache"
+	"golang.org/x/tools/gopls/internal/file"
+	"golang.org/x/tools/gopls/internal/protocol"
+	"golang.org/x/tools/gopls/internal/settings"
+	"golang.org/x/tools/gopls/internal/util/safetoken"
+	"golang.org/x/tools/gopls/mcpbridge/api"
+	"golang.org/x/tools/internal/diff"
+	"golang.org/x/tools/internal/imports"
+)
+
+// ===== go_structural_replace =====
+// Search and replace by AST pattern: each match of a go_structural_search
+// pattern is replaced by a template in which the metavariables stand for
+// the source of the code they matched. Imports are then fixed and the
+// changed files formatted, like goimports. Changes are previewed as a
+// unified diff unless applied.
+
+func handleGoStructuralReplace(ctx context.Context, h *Handler, req *mcp.CallToolRequest, input api.IStructuralReplaceParams) (*mcp.CallToolResult, *api.OStructuralReplaceResult, error) {
+	pattern, err := parseStructuralPattern(input.Pattern, input.Constraints)
+	if err != nil {
+		return nil, nil, err
+	}
+	tmpl, err := parseReplacementTemplate(input.Replacement, pattern)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	snapshot, release, err := h.snapshotForDir(input.Cwd)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer release()
+
+	filter, err := newSearchFilter(api.ISearchParams{Package: input.Package}, snapshot.View().Root().Path())
+	if err != nil {
+		return nil, nil, err
+	}
+	matches, err := structuralSearch(ctx, snapshot, pattern, filter)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to search: %v", err)
+	}
+
+	result := &api.OStructuralReplaceResult{}
+	var (
+		edits   []*structuralEdit
+		changes []protocol.DocumentChange
+	)
+	for i := 0; i < len(matches); {
+		j := i + 1
+		for j < len(matches) && matches[j].pgf == matches[i].pgf {
+			j++
+		}
+		edit, err := replaceInFile(ctx, snapshot, matches[i:j], tmpl)
+		if err != nil {
+			return nil, nil, err
+		}
+		i = j
+		result.Replacements += edit.replacements
+		result.Skipped += edit.skipped
+		if bytes.Equal(edit.content, edit.newContent) {
+			continue
+		}
+		mapper := protocol.NewMapper(edit.fh.URI(), edit.content)
+		textEdits, err := protocol.EditsFromDiffEdits(mapper, diff.Bytes(edit.content, edit.newContent))
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to compute edits: %v", err)
+		}
+		edits = append(edits, edit)
+		changes = append(changes, protocol.DocumentChangeEdit(edit.fh, textEdits))
+		result.Files = append(result.Files, edit.fh.URI().Path())
+	}
+	if result.Diff, err = toUnifiedDiff(ctx, snapshot, changes); err != nil {
+		return nil, nil, fmt.Errorf("failed to compute diff: %v", err)
+	}
+
+	if input.Apply && len(edits) > 0 {
+		written, err := h.applyStructuralEdits(ctx, snapshot, edits)
+		if err != nil {
+			msg := fmt.Sprintf("failed to apply the replacement: %v", err)
+			if len(written) > 0 {
+				msg += fmt.Sprintf("; these files were changed and could not be restored: %s", strings.Join(written, ", "))
+			} else {
+				msg += "; no file was changed"
+			}
+			result.Files = written
+			result.Summary = msg
+			return &mcp.CallToolResult{IsError: true, Content: []mcp.Content{&mcp.TextContent{Text: msg}}}, result, nil
+		}
+		result.Applied = true
+	}
+
+	var summary strings.Builder
+	switch {
+	case result.Replacements == 0:
+		fmt.Fprintf(&summary, "No matches found for %s.", input.Pattern)
+	case result.Applied:
+		fmt.Fprintf(&summary, "Replaced %d match(es) of %s in %d file(s):\n\n%s", result.Replacements, input.Pattern, len(result.Files), result.Diff)
+	default:
+		fmt.Fprintf(&summary, "=== DRY RUN: Structural Replace Preview ===\n")
+		fmt.Fprintf(&summary, "%d match(es) of %s would be replaced in %d file(s).\n", result.Replacements, input.Pattern, len(result.Files))
+		fmt.Fprintf(&summary, "NO FILES HAVE BEEN MODIFIED - this is a preview only. Set apply to true to write the changes.\n\n")
+		fmt.Fprintf(&summary, "%s\n", result.Diff)
+	}
+	if result.Skipped > 0 {
+		fmt.Fprintf(&summary, "\n%d match(es) nested in other matches were not replaced; run again to replace them.\n", result.Skipped)
+	}
+	result.Summary = summary.String()
+	return &mcp.CallToolResult{Content: []mcp.Content{&mcp.TextContent{Text: result.Summary}}}, result, nil
+}
+
+// replacementTemplate is a parsed replacement.
+type replacementTemplate struct {
+	*metaSyntax
+	start, end int           // offsets of the template in src
+	vars       []templateVar // metavariables, by offset
+}
+
+// templateVar is an occurrence of a metavariable in a template.
+type templateVar struct {
+	name       string
+	ident      *ast.Ident
+	parent     ast.Node
+	start, end int // offsets in src
+}
+
+// parseReplacementTemplate parses a replacement for the matches of the
+// pattern. An empty replacement deletes the matches.
+func parseReplacementTemplate(text string, pattern *structuralPattern) (*replacementTemplate, error) {
+	tmpl := &replacementTemplate{}
+	if strings.TrimSpace(text) == "" {
+		tmpl.metaSyntax = &metaSyntax{}
+		return tmpl, nil
+	}
+	syntax, err := parseMetaSyntax(text)
+	if err != nil {
+		return nil, fmt.Errorf("invalid replacement %q: %v", text, err)
+	}
+	if pattern.isExpr() && !syntax.isExpr() {
+		return nil, fmt.Errorf("invalid replacement %q: the pattern is an expression, so the replacement must be one too", text)
+	}
+	tmpl.metaSyntax = syntax
+
+	tok := syntax.fset.File(syntax.nodes[0].Pos())
+	tmpl.start = tok.Offset(syntax.nodes[0].Pos())
+	tmpl.end = tok.Offset(syntax.nodes[len(syntax.nodes)-1].End())
+	for _, node := range syntax.nodes {
+		var stack []ast.Node
+		var walkErr error
+		ast.Inspect(node, func(n ast.Node) bool {
+			if n == nil {
+				stack = stack[:len(stack)-1]
+				return false
+			}
+			if id, ok := n.(*ast.Ident); ok {
+				name, isVar := strings.CutPrefix(id.Name, metaListVarPrefix)
+				if !isVar {
+					name, isVar = strings.CutPrefix(id.Name, metaVarPrefix)
+				}
+				if isVar && (name == "_" || !pattern.names[name]) {
+					walkErr = fmt.Errorf("invalid replacement %q: $%s is not bound by the pattern", text, name)
+				} else if isVar {
+					var parent ast.Node
+					if len(stack) > 0 {
+						parent = stack[len(stack)-1]
+					}
+					tmpl.vars = append(tmpl.vars, templateVar{
+						name:   name,
+						ident:  id,
+						parent: parent,
+						start:  tok.Offset(id.Pos()),
+						end:    tok.Offset(id.End()),
+					})
+				}
+			}
+			stack = append(stack, n)
+			return walkErr == nil
+		})
+		if walkErr != nil {
+			return nil, walkErr
+		}
+	}
+	return tmpl, nil
+}
+
+// expand returns the replacement of a match: the template, in which the
+// metavariables are replaced by the source of their bindings, in
+// parentheses where required by the context.
+func (tmpl *replacementTemplate) expand(m *structuralMatch) string {
+	if len(tmpl.nodes) == 0 {
+		return ""
+	}
+	var buf strings.Builder
+	pos := tmpl.start
+	for _, v := range tmpl.vars {
+		buf.WriteString(tmpl.src[pos:v.start])
+		b := m.bindings[v.name]
+		text := b.text(m.pgf)
+		if !b.isList && needsParens(v.parent, v.ident, b.node) {
+			text = "(" + text + ")"
+		}
+		buf.WriteString(text)
+		pos = v.end
+	}
+	buf.WriteString(tmpl.src[pos:tmpl.end])
+
+	if m.node != nil && len(tmpl.nodes) == 1 && needsParens(m.parent, m.node, tmpl.nodes[0]) {
+		return "(" + buf.String() + ")"
+	}
+	return buf.String()
+}
+
+// needsParens reports whether the expression expr must be parenthesized to
+// replace child, a child of parent.
+func needsParens(parent, child, expr ast.Node) bool {
+	switch expr.(type) {
+	case *ast.BinaryExpr, *ast.UnaryExpr, *ast.StarExpr:
+	default:
+		return false
+	}
+	switch parent := parent.(type) {
+	case *ast.BinaryExpr:
+		x, ok := expr.(*ast.BinaryExpr)
+		return ok && (x.Op.Precedence() < parent.Op.Precedence() ||
+			x.Op.Precedence() == parent.Op.Precedence() && parent.Y == child)
+	case *ast.UnaryExpr, *ast.StarExpr:
+		_, ok := expr.(*ast.BinaryExpr)
+		return ok
+	case *ast.SelectorExpr:
+		return parent.X == child
+	case *ast.CallExpr:
+		return parent.Fun == child
+	case *ast.IndexExpr:
+		return parent.X == child
+	case *ast.IndexListExpr:
+		return parent.X == child
+	case *ast.SliceExpr:
+		return parent.X == child
+	case *ast.TypeAssertExpr:
+		return parent.X == child
+	}
+	return false
+}
+
+// structuralEdit is the new content of a file in which matches were
+// replaced.
+type structuralEdit struct {
+	fh                  file.Handle
+	content, newContent []byte
+	replacements        int
+	skipped             int // matches nested in replaced ones
+}
+
+// replaceInFile replaces the matches of a file, outer matches first, and
+// fixes its imports.
+func replaceInFile(ctx context.Context, snapshot *cache.Snapshot, matches []*structuralMatch, tmpl *replacementTemplate) (*structuralEdit, error) {
+	pgf := matches[0].pgf
+	if pgf.ParseErr != nil {
+		return nil, fmt.Errorf("cannot replace in %s, which has syntax errors: %v", pgf.URI.Path(), pgf.ParseErr)
+	}
+	fh, err := snapshot.ReadFile(ctx, pgf.URI)
+	if err != nil {
+		return nil, err
+	}
+	edit := &structuralEdit{fh: fh, content: pgf.Src}
+
+	var (
+		buf  bytes.Buffer
+		pos  int
+		last token.Pos // end of the last replaced match
+	)
+	for _, m := range matches {
+		if m.start < last {
+			edit.skipped++
+			continue
+		}
+		start, end, err := safetoken.Offsets(pgf.Tok, m.start, m.end)
+		if err != nil {
+			return nil, err
+		}
+		buf.Write(pgf.Src[pos:start])
+		buf.WriteString(tmpl.expand(m))
+		pos, last = end, m.end
+		edit.replacements++
+	}
+	buf.Write(pgf.Src[pos:])
+
+	edit.newContent, err = fixImports(ctx, snapshot, pgf.URI.Path(), pgf.File.Name.Name, buf.Bytes())
+	if err != nil {
+		return nil, fmt.Errorf("invalid code after replacement in %s: %v", pgf.URI.Path(), err)
+	}
+	return edit, nil
+}
+
+// fixImports adds the missing imports of the content of a file and removes
+// the unused ones, and formats it, like goimports.
+// Adapted from computeImportEdits in gopls/internal/golang/format.go
+func fixImports(ctx context.Context, snapshot *cache.Snapshot, filename, pkgName string, src []byte) ([]byte, error) {
+	var fixed []byte
+	err := snapshot.RunProcessEnvFunc(ctx, func(ctx context.Context, opts *imports.Options) error {
+		isource, err := imports.NewProcessEnvSource(opts.Env, filename, pkgName)
+		if err != nil {
+			return err
+		}
+		var source imports.Source
+		switch snapshot.Options().ImportsSource {
+		case settings.ImportsSourceGopls:
+			source = snapshot.NewGoplsSource(isource)
+		case settings.ImportsSourceOff:
+			source = nil
+		case settings.ImportsSourceGoimports:
+			source = isource
+		}
+		fixes, err := imports.FixImports(ctx, filename, src, snapshot.View().Folder().Env.GOROOT, opts.Env.Logf, source)
+		if err != nil {
+			return err
+		}
+		fixed, err = imports.ApplyFixes(fixes, filename, src, opts, 0)
+		return err
+	})
+	return fixed, err
+}
+
+// applyStructuralEdits writes the new contents of the files, all or none,
+// and returns the paths of the files left changed on disk. Documents
+// synchronized with go_sync_document are changed in the session, as an
+// editor would; other files are written to disk, provided that they have no
+// overlay (the unsaved buffer of an editor sharing the session) and did not
+// change since they were read. The session is notified of every file
+// written, even if the edits are rolled back.
+func (h *Handler) applyStructuralEdits(ctx context.Context, snapshot *cache.Snapshot, edits []*structuralEdit) ([]string, error) {
+	overlays := make(map[protocol.DocumentURI]bool)
+	for _, o := range snapshot.Overlays() {
+		overlays[o.URI()] = true
+	}
+
+	// Hold the lock until the synchronized documents are recorded, so that
+	// concurrent syncs of them see the new versions.
+	h.openDocumentsMu.Lock()
+	defer h.openDocumentsMu.Unlock()
+
+	// Check all the files, and stage their new contents next to them, before
+	// changing any.
+	var (
+		synced []file.Modification
+		staged []*stagedFile
+	)
+	defer func() {
+		for _, f := range staged {
+			if f.temp != "" {
+				os.Remove(f.temp)
+			}
+		}
+	}()
+	for _, edit := range edits {
+		uri := edit.fh.URI()
+		if _, isOpen := h.openDocuments[uri]; isOpen {
+			mod, err := h.documentModificationLocked(uri, "change", string(edit.newContent), 0)
+			if err != nil {
+				return nil, err
+			}
+			synced = append(synced, mod)
+			continue
+		}
+		if overlays[uri] {
+			return nil, fmt.Errorf("%s has unsaved changes in an editor: save it, or preview the changes without apply", uri.Path())
+		}
+		f, err := stageFile(uri.Path(), edit.content, edit.newContent)
+		if err != nil {
+			return nil, err
+		}
+		staged = append(staged, f)
+	}
+
+	// Replace the files, or restore those replaced if one fails.
+	var (
+		written []string
+		mods    []file.Modification
+		err     error
+	)
+	for _, f := range staged {
+		if err = os.Rename(f.temp, f.path); err != nil {
+			err = fmt.Errorf("failed to write %s: %v", f.path, err)
+			break
+		}
+		f.temp = ""
+		written = append(written, f.path)
+		mods = append(mods, file.Modification{URI: protocol.URIFromPath(f.path), Action: file.Change, OnDisk: true})
+	}
+	if err != nil {
+		var kept []string
+		for _, f := range staged[:len(written)] {
+			if err := os.WriteFile(f.path, f.content, f.perm); err != nil {
+				kept = append(kept, f.path)
+			}
+		}
+		written = kept
+	} else {
+		mods = append(mods, synced...)
+	}
+	if _, modErr := h.session.DidModifyFiles(ctx, mods); modErr != nil && err == nil {
+		err = fmt.Errorf("failed to update the session: %v", modErr)
+	}
+	if err != nil {
+		return written, err
+	}
+
+	for _, mod := range synced {
+		h.openDocuments[mod.URI] = mod.Version
+	}
+	return written, nil
+}
+
+// stagedFile is the new content of a file on disk, written to a temporary
+// file of the same directory until it replaces the file.
+type stagedFile struct {
+	path, temp string
+	content    []byte // the current content of the file
+	perm       fs.FileMode
+}
+
+// stageFile writes the new content of the file at path to a temporary file,
+// provided that the file's content is still content.
+func stageFile(path string, content, newContent []byte) (*stagedFile, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to write %s: %v", path, err)
+	}
+	current, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to write %s: %v", path, err)
+	}
+	if !bytes.Equal(current, content) {
+		return nil, fmt.Errorf("%s changed on disk since it was read: run the replacement again", path)
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(path), "."+filepath.Base(path)+".*")
+	if err != nil {
+		return nil, fmt.Errorf("failed to write %s: %v", path, err)
+	}
+	f := &stagedFile{path: path, temp: tmp.Name(), content: content, perm: info.Mode().Perm()}
+	_, err = tmp.Write(newContent)
+	if closeErr := tmp.Close(); err == nil {
+		err = closeErr
+	}
+	if err == nil {
+		err = os.Chmod(f.temp, f.perm)
+	}
+	if err != nil {
+		os.Remove(f.temp)
+		return nil, fmt.Errorf("failed to write %s: %v", path, err)
+	}
+	return f, nil
+}
@@ -0,0 +1,101 @@
+package core
+
+import (
+	"go/ast"
+	"go/parser"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestStageFile(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "a.go")
+	if err := os.WriteFile(path, []byte("old"), 0640); err != nil {
+		t.Fatal(err)
+	}
+
+	t.Run("Staged", func(t *testing.T) {
+		f, err := stageFile(path, []byte("old"), []byte("new"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer os.Remove(f.temp)
+		if data, _ := os.ReadFile(f.temp); string(data) != "new" {
+			t.Errorf("Expected the staged content, got %q", data)
+		}
+		if info, err := os.Stat(f.temp); err != nil || info.Mode().Perm() != 0640 {
+			t.Errorf("Expected the staged file to have the file's permissions, got %v (%v)", info.Mode().Perm(), err)
+		}
+		if data, _ := os.ReadFile(path); string(data) != "old" {
+			t.Errorf("Expected the file to be unchanged until renamed, got %q", data)
+		}
+	})
+
+	t.Run("ChangedOnDisk", func(t *testing.T) {
+		_, err := stageFile(path, []byte("read before"), []byte("new"))
+		if err == nil || !strings.Contains(err.Error(), "changed on disk") {
+			t.Errorf("Expected a changed on disk error, got %v", err)
+		}
+		entries, _ := os.ReadDir(dir)
+		if len(entries) != 1 {
+			t.Errorf("Expected no staged file to be left, got %v", entries)
+		}
+	})
+}
+
+func TestNeedsParens(t *testing.T) {
+	for _, test := range []struct {
+		parent string // an expression in which X is replaced
+		expr   string // the replacement of X
+		want   bool
+	}{
+		{"X * 2", "a + b", true},
+		{"2 * X", "a + b", true},
+		{"X + 2", "a * b", false},
+		{"2 - X", "a - b", true},
+		{"X - 2", "a - b", false},
+		{"X + 2", "*p", false},
+		{"X + 2", "a", false},
+		{"-X", "a + b", true},
+		{"!X", "!a", false},
+		{"*X", "a + b", true},
+		{"X.f", "*p", true},
+		{"X.f", "a + b", true},
+		{"X(1)", "*f", true},
+		{"f(X)", "a + b", false},
+		{"X[i]", "*p", true},
+		{"a[X]", "a + b", false},
+		{"X[int, string]", "*p", true},
+		{"X[1:2]", "-s", true},
+		{"X.(T)", "*p", true},
+		{"f(X.(T))", "a", false},
+	} {
+		parent, err := parser.ParseExpr(test.parent)
+		if err != nil {
+			t.Fatal(err)
+		}
+		expr, err := parser.ParseExpr(test.expr)
+		if err != nil {
+			t.Fatal(err)
+		}
+		// Find X and its parent.
+		var stack []ast.Node
+		var child, childParent ast.Node
+		ast.Inspect(parent, func(n ast.Node) bool {
+			if n == nil {
+				stack = stack[:len(stack)-1]
+				return false
+			}
+			if id, ok := n.(*ast.Ident); ok && id.Name == "X" {
+				child, childParent = id, stack[len(stack)-1]
+			}
+			stack = append(stack, n)
+			return true
+		})
+		if got := needsParens(childParent, child, expr); got != test.want {
+			t.Errorf("needsParens(%s, X, %s) = %v, want %v", test.parent, test.expr, got, test.want)
+		}
+	}
+}
@@ -0,0 +1,633 @@
+package core
+
+import (
+	"cmp"
+	"context"
+	"fmt"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"go/types"
+	"maps"
+	"reflect"
+	"regexp"
+	"slices"
+	"strings"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+	"golang.org/x/tools/gopls/internal/cache"
+	"golang.org/x/tools/gopls/internal/cache/metadata"
+	"golang.org/x/tools/gopls/internal/cache/parsego"
+	"golang.org/x/tools/gopls/internal/protocol"
+	"golang.org/x/tools/gopls/internal/util/safetoken"
+	"golang.org/x/tools/gopls/mcpbridge/api"
+)
+
+// ===== go_structural_search =====
+// Search for code by AST pattern, in the style of gogrep: a pattern is Go
+// syntax in which metavariables stand for nodes.
+//
+//   - $x matches any node and binds it to x; further occurrences of $x
+//     must match an identical node.
+//   - $*x matches any number of consecutive elements of a list (call
+//     arguments, statements, composite literal elements, ...).
+//   - $_ and $*_ match likewise, without binding.
+//
+// The pattern is parsed as an expression, or else as a statement list.
+// Constraints on the metavariables are checked against the type
+// information of the packages, which are type-checked by the snapshot.
+
+// Metavariables are rewritten to identifiers before parsing.
+const (
+	metaVarPrefix     = "__mv_"  // $x
+	metaListVarPrefix = "__mvs_" // $*x
+)
+
+// metaVarRx matches the metavariables of a pattern.
+var metaVarRx = regexp.MustCompile(`\$(\*?)([A-Za-z_][A-Za-z0-9_]*)`)
+
+// maxMatchTextLength is the length beyond which the source of a match is
+// truncated in the results.
+const maxMatchTextLength = 200
+
+func handleGoStructuralSearch(ctx context.Context, h *Handler, req *mcp.CallToolRequest, input api.IStructuralSearchParams) (*mcp.CallToolResult, *api.OStructuralSearchResult, error) {
+	pattern, err := parseStructuralPattern(input.Pattern, input.Constraints)
+	if err != nil {
+		return nil, nil, err
+	}
+	maxResults := input.MaxResults
+	if maxResults <= 0 {
+		maxResults = 50
+	}
+
+	snapshot, release, err := h.snapshotForDir(input.Cwd)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer release()
+
+	filter, err := newSearchFilter(api.ISearchParams{Package: input.Package}, snapshot.View().Root().Path())
+	if err != nil {
+		return nil, nil, err
+	}
+	matches, err := structuralSearch(ctx, snapshot, pattern, filter)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to search: %v", err)
+	}
+
+	result := &api.OStructuralSearchResult{Total: len(matches)}
+	for _, m := range matches[:min(len(matches), maxResults)] {
+		result.Matches = append(result.Matches, m.toAPI())
+	}
+
+	var summary strings.Builder
+	if len(matches) == 0 {
+		fmt.Fprintf(&summary, "No matches found for %s.", input.Pattern)
+	} else {
+		fmt.Fprintf(&summary, "Found %d match(es) for %s:\n", len(matches), input.Pattern)
+		for _, m := range result.Matches {
+			fmt.Fprintf(&summary, "  - %s:%d:%d", m.File, m.Line, m.Column)
+			if m.EnclosingFunction != "" {
+				fmt.Fprintf(&summary, " in %s", m.EnclosingFunction)
+			}
+			fmt.Fprintf(&summary, "\n      %s\n", strings.ReplaceAll(m.Text, "\n", "\n      "))
+			if len(m.Bindings) > 0 {
+				var bindings []string
+				for _, name := range slices.Sorted(maps.Keys(m.Bindings)) {
+					bindings = append(bindings, fmt.Sprintf("$%s = %s", name, m.Bindings[name]))
+				}
+				fmt.Fprintf(&summary, "      %s\n", strings.Join(bindings, ", "))
+			}
+		}
+		if len(matches) > len(result.Matches) {
+			fmt.Fprintf(&summary, "... and %d more (use max_results for more)\n", len(matches)-len(result.Matches))
+		}
+	}
+	result.Summary = summary.String()
+	return &mcp.CallToolResult{Content: []mcp.Content{&mcp.TextContent{Text: result.Summary}}}, result, nil
+}
+
+// structuralPattern is a parsed structural search pattern.
+type structuralPattern struct {
+	*metaSyntax
+	constraints map[string]*metaConstraint
+}
+
+// metaSyntax is Go syntax with metavariables: a pattern, or a replacement
+// template.
+type metaSyntax struct {
+	fset  *token.FileSet
+	src   string     // the parsed source, in which metavariables are identifiers
+	nodes []ast.Node // an expression, or a list of statements
+	names map[string]bool
+}
+
+// parseMetaSyntax parses Go syntax with metavariables, as an expression or
+// else as a statement list.
+func parseMetaSyntax(text string) (*metaSyntax, error) {
+	syntax := &metaSyntax{fset: token.NewFileSet(), names: make(map[string]bool)}
+	src := metaVarRx.ReplaceAllStringFunc(text, func(s string) string {
+		m := metaVarRx.FindStringSubmatch(s)
+		syntax.names[m[2]] = true
+		if m[1] == "*" {
+			return metaListVarPrefix + m[2]
+		}
+		return metaVarPrefix + m[2]
+	})
+
+	if expr, err := parser.ParseExprFrom(syntax.fset, "", src, parser.SkipObjectResolution); err == nil {
+		syntax.src, syntax.nodes = src, []ast.Node{expr}
+		return syntax, nil
+	}
+	syntax.src = "package p; func _() {\n" + src + "\n}"
+	f, err := parser.ParseFile(syntax.fset, "", syntax.src, parser.SkipObjectResolution)
+	if err != nil {
+		return nil, fmt.Errorf("not a Go expression or statement list: %v", err)
+	}
+	body := f.Decls[0].(*ast.FuncDecl).Body.List
+	if len(body) == 0 {
+		return nil, fmt.Errorf("empty statement list")
+	}
+	for _, stmt := range body {
+		syntax.nodes = append(syntax.nodes, stmt)
+	}
+	if len(body) == 1 {
+		if expr, ok := body[0].(*ast.ExprStmt); ok {
+			syntax.nodes = []ast.Node{expr.X}
+		}
+	}
+	return syntax, nil
+}
+
+// isExpr reports whether the syntax is an expression.
+func (syntax *metaSyntax) isExpr() bool {
+	_, ok := syntax.nodes[0].(ast.Expr)
+	return ok
+}
+
+// parseStructuralPattern parses a pattern and the constraints on its
+// metavariables.
+func parseStructuralPattern(text string, constraints map[string]string) (*structuralPattern, error) {
+	if strings.TrimSpace(text) == "" {
+		return nil, fmt.Errorf("pattern is required, e.g. $db.Query($q, $*_)")
+	}
+	syntax, err := parseMetaSyntax(text)
+	if err != nil {
+		return nil, fmt.Errorf("invalid pattern %q: %v", text, err)
+	}
+	pattern := &structuralPattern{metaSyntax: syntax, constraints: make(map[string]*metaConstraint)}
+	for name, constraint := range constraints {
+		name = strings.TrimPrefix(name, "$")
+		if !pattern.names[name] || name == "_" {
+			return nil, fmt.Errorf("invalid constraint on $%s: no such metavariable in the pattern", name)
+		}
+		c, err := parseMetaConstraint(constraint)
+		if err != nil {
+			return nil, fmt.Errorf("invalid constraint on $%s: %v", name, err)
+		}
+		pattern.constraints[name] = c
+	}
+	return pattern, nil
+}
+
+// metaConstraint is a constraint on the expression bound to a
+// metavariable.
+type metaConstraint struct {
+	negate bool
+	kind   string // "const", "implements" or "type"
+	typ    string // the type of "implements" and "type" constraints
+}
+
+// parseMetaConstraint parses a constraint: "const", "implements:T" or a
+// type, negated by a leading "!".
+func parseMetaConstraint(s string) (*metaConstraint, error) {
+	c := &metaConstraint{}
+	s = strings.TrimSpace(s)
+	if rest, ok := strings.CutPrefix(s, "!"); ok {
+		c.negate, s = true, strings.TrimSpace(rest)
+	}
+	switch {
+	case s == "const":
+		c.kind = "const"
+	case strings.HasPrefix(s, "implements:"):
+		c.kind, c.typ = "implements", strings.TrimSpace(strings.TrimPrefix(s, "implements:"))
+	default:
+		c.kind, c.typ = "type", strings.ReplaceAll(s, " ", "")
+	}
+	if c.kind != "const" && c.typ == "" {
+		return nil, fmt.Errorf("expected a type, implements:T or const")
+	}
+	return c, nil
+}
+
+// check reports whether the node satisfies the constraint, given the type
+// information of its package.
+func (c *metaConstraint) check(node ast.Node, pkg *cache.Package) bool {
+	expr, ok := node.(ast.Expr)
+	if !ok {
+		return false
+	}
+	tv, ok := pkg.TypesInfo().Types[expr]
+	if !ok || tv.Type == nil {
+		return false
+	}
+	var sat bool
+	switch c.kind {
+	case "const":
+		sat = tv.Value != nil
+	case "type":
+		sat = types.TypeString(tv.Type, nil) == c.typ ||
+			types.TypeString(tv.Type, func(p *types.Package) string { return p.Name() }) == c.typ
+	case "implements":
+		iface := lookupInterface(pkg.Types(), c.typ)
+		sat = iface != nil && types.AssignableTo(tv.Type, iface)
+	}
+	return sat != c.negate
+}
+
+// lookupInterface returns the interface type named name ("io.Reader" or
+// "database/sql.Scanner", or an unqualified name of pkg or the universe)
+// among pkg and the packages it transitively imports, or nil.
+func lookupInterface(pkg *types.Package, name string) types.Type {
+	var obj types.Object
+	if i := strings.LastIndex(name, "."); i >= 0 {
+		qual, sel := name[:i], name[i+1:]
+		for _, p := range importClosure(pkg) {
+			if p.Path() == qual || p.Name() == qual {
+				if obj = p.Scope().Lookup(sel); obj != nil {
+					break
+				}
+			}
+		}
+	} else if obj = pkg.Scope().Lookup(name); obj == nil {
+		obj = types.Universe.Lookup(name)
+	}
+	if tn, ok := obj.(*types.TypeName); ok && types.IsInterface(tn.Type()) {
+		return tn.Type()
+	}
+	return nil
+}
+
+// structuralMatch is a match of a pattern.
+type structuralMatch struct {
+	pkg        *cache.Package
+	pgf        *parsego.File
+	start, end token.Pos
+	node       ast.Node // the matched node, or nil for a statement list
+	parent     ast.Node // the parent of the matched node or statements
+	bindings   map[string]metaBinding
+	enclosing  string // enclosing function, if any
+}
+
+// metaBinding is the node, or the list of nodes, bound to a metavariable.
+type metaBinding struct {
+	node   ast.Node
+	list   []ast.Node
+	isList bool
+}
+
+// text returns the source of the binding.
+func (b metaBinding) text(pgf *parsego.File) string {
+	if !b.isList {
+		return nodeSource(pgf, b.node.Pos(), b.node.End())
+	}
+	if len(b.list) == 0 {
+		return ""
+	}
+	return nodeSource(pgf, b.list[0].Pos(), b.list[len(b.list)-1].End())
+}
+
+// nodeSource returns the source of the range of pgf.
+func nodeSource(pgf *parsego.File, start, end token.Pos) string {
+	text, err := pgf.PosText(start, end)
+	if err != nil {
+		return ""
+	}
+	return string(text)
+}
+
+// toAPI converts the match to its API form.
+func (m *structuralMatch) toAPI() api.StructuralMatch {
+	start := safetoken.StartPosition(m.pkg.FileSet(), m.start)
+	end := safetoken.EndPosition(m.pkg.FileSet(), m.end)
+	text := nodeSource(m.pgf, m.start, m.end)
+	if len(text) > maxMatchTextLength {
+		text = text[:maxMatchTextLength] + "..."
+	}
+	result := api.StructuralMatch{
+		File:              m.pgf.URI.Path(),
+		Line:              start.Line,
+		Column:            start.Column,
+		EndLine:           end.Line,
+		EndColumn:         end.Column,
+		Text:              text,
+		EnclosingFunction: m.enclosing,
+	}
+	for name, b := range m.bindings {
+		if result.Bindings == nil {
+			result.Bindings = make(map[string]string)
+		}
+		result.Bindings[name] = b.text(m.pgf)
+	}
+	return result
+}
+
+// structuralSearch returns the matches of the pattern in the files of the
+// workspace packages selected by the filter, by file and position, outer
+// matches first. Each
+// file is searched once, with the type information of the first package
+// that contains it.
+func structuralSearch(ctx context.Context, snapshot *cache.Snapshot, pattern *structuralPattern, filter *searchFilter) ([]*structuralMatch, error) {
+	mps, err := snapshot.WorkspaceMetadata(ctx)
+	if err != nil {
+		return nil, err
+	}
+	// Non-test variants first, so that test variants only contribute their
+	// test files.
+	slices.SortStableFunc(mps, func(a, b *metadata.Package) int {
+		return cmp.Compare(len(a.ForTest), len(b.ForTest))
+	})
+	var ids []metadata.PackageID
+	for _, mp := range mps {
+		if !mp.IsIntermediateTestVariant() && !metadata.IsCommandLineArguments(mp.ID) {
+			ids = append(ids, mp.ID)
+		}
+	}
+	pkgs, err := snapshot.TypeCheck(ctx, ids...)
+	if err != nil {
+		return nil, err
+	}
+
+	var (
+		matches []*structuralMatch
+		seen    = make(map[protocol.DocumentURI]bool)
+	)
+	for _, pkg := range pkgs {
+		if ctx.Err() != nil {
+			return nil, ctx.Err()
+		}
+		mp := pkg.Metadata()
+		goFiles := make(map[protocol.DocumentURI]bool)
+		for _, uri := range mp.GoFiles {
+			goFiles[uri] = true
+		}
+		pkgPath := symbolPackage{path: string(mp.PkgPath), forTest: string(mp.ForTest)}
+		for _, pgf := range pkg.CompiledGoFiles() {
+			// Skip generated cgo files, and files already searched.
+			if !goFiles[pgf.URI] || seen[pgf.URI] {
+				continue
+			}
+			seen[pgf.URI] = true
+			if !filter.matchesFile(pgf.URI.Path(), pkgPath) {
+				continue
+			}
+			matches = append(matches, matchFile(pattern, pkg, pgf)...)
+		}
+	}
+	slices.SortFunc(matches, func(a, b *structuralMatch) int {
+		return cmp.Or(cmp.Compare(a.pgf.URI, b.pgf.URI), cmp.Compare(a.start, b.start), cmp.Compare(b.end, a.end))
+	})
+	return matches, nil
+}
+
+// matchFile returns the matches of the pattern in a file.
+func matchFile(pattern *structuralPattern, pkg *cache.Package, pgf *parsego.File) []*structuralMatch {
+	var (
+		matches   []*structuralMatch
+		enclosing string
+		funcEnd   token.Pos
+	)
+	var stack []ast.Node // ancestors of the current node
+	record := func(m *matcher, node ast.Node, start, end token.Pos) {
+		matches = append(matches, &structuralMatch{
+			pkg:       pkg,
+			pgf:       pgf,
+			start:     start,
+			end:       end,
+			node:      node,
+			parent:    stack[len(stack)-1],
+			bindings:  m.bindings,
+			enclosing: enclosing,
+		})
+	}
+	ast.Inspect(pgf.File, func(n ast.Node) bool {
+		if n == nil {
+			stack = stack[:len(stack)-1]
+			return false
+		}
+		if decl, ok := n.(*ast.FuncDecl); ok {
+			enclosing, funcEnd = funcDeclName(decl), decl.End()
+		} else if n.Pos() >= funcEnd {
+			enclosing = ""
+		}
+
+		if len(pattern.nodes) == 1 {
+			m := newMatcher(pattern, pkg)
+			if len(stack) > 0 && m.node(pattern.nodes[0], n) {
+				record(m, n, n.Pos(), n.End())
+			}
+			stack = append(stack, n)
+			return true
+		}
+		// A statement list matches consecutive statements of a block.
+		stack = append(stack, n)
+		list := stmtList(n)
+		for i := 0; i < len(list); {
+			matched := false
+			for j := i + 1; j <= len(list); j++ {
+				m := newMatcher(pattern, pkg)
+				if m.list(pattern.nodes, stmtNodes(list[i:j])) {
+					record(m, nil, list[i].Pos(), list[j-1].End())
+					i, matched = j, true
+					break
+				}
+			}
+			if !matched {
+				i++
+			}
+		}
+		return true
+	})
+	return matches
+}
+
+// funcDeclName returns the name of a function, or of a method qualified by
+// its receiver type: "(*Server).Start", "Server.Start".
+func funcDeclName(decl *ast.FuncDecl) string {
+	if decl.Recv == nil || len(decl.Recv.List) == 0 {
+		return decl.Name.Name
+	}
+	recv := receiverTypeName(decl.Recv.List[0].Type)
+	if strings.HasPrefix(recv, "*") {
+		return "(" + recv + ")." + decl.Name.Name
+	}
+	return recv + "." + decl.Name.Name
+}
+
+// stmtList returns the statements of a block, case or select clause.
+func stmtList(n ast.Node) []ast.Stmt {
+	switch n := n.(type) {
+	case *ast.BlockStmt:
+		return n.List
+	case *ast.CaseClause:
+		return n.Body
+	case *ast.CommClause:
+		return n.Body
+	}
+	return nil
+}
+
+func stmtNodes(stmts []ast.Stmt) []ast.Node {
+	nodes := make([]ast.Node, len(stmts))
+	for i, stmt := range stmts {
+		nodes[i] = stmt
+	}
+	return nodes
+}
+
+// matcher matches pattern nodes against syntax nodes, recording the
+// bindings of the metavariables.
+type matcher struct {
+	pattern  *structuralPattern
+	pkg      *cache.Package // nil when comparing bound nodes
+	bindings map[string]metaBinding
+}
+
+func newMatcher(pattern *structuralPattern, pkg *cache.Package) *matcher {
+	return &matcher{pattern: pattern, pkg: pkg, bindings: make(map[string]metaBinding)}
+}
+
+// metaVar returns the name of the metavariable that the pattern node
+// stands for, if any. A metavariable used as a statement stands for any
+// statement.
+func metaVar(p ast.Node, prefix string) (string, bool) {
+	switch p := p.(type) {
+	case *ast.Ident:
+		return strings.CutPrefix(p.Name, prefix)
+	case *ast.ExprStmt:
+		return metaVar(p.X, prefix)
+	case *ast.Field:
+		if len(p.Names) == 0 {
+			return metaVar(p.Type, prefix)
+		}
+	}
+	return "", false
+}
+
+var nodeType = reflect.TypeFor[ast.Node]()
+
+// node reports whether the pattern node p matches n.
+func (m *matcher) node(p, n ast.Node) bool {
+	if isNilNode(p) {
+		return isNilNode(n)
+	}
+	if isNilNode(n) {
+		return false
+	}
+	if name, ok := metaVar(p, metaVarPrefix); ok && m.pattern != nil {
+		if _, isStmt := p.(*ast.ExprStmt); isStmt {
+			if _, ok := n.(ast.Stmt); !ok {
+				return false
+			}
+		}
+		return m.bind(name, metaBinding{node: n})
+	}
+
+	pv, nv := reflect.ValueOf(p).Elem(), reflect.ValueOf(n).Elem()
+	if pv.Type() != nv.Type() {
+		return false
+	}
+	for i := range pv.NumField() {
+		if !m.field(pv.Field(i), nv.Field(i)) {
+			return false
+		}
+	}
+	return true
+}
+
+// field reports whether the field of a pattern node matches that of a
+// syntax node. Positions, comments and resolved objects are ignored.
+func (m *matcher) field(p, n reflect.Value) bool {
+	switch p.Type() {
+	case reflect.TypeFor[token.Pos](), reflect.TypeFor[*ast.Object](), reflect.TypeFor[*ast.Scope](), reflect.TypeFor[*ast.CommentGroup]():
+		return true
+	}
+	switch {
+	case p.Kind() == reflect.Slice:
+		ps, ns := make([]ast.Node, p.Len()), make([]ast.Node, n.Len())
+		for i := range ps {
+			ps[i], _ = p.Index(i).Interface().(ast.Node)
+		}
+		for i := range ns {
+			ns[i], _ = n.Index(i).Interface().(ast.Node)
+		}
+		return m.list(ps, ns)
+	case p.Type().Implements(nodeType) || p.Type() == nodeType || p.Kind() == reflect.Interface:
+		pn, _ := p.Interface().(ast.Node)
+		nn, _ := n.Interface().(ast.Node)
+		return m.node(pn, nn)
+	case p.Kind() == reflect.Bool:
+		return true // Incomplete flags
+	}
+	return p.Interface() == n.Interface()
+}
+
+// list reports whether the pattern nodes match the list of syntax nodes,
+// where list metavariables match any number of consecutive nodes.
+func (m *matcher) list(ps, ns []ast.Node) bool {
+	if len(ps) == 0 {
+		return len(ns) == 0
+	}
+	if name, ok := metaVar(ps[0], metaListVarPrefix); ok && m.pattern != nil {
+		for i := 0; i <= len(ns); i++ {
+			saved := maps.Clone(m.bindings)
+			if m.bind(name, metaBinding{list: ns[:i], isList: true}) && m.list(ps[1:], ns[i:]) {
+				return true
+			}
+			m.bindings = saved
+		}
+		return false
+	}
+	if len(ns) == 0 {
+		return false
+	}
+	saved := maps.Clone(m.bindings)
+	if m.node(ps[0], ns[0]) && m.list(ps[1:], ns[1:]) {
+		return true
+	}
+	m.bindings = saved
+	return false
+}
+
+// bind binds a metavariable, or checks that the node is identical to the
+// one already bound, and checks the constraint of the metavariable.
+func (m *matcher) bind(name string, b metaBinding) bool {
+	if name == "_" {
+		return true
+	}
+	if prev, ok := m.bindings[name]; ok {
+		return prev.isList == b.isList && identicalNodes(prev, b)
+	}
+	if c := m.pattern.constraints[name]; c != nil && (b.isList || !c.check(b.node, m.pkg)) {
+		return false
+	}
+	m.bindings[name] = b
+	return true
+}
+
+// identicalNodes reports whether two bindings are syntactically identical.
+func identicalNodes(a, b metaBinding) bool {
+	cmp := &matcher{} // no pattern: metavariables are plain identifiers
+	if !a.isList {
+		return cmp.node(a.node, b.node)
+	}
+	return cmp.list(a.list, b.list)
+}
+
+func isNilNode(n ast.Node) bool {
+	if n == nil {
+		return true
+	}
+	v := reflect.ValueOf(n)
+	return v.Kind() == reflect.Pointer && v.IsNil()
+}
@@ -0,0 +1,136 @@
+package core
+
+import (
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"go/types"
+	"maps"
+	"strings"
+	"testing"
+)
+
+func TestStructuralMatcher(t *testing.T) {
+	for _, test := range []struct {
+		pattern string
+		src     string            // an expression, or a list of statements
+		want    map[string]string // the bindings if the pattern matches, or nil
+	}{
+		{"$x + 1", "a + 1", map[string]string{"x": "a"}},
+		{"$x + 1", "a + 2", nil},
+		{"$x + $x", "f(a) + f(a)", map[string]string{"x": "f(a)"}},
+		{"$x + $x", "a + b", nil},
+		{"$_ + $_", "a + b", map[string]string{}},
+		{"$x.y", "a.b.y", map[string]string{"x": "a.b"}},
+		{"$db.Query($q, $*_)", "db.Query(sql, 1, 2)", map[string]string{"db": "db", "q": "sql"}},
+		{"$db.Query($q, $*_)", "db.Query()", nil},
+		{"f($*args)", "f()", map[string]string{"args": ""}},
+		{"f($*a, 0, $*b)", "f(1, 0, 2, 0)", map[string]string{"a": "1", "b": "2, 0"}},
+		{"[]$t{}", "[]string{}", map[string]string{"t": "string"}},
+		{"func($*_) error", "func(a, b int) error", map[string]string{}},
+		{"x = 1", "x := 1", nil},
+		{"if $err != nil { return $err }", "if e != nil { return e }", map[string]string{"err": "e"}},
+		{"if $err != nil { return $err }", "if e != nil { return nil }", nil},
+		{"$x := $y; return $x", "v := f(); return v", map[string]string{"x": "v", "y": "f()"}},
+		{"for $k, $v := range $m { $*_ }", "for i, x := range xs { use(x) }", map[string]string{"k": "i", "v": "x", "m": "xs"}},
+		{"for { $s }", "for { i++ }", map[string]string{"s": "i++"}},
+	} {
+		pattern, err := parseStructuralPattern(test.pattern, nil)
+		if err != nil {
+			t.Fatalf("parseStructuralPattern(%q) failed: %v", test.pattern, err)
+		}
+		nodes := parseTestNodes(t, test.src)
+		m := newMatcher(pattern, nil)
+		var matched bool
+		if len(pattern.nodes) == 1 && len(nodes) == 1 {
+			matched = m.node(pattern.nodes[0], nodes[0])
+		} else {
+			matched = m.list(pattern.nodes, nodes)
+		}
+		if !matched {
+			if test.want != nil {
+				t.Errorf("%s does not match %s, want %v", test.pattern, test.src, test.want)
+			}
+			continue
+		}
+		if test.want == nil {
+			t.Errorf("%s matches %s", test.pattern, test.src)
+			continue
+		}
+		got := make(map[string]string)
+		for name, b := range m.bindings {
+			got[name] = bindingString(b)
+		}
+		if !maps.Equal(got, test.want) {
+			t.Errorf("%s matches %s with %v, want %v", test.pattern, test.src, got, test.want)
+		}
+	}
+}
+
+// parseTestNodes parses an expression, or else a list of statements.
+func parseTestNodes(t *testing.T, src string) []ast.Node {
+	if expr, err := parser.ParseExpr(src); err == nil {
+		return []ast.Node{expr}
+	}
+	f, err := parser.ParseFile(token.NewFileSet(), "", "package p; func _() {\n"+src+"\n}", 0)
+	if err != nil {
+		t.Fatalf("invalid source %q: %v", src, err)
+	}
+	return stmtNodes(f.Decls[0].(*ast.FuncDecl).Body.List)
+}
+
+// bindingString returns the source of a binding of expressions and simple
+// statements.
+func bindingString(b metaBinding) string {
+	nodes := b.list
+	if !b.isList {
+		nodes = []ast.Node{b.node}
+	}
+	var strs []string
+	for _, n := range nodes {
+		switch n := n.(type) {
+		case ast.Expr:
+			strs = append(strs, types.ExprString(n))
+		case *ast.IncDecStmt:
+			strs = append(strs, types.ExprString(n.X)+n.Tok.String())
+		default:
+			strs = append(strs, "?")
+		}
+	}
+	return strings.Join(strs, ", ")
+}
+
+func TestParseStructuralPattern(t *testing.T) {
+	for _, test := range []struct {
+		pattern     string
+		constraints map[string]string
+		want        *metaConstraint // the constraint of $x, if any
+		err         string          // a substring of the error, if any
+	}{
+		{pattern: "$x + 1", constraints: map[string]string{"x": "int"}, want: &metaConstraint{kind: "type", typ: "int"}},
+		{pattern: "$x + 1", constraints: map[string]string{"$x": "!const"}, want: &metaConstraint{negate: true, kind: "const"}},
+		{pattern: "f($x)", constraints: map[string]string{"x": "implements: io.Reader"}, want: &metaConstraint{kind: "implements", typ: "io.Reader"}},
+		{pattern: "f($x)", constraints: map[string]string{"x": "map[string] int"}, want: &metaConstraint{kind: "type", typ: "map[string]int"}},
+		{pattern: " ", err: "pattern is required"},
+		{pattern: "f(", err: "invalid pattern"},
+		{pattern: "f($x)", constraints: map[string]string{"y": "int"}, err: "no such metavariable"},
+		{pattern: "f($_)", constraints: map[string]string{"_": "int"}, err: "no such metavariable"},
+		{pattern: "f($x)", constraints: map[string]string{"x": "implements:"}, err: "expected a type"},
+		{pattern: "f($x)", constraints: map[string]string{"x": "!"}, err: "expected a type"},
+	} {
+		pattern, err := parseStructuralPattern(test.pattern, test.constraints)
+		if test.err != "" {
+			if err == nil || !strings.Contains(err.Error(), test.err) {
+				t.Errorf("parseStructuralPattern(%q, %v) = %v, want error containing %q", test.pattern, test.constraints, err, test.err)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("parseStructuralPattern(%q, %v) failed: %v", test.pattern, test.constraints, err)
+			continue
+		}
+		if got := pattern.constraints["x"]; *got != *test.want {
+			t.Errorf("parseStructuralPattern(%q, %v) constraint = %+v, want %+v", test.pattern, test.constraints, *got, *test.want)
+		}
+	}
+}
@@ -0,0 +1,550 @@
+package core
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/gob"
+	"errors"
+	"go/parser"
+	"go/token"
+	"io/fs"
+	"log"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"sync"
+	"time"
+
+	"golang.org/x/tools/gopls/internal/cache"
+	"golang.org/x/tools/gopls/internal/file"
+	"golang.org/x/tools/gopls/internal/filecache"
+	"golang.org/x/tools/gopls/internal/protocol"
+	"golang.org/x/tools/gopls/mcpbridge/api"
+)
+
+// Symbol index for go_search: the symbols of every Go file under
+// a view root, built once by walking the tree and then kept up to date
+// incrementally, so that a search does not re-parse the workspace:
+//
+//   - If the root is watched by the file watcher, the files of the events
+//     delivered by the watcher are re-parsed on the next search.
+//   - Otherwise, each search walks the tree, and re-parses the files whose
+//     modification time or size changed.
+//   - Overlays (see go_sync_document) are parsed on demand and take
+//     precedence over the files on disk.
+//
+// The index also holds the searchable text of the symbols, over which
+// go_search_docs maintains an inverted index (see doc_search.go).
+//
+// With search_index.persist, the index is stored in the gopls file cache
+// (internal/filecache) and loaded on the first search after a restart; the
+// loaded index is then validated like an unwatched one.
+
+// symbolIndexKind is the filecache kind of persisted symbol indexes.
+const symbolIndexKind = "gopls-mcp-symbol-index"
+
+// symbolIndexVersion is the version of the persisted symbol index. It must
+// be incremented when the symbols extracted from a file change, so that the
+// indexes persisted by a previous version are discarded.
+const symbolIndexVersion = 3
+
+// symbolIndexSaveDelay is the delay after an update before the index is
+// persisted, so that bursts of changes are saved once.
+const symbolIndexSaveDelay = 2 * time.Second
+
+// mtimeReliableAge is how old a modification time must be to be trusted to
+// change with the file's content, as in gopls' memoizedFS: the mtime
+// precision of some file systems is as coarse as 1s.
+const mtimeReliableAge = 2 * time.Second
+
+// indexedFile is the index entry of a file on disk.
+type indexedFile struct {
+	ModTime   int64 // modification time (Unix nanoseconds) of the indexed content
+	Size      int64 // size of the indexed content
+	IndexedAt int64 // time (Unix nanoseconds) the file was indexed
+	Symbols   []*api.Symbol
+	Texts     []symbolText // the searchable texts of Symbols, by index
+}
+
+// upToDate reports whether the entry is known to index the file with the
+// given stat information.
+func (f *indexedFile) upToDate(info fs.FileInfo) bool {
+	mtime := info.ModTime().UnixNano()
+	return f.ModTime == mtime && f.Size == info.Size() &&
+		time.Duration(f.IndexedAt-mtime) >= mtimeReliableAge
+}
+
+// overlayFile is the index entry of an overlay.
+type overlayFile struct {
+	hash file.Hash
+	file *indexedFile
+}
+
+// symbolIndex is the symbol index of the Go files under a root directory.
+type symbolIndex struct {
+	root    string
+	watched bool // whether the file watcher delivers the events of root
+	persist bool
+
+	// filtered reports whether a path is excluded by the view's
+	// directoryFilters; nil if nothing is filtered.
+	filtered func(path string) bool
+
+	mu        sync.Mutex // guards the fields below
+	files     map[string]*indexedFile
+	scanned   bool // whether files was validated by walking the tree
+	overlays  map[protocol.DocumentURI]*overlayFile
+	saveTimer *time.Timer
+	docs      *docIndex // created by the first go_search_docs search
+
+	changedMu sync.Mutex
+	changed   map[string]bool // paths of the file events since the last update
+}
+
+// symbolIndex returns the symbol index of the snapshot's view root,
+// creating it if needed.
+func (h *Handler) symbolIndex(snapshot *cache.Snapshot) *symbolIndex {
+	view := snapshot.View()
+	root := filepath.Clean(view.Root().Path())
+
+	h.symbolIndexesMu.Lock()
+	defer h.symbolIndexesMu.Unlock()
+	if idx, ok := h.symbolIndexes[root]; ok {
+		return idx
+	}
+	idx := &symbolIndex{
+		root:     root,
+		watched:  h.watcher != nil && isUnder(root, filepath.Clean(h.watcher.Dir())),
+		persist:  h.config.SearchIndex != nil && h.config.SearchIndex.Persist,
+		filtered: func(path string) bool { return view.IsFiltered(protocol.URIFromPath(path)) },
+		files:    make(map[string]*indexedFile),
+		overlays: make(map[protocol.DocumentURI]*overlayFile),
+		changed:  make(map[string]bool),
+	}
+	if idx.persist {
+		idx.load()
+	}
+	h.symbolIndexes[root] = idx
+	return idx
+}
+
+// didChangeFiles records the file events delivered by the watcher in the
+// symbol indexes they concern.
+func (h *Handler) didChangeFiles(events []protocol.FileEvent) {
+	h.symbolIndexesMu.Lock()
+	defer h.symbolIndexesMu.Unlock()
+	for _, idx := range h.symbolIndexes {
+		idx.didChange(events)
+	}
+}
+
+// closeSymbolIndexes saves the persisted symbol indexes with pending
+// changes.
+func (h *Handler) closeSymbolIndexes() {
+	h.symbolIndexesMu.Lock()
+	defer h.symbolIndexesMu.Unlock()
+	for _, idx := range h.symbolIndexes {
+		idx.flush()
+	}
+}
+
+// isUnder reports whether path is dir or is in the tree of dir.
+func isUnder(path, dir string) bool {
+	rel, err := filepath.Rel(dir, path)
+	return err == nil && rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator))
+}
+
+// didChange records the paths of file events under the index root.
+func (idx *symbolIndex) didChange(events []protocol.FileEvent) {
+	idx.changedMu.Lock()
+	defer idx.changedMu.Unlock()
+	for _, e := range events {
+		if path := filepath.Clean(e.URI.Path()); isUnder(path, idx.root) {
+			idx.changed[path] = true
+		}
+	}
+}
+
+// symbols returns the symbols of the Go files under the index root, after
+// bringing the index up to date. The overlays under the root replace the
+// corresponding files on disk.
+func (idx *symbolIndex) symbols(ctx context.Context, overlays []file.Handle) ([]*api.Symbol, error) {
+	idx.mu.Lock()
+	defer idx.mu.Unlock()
+
+	files, err := idx.currentFiles(ctx, overlays)
+	if err != nil {
+		return nil, err
+	}
+	var symbols []*api.Symbol
+	for _, f := range files {
+		symbols = append(symbols, f.Symbols...)
+	}
+	return symbols, nil
+}
+
+// currentFiles brings the index up to date and returns the entries of the
+// Go files under the index root, by path, where the overlays under the root
+// replace the corresponding files on disk. idx.mu must be held.
+func (idx *symbolIndex) currentFiles(ctx context.Context, overlays []file.Handle) (map[string]*indexedFile, error) {
+	if err := idx.update(ctx); err != nil {
+		return nil, err
+	}
+
+	files := make(map[string]*indexedFile, len(idx.files))
+	fset := token.NewFileSet()
+	for _, fh := range overlays {
+		uri := fh.URI()
+		path := filepath.Clean(uri.Path())
+		if filepath.Ext(path) != ".go" || !isUnder(path, idx.root) {
+			continue
+		}
+		entry := idx.overlays[uri]
+		if entry == nil || entry.hash != fh.Identity().Hash {
+			content, err := fh.Content()
+			if err != nil {
+				continue
+			}
+			f := &indexedFile{IndexedAt: time.Now().UnixNano()}
+			f.Symbols, f.Texts = parseFileSymbols(fset, path, content)
+			entry = &overlayFile{hash: fh.Identity().Hash, file: f}
+			idx.overlays[uri] = entry
+		}
+		files[path] = entry.file
+	}
+	// Forget the overlays that were closed.
+	for uri := range idx.overlays {
+		if _, ok := files[filepath.Clean(uri.Path())]; !ok {
+			delete(idx.overlays, uri)
+		}
+	}
+
+	for path, f := range idx.files {
+		if _, ok := files[path]; !ok {
+			files[path] = f
+		}
+	}
+	return files, nil
+}
+
+// update brings the index up to date: by walking the tree if the index was
+// not validated yet or is not watched, and otherwise by re-indexing the
+// files changed since the last update. If it fails or ctx is cancelled, the
+// paths it did not process are recorded as changed again, and a tree that
+// was not fully indexed is walked again by the next update.
+func (idx *symbolIndex) update(ctx context.Context) error {
+	idx.changedMu.Lock()
+	changed := idx.changed
+	idx.changed = make(map[string]bool)
+	idx.changedMu.Unlock()
+
+	var (
+		stale   []string
+		removed int
+		walked  = !idx.scanned || !idx.watched
+	)
+	if walked {
+		seen := make(map[string]bool)
+		err := walkGoFiles(idx.root, idx.filtered, func(path string, info fs.FileInfo) {
+			seen[path] = true
+			if f, ok := idx.files[path]; !ok || !f.upToDate(info) {
+				stale = append(stale, path)
+			}
+		})
+		if err != nil {
+			idx.requeue(changed)
+			return err
+		}
+		for path := range idx.files {
+			if !seen[path] {
+				delete(idx.files, path)
+				removed++
+			}
+		}
+		if !idx.scanned {
+			log.Printf("[gopls-mcp] Symbol index for %s: %d files, %d to parse", idx.root, len(seen), len(stale))
+		}
+	} else {
+		for path := range changed {
+			info, err := os.Stat(path)
+			switch {
+			case err != nil:
+				// Deleted file or directory
+				for p := range idx.files {
+					if isUnder(p, path) {
+						delete(idx.files, p)
+						removed++
+					}
+				}
+			case info.Mode().IsRegular() && filepath.Ext(path) == ".go" && (idx.filtered == nil || !idx.filtered(path)):
+				if f, ok := idx.files[path]; !ok || !f.upToDate(info) {
+					stale = append(stale, path)
+				}
+				// Created directories need no handling: the watcher
+				// reports the files they contain.
+			}
+		}
+	}
+
+	var indexed map[string]*indexedFile
+	if len(stale) > 0 {
+		indexed = indexFiles(ctx, stale)
+		for path, f := range indexed {
+			if f == nil {
+				delete(idx.files, path) // deleted since
+				removed++
+				continue
+			}
+			idx.files[path] = f
+		}
+	}
+	if len(indexed) > 0 || removed > 0 {
+		idx.scheduleSave()
+	}
+	if ctx.Err() != nil {
+		// Keep the progress, and index the remaining files next time.
+		unprocessed := make(map[string]bool)
+		for _, path := range stale {
+			if _, ok := indexed[path]; !ok {
+				unprocessed[path] = true
+			}
+		}
+		idx.requeue(unprocessed)
+		return ctx.Err()
+	}
+	if walked {
+		idx.scanned = true
+	}
+	return nil
+}
+
+// requeue records paths as changed since the last update, so that the next
+// update processes them.
+func (idx *symbolIndex) requeue(paths map[string]bool) {
+	idx.changedMu.Lock()
+	defer idx.changedMu.Unlock()
+	for path := range paths {
+		idx.changed[path] = true
+	}
+}
+
+// walkGoFiles calls fn for each Go file in the tree of root, skipping
+// symbolic links, the directories that gopls ignores by convention
+// (testdata, and names starting with '.' or '_') and, if filtered is not
+// nil, the directories it reports as filtered.
+func walkGoFiles(root string, filtered func(path string) bool, fn func(path string, info fs.FileInfo)) error {
+	return filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			if path == root {
+				return err
+			}
+			return nil
+		}
+		if d.Type()&fs.ModeSymlink != 0 {
+			return nil
+		}
+		if d.IsDir() {
+			if name := d.Name(); path != root && (name == "testdata" || strings.HasPrefix(name, ".") || strings.HasPrefix(name, "_")) {
+				return filepath.SkipDir
+			}
+			if path != root && filtered != nil && filtered(path) {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if filepath.Ext(path) != ".go" {
+			return nil
+		}
+		if info, err := d.Info(); err == nil {
+			fn(path, info)
+		}
+		return nil
+	})
+}
+
+// indexFiles parses the files at the given paths and returns their index
+// entries, or nil for the files that no longer exist.
+// Each file is parsed, symbols extracted, then AST is discarded (GC-friendly).
+// Processes files concurrently using a worker pool for better performance.
+func indexFiles(ctx context.Context, paths []string) map[string]*indexedFile {
+	numWorkers := min(runtime.NumCPU(), 8)
+
+	pathsChan := make(chan string, len(paths))
+	for _, path := range paths {
+		pathsChan <- path
+	}
+	close(pathsChan)
+
+	var (
+		mu    sync.Mutex
+		files = make(map[string]*indexedFile, len(paths))
+		wg    sync.WaitGroup
+	)
+	for range numWorkers {
+		wg.Go(func() {
+			fset := token.NewFileSet()
+			for path := range pathsChan {
+				if ctx.Err() != nil {
+					return
+				}
+				f := indexFile(fset, path)
+				mu.Lock()
+				files[path] = f
+				mu.Unlock()
+			}
+		})
+	}
+	wg.Wait()
+	return files
+}
+
+// indexFile reads and parses the file at path, and returns its index
+// entry, or nil if it cannot be read.
+func indexFile(fset *token.FileSet, path string) *indexedFile {
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil
+	}
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return nil
+	}
+	f := &indexedFile{
+		ModTime:   info.ModTime().UnixNano(),
+		Size:      info.Size(),
+		IndexedAt: time.Now().UnixNano(),
+	}
+	f.Symbols, f.Texts = parseFileSymbols(fset, path, content)
+	return f
+}
+
+// parseFileSymbols parses a Go file and returns its symbols and their
+// searchable texts. A file with syntax errors yields the symbols of the
+// declarations that could be parsed.
+func parseFileSymbols(fset *token.FileSet, path string, content []byte) ([]*api.Symbol, []symbolText) {
+	f, _ := parser.ParseFile(fset, path, content, parser.ParseComments|parser.SkipObjectResolution)
+	if f == nil {
+		return nil, nil
+	}
+	return extractFileSymbols(f, fset.File(f.Pos()), path)
+}
+
+// fileSymbolCache caches the symbols of individual files outside of the
+// workspace, such as those of the dependencies and the standard library
+// searched by go_search with include_dependencies. The entries are
+// validated by modification time and size on each use. The zero value is
+// ready to use.
+type fileSymbolCache struct {
+	mu    sync.Mutex
+	files map[string]*indexedFile
+}
+
+// symbols returns the symbols of the files at the given paths, parsing the
+// files that are new or changed since they were cached. The files that
+// cannot be read are omitted.
+func (c *fileSymbolCache) symbols(ctx context.Context, paths []string) (map[string][]*api.Symbol, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.files == nil {
+		c.files = make(map[string]*indexedFile)
+	}
+
+	var stale []string
+	for _, path := range paths {
+		info, err := os.Stat(path)
+		if err != nil {
+			delete(c.files, path)
+			continue
+		}
+		if f, ok := c.files[path]; !ok || !f.upToDate(info) {
+			stale = append(stale, path)
+		}
+	}
+	for path, f := range indexFiles(ctx, stale) {
+		if f == nil {
+			delete(c.files, path)
+			continue
+		}
+		c.files[path] = f
+	}
+	if ctx.Err() != nil {
+		return nil, ctx.Err()
+	}
+
+	symbols := make(map[string][]*api.Symbol, len(paths))
+	for _, path := range paths {
+		if f, ok := c.files[path]; ok {
+			symbols[path] = f.Symbols
+		}
+	}
+	return symbols, nil
+}
+
+// persistedSymbolIndex is the persisted form of a symbol index.
+type persistedSymbolIndex struct {
+	Version int
+	Root    string
+	Files   map[string]*indexedFile
+}
+
+// filecacheKey returns the filecache key of the index.
+func (idx *symbolIndex) filecacheKey() [32]byte {
+	return sha256.Sum256([]byte(symbolIndexKind + "\x00" + idx.root))
+}
+
+// load loads the persisted index, if any. The loaded index is validated on
+// the first update.
+func (idx *symbolIndex) load() {
+	data, err := filecache.Get(symbolIndexKind, idx.filecacheKey())
+	if err != nil {
+		if !errors.Is(err, filecache.ErrNotFound) {
+			log.Printf("[gopls-mcp] Warning: failed to load symbol index for %s: %v", idx.root, err)
+		}
+		return
+	}
+	var persisted persistedSymbolIndex
+	if err := gob.NewDecoder(bytes.NewReader(data)).Decode(&persisted); err != nil || persisted.Version != symbolIndexVersion || persisted.Root != idx.root {
+		log.Printf("[gopls-mcp] Warning: ignoring invalid persisted symbol index for %s", idx.root)
+		return
+	}
+	idx.files = persisted.Files
+	log.Printf("[gopls-mcp] Loaded symbol index for %s from the file cache (%d files)", idx.root, len(idx.files))
+}
+
+// scheduleSave schedules saving the index, if it is persisted.
+// idx.mu must be held.
+func (idx *symbolIndex) scheduleSave() {
+	if idx.persist && idx.saveTimer == nil {
+		idx.saveTimer = time.AfterFunc(symbolIndexSaveDelay, func() {
+			idx.mu.Lock()
+			defer idx.mu.Unlock()
+			idx.save()
+		})
+	}
+}
+
+// flush saves the index now if a save is scheduled.
+func (idx *symbolIndex) flush() {
+	idx.mu.Lock()
+	defer idx.mu.Unlock()
+	if idx.saveTimer != nil && idx.saveTimer.Stop() {
+		idx.save()
+	}
+}
+
+// save stores the index in the file cache. idx.mu must be held.
+func (idx *symbolIndex) save() {
+	idx.saveTimer = nil
+	var buf bytes.Buffer
+	if err := gob.NewEncoder(&buf).Encode(persistedSymbolIndex{Version: symbolIndexVersion, Root: idx.root, Files: idx.files}); err != nil {
+		log.Printf("[gopls-mcp] Warning: failed to encode symbol index for %s: %v", idx.root, err)
+		return
+	}
+	if err := filecache.Set(symbolIndexKind, idx.filecacheKey(), buf.Bytes()); err != nil {
+		log.Printf("[gopls-mcp] Warning: failed to save symbol index for %s: %v", idx.root, err)
+		return
+	}
+	log.Printf("[gopls-mcp] Saved symbol index for %s (%d files, %d bytes)", idx.root, len(idx.files), buf.Len())
+}
@@ -0,0 +1,114 @@
+package core
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// TestSymbolIndexUpdateCancelled verifies that a cancelled update of a
+// watched index loses neither the files of the initial walk nor the
+// changes delivered by the watcher.
+func TestSymbolIndexUpdateCancelled(t *testing.T) {
+	root := t.TempDir()
+	write := func(name, content string) string {
+		path := filepath.Join(root, name)
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+		return path
+	}
+	for i := range 3 {
+		write(fmt.Sprintf("f%d.go", i), fmt.Sprintf("package p\n\nfunc F%d() {}\n", i))
+	}
+	idx := &symbolIndex{
+		root:    root,
+		watched: true,
+		files:   make(map[string]*indexedFile),
+		changed: make(map[string]bool),
+	}
+
+	cancelled, cancel := context.WithCancel(context.Background())
+	cancel()
+	if err := idx.update(cancelled); err == nil {
+		t.Fatal("Expected the cancelled update to fail")
+	}
+	if idx.scanned {
+		t.Error("Expected the index not to be scanned after a cancelled walk")
+	}
+	if err := idx.update(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if !idx.scanned || len(idx.files) != 3 {
+		t.Fatalf("Expected 3 indexed files after the walk, got %d (scanned: %v)", len(idx.files), idx.scanned)
+	}
+
+	// A change delivered by the watcher survives a cancelled update.
+	added := write("g.go", "package p\n\nfunc G() {}\n")
+	idx.changed[added] = true
+	if err := idx.update(cancelled); err == nil {
+		t.Fatal("Expected the cancelled update to fail")
+	}
+	if !idx.changed[added] {
+		t.Errorf("Expected %s to remain changed after a cancelled update, got %v", added, idx.changed)
+	}
+	if err := idx.update(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if f := idx.files[added]; f == nil || len(f.Symbols) != 1 || f.Symbols[0].Name != "G" {
+		t.Errorf("Expected %s to be indexed with G, got %+v", added, f)
+	}
+
+	// So do the changes of a failed walk.
+	idx.watched = false
+	idx.root = filepath.Join(root, "missing")
+	idx.changed[added] = true
+	if err := idx.update(context.Background()); err == nil {
+		t.Fatal("Expected the walk of a missing root to fail")
+	}
+	if !idx.changed[added] {
+		t.Errorf("Expected %s to remain changed after a failed walk", added)
+	}
+}
+
+// TestSymbolIndexFiltered verifies that the files of filtered directories
+// are indexed neither by a walk nor from the events of the watcher.
+func TestSymbolIndexFiltered(t *testing.T) {
+	root := t.TempDir()
+	write := func(name string) string {
+		path := filepath.Join(root, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte("package p\n\nfunc F() {}\n"), 0644); err != nil {
+			t.Fatal(err)
+		}
+		return path
+	}
+	kept := write("kept/a.go")
+	write("generated/b.go")
+	idx := &symbolIndex{
+		root:     root,
+		watched:  true,
+		filtered: func(path string) bool { return isUnder(path, filepath.Join(root, "generated")) },
+		files:    make(map[string]*indexedFile),
+		changed:  make(map[string]bool),
+	}
+	if err := idx.update(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if len(idx.files) != 1 || idx.files[kept] == nil {
+		t.Fatalf("Expected only %s to be indexed, got %v", kept, idx.files)
+	}
+
+	added := write("generated/c.go")
+	idx.changed[added] = true
+	if err := idx.update(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := idx.files[added]; ok {
+		t.Errorf("Expected %s in a filtered directory not to be indexed", added)
+	}
+}
@@ -2,9 +2,12 @@ package core
 
 import (
 	"context"
+	"fmt"
 	"log"
+	"time"
 
 	"github.com/modelcontextprotocol/go-sdk/mcp"
+	"golang.org/x/tools/internal/event"
 )
 
 // GenericTool is a type-safe wrapper for MCP tools that use our Handler pattern.
@@ -12,6 +15,17 @@ import (
 type GenericTool[In, Out any] struct {
 	Name        string
 	Description string
+	// Doc is the documentation of a custom tool (see WithTools), as returned
+	// by Docs. It defaults to the description. Built-in tools are documented
+	// in docMap instead.
+	Doc string
+	// Category is the go_list_tools category of a custom tool. If empty, the
+	// category is derived from the tool name.
+	Category string
+	// ReadOnly declares that the tool does not modify files or session
+	// state, nor run commands. Only read-only tools are served in read-only
+	// mode (see ToolsConfig.ReadOnly).
+	ReadOnly bool
 	// Handler takes a Handler with access to gopls session/snapshot
 	// Note: Out is typically a pointer type like *api.OGoInfo, so we return Out not *Out
 	Handler func(ctx context.Context, h *Handler, req *mcp.CallToolRequest, input In) (*mcp.CallToolResult, Out, error)
@@ -27,31 +41,77 @@ const defaultMaxResponseBytes = 32 * 1024 // 32KB
 
 // Register registers the tool with the MCP server using a Handler.
 // The Handler provides access to gopls's session and snapshot.
-// Automatically applies response size limits from handler config.
+// Automatically applies response size limits and default arguments from
+// handler config.
 func (t GenericTool[In, Out]) Register(server *mcp.Server, handler *Handler) {
-	// Get max bytes from config
+	toolConfig := handler.config.toolConfig(t.Name)
+
+	// Get max bytes from config, preferring the per-tool limit
 	maxBytes := handler.config.MaxResponseBytes
+	if toolConfig.MaxResponseBytes > 0 {
+		maxBytes = toolConfig.MaxResponseBytes
+	}
 	// set max bytes limit to prevent response consumes too many user tokens,
 	// as they are input tokens user need to pay for.
 	if maxBytes == 0 {
 		maxBytes = defaultMaxResponseBytes
 	}
 
-	// Create a wrapper function that applies response limits
+	// Create a wrapper function that applies response limits, records
+	// metrics (see metrics.go) and audits the call (see audit.go)
 	wrapped := func(ctx context.Context, req *mcp.CallToolRequest, input In) (*mcp.CallToolResult, Out, error) {
+		ctx, done := event.Start(ctx, "mcp.tool."+t.Name, toolName.Of(t.Name))
+		defer done()
+		call := &toolCall{tool: t.Name, req: req, start: time.Now()}
+		defer func() {
+			call.duration = time.Since(call.start)
+			call.record(ctx, handler)
+			if handler.audit != nil {
+				handler.audit.recordCall(call)
+			}
+		}()
+
+		if !toolAllowed(req, t.Name) {
+			var zero Out
+			call.err = fmt.Errorf("tool %s is not allowed for this token", t.Name)
+			call.isError = true
+			return nil, zero, call.err
+		}
+
+		if len(toolConfig.Defaults) > 0 {
+			var err error
+			if input, err = withDefaults[In](req, toolConfig.Defaults); err != nil {
+				var zero Out
+				call.err = fmt.Errorf("failed to apply default arguments of %s: %v", t.Name, err)
+				call.isError = true
+				return nil, zero, call.err
+			}
+		}
+
 		result, output, err := t.Handler(ctx, handler, req, input)
 		if err != nil {
+			call.err = err
+			call.isError = true
 			return result, output, err
 		}
 
 		// Apply response limits to ALL tools
 		if result != nil {
+			if size := resultSize(result); size > maxBytes {
+				call.truncatedFrom = size
+			}
 			result = applyResponseLimits(result, maxBytes, t.Name)
+			call.isError = result.IsError
 		}
+		call.responseBytes = resultSize(result)
 
 		return result, output, nil
 	}
-	mcp.AddTool(server, &mcp.Tool{Name: t.Name, Description: t.Description}, wrapped)
+	mcp.AddTool(server, &mcp.Tool{
+		Name:        t.Name,
+		Description: t.Description,
+		Annotations: &mcp.ToolAnnotations{ReadOnlyHint: t.ReadOnly},
+	}, wrapped)
 
 	log.Printf("[gopls-mcp] Registered tool %s: %s (max_bytes=%d)", t.Name, t.Description, maxBytes)
 }
@@ -61,12 +121,24 @@ func (t GenericTool[In, Out]) Details() (name, description string) {
 	return t.Name, t.Description
 }
 
+// Docs returns the documentation of the tool: its Doc, or else the
+// documentation of the built-in tool of that name, or else its description.
 func (t GenericTool[In, Out]) Docs() string {
-	doc, ok := docMap[t.Name]
-	if !ok {
-		panic("documentation not found for tool: " + t.Name)
+	if t.Doc != "" {
+		return t.Doc
+	}
+	if doc, ok := docMap[t.Name]; ok {
+		return doc
 	}
-	return doc
+	return t.Description
+}
+
+func (t GenericTool[In, Out]) category() string {
+	return t.Category
+}
+
+func (t GenericTool[In, Out]) readOnly() bool {
+	return t.ReadOnly
 }
 
 // getTools returns the list of registered tools.
@@ -0,0 +1,18 @@
+package core
+
+import "testing"
+
+func TestToolDocs(t *testing.T) {
+	for _, test := range []struct {
+		tool GenericTool[struct{}, any]
+		want string
+	}{
+		{GenericTool[struct{}, any]{Name: "acme_tool", Description: "Do things.", Doc: "Does things."}, "Does things."},
+		{GenericTool[struct{}, any]{Name: "acme_tool", Description: "Do things."}, "Do things."},
+		{GenericTool[struct{}, any]{Name: "go_search", Description: "Search."}, docMap["go_search"]},
+	} {
+		if got := test.tool.Docs(); got != test.want {
+			t.Errorf("Docs() of %s = %q, want %q", test.tool.Name, got, test.want)
+		}
+	}
+}
@@ -0,0 +1,101 @@
+package core
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"slices"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+)
+
+// Tool selection and per-tool configuration (MCPConfig.Tools): RegisterTools
+// only registers (and go_list_tools only lists) the enabled tools, and
+// GenericTool.Register applies the per-tool response limit and default
+// arguments.
+
+// toolEnabled reports whether the tools config enables the tool.
+func (h *Handler) toolEnabled(tool Tool) bool {
+	cfg := h.config.Tools
+	if cfg == nil {
+		return true
+	}
+	if cfg.ReadOnly && !isReadOnly(tool) {
+		return false
+	}
+	name, _ := tool.Details()
+	category := toolCategory(tool)
+	selected := func(list []string) bool {
+		return slices.Contains(list, name) || slices.Contains(list, category)
+	}
+	if len(cfg.Enabled) > 0 && !selected(cfg.Enabled) {
+		return false
+	}
+	return !selected(cfg.Disabled)
+}
+
+// isReadOnly reports whether a tool is declared read-only.
+func isReadOnly(tool Tool) bool {
+	r, ok := tool.(interface{ readOnly() bool })
+	return ok && r.readOnly()
+}
+
+// checkToolsConfig logs a warning for each tool or category of the tools
+// config that matches none of the given tools, which is likely a typo.
+func (h *Handler) checkToolsConfig(tools []Tool) {
+	cfg := h.config.Tools
+	if cfg == nil {
+		return
+	}
+	known := make(map[string]bool)
+	for _, tool := range tools {
+		name, _ := tool.Details()
+		known[name] = true
+		known[toolCategory(tool)] = true
+	}
+	for _, list := range [][]string{cfg.Enabled, cfg.Disabled} {
+		for _, item := range list {
+			if !known[item] {
+				log.Printf("[gopls-mcp] Warning: tools config: unknown tool or category %q", item)
+			}
+		}
+	}
+	for name := range cfg.PerTool {
+		if !known[name] {
+			log.Printf("[gopls-mcp] Warning: tools config: per_tool: unknown tool %q", name)
+		}
+	}
+}
+
+// toolConfig returns the per-tool configuration of the named tool.
+func (c *MCPConfig) toolConfig(name string) ToolConfig {
+	if c.Tools == nil {
+		return ToolConfig{}
+	}
+	return c.Tools.PerTool[name]
+}
+
+// withDefaults returns the tool input with the default arguments applied to
+// the arguments the client omitted.
+func withDefaults[In any](req *mcp.CallToolRequest, defaults map[string]any) (In, error) {
+	var input In
+	args := make(map[string]any)
+	if req != nil && req.Params != nil && len(req.Params.Arguments) > 0 {
+		if err := json.Unmarshal(req.Params.Arguments, &args); err != nil {
+			return input, fmt.Errorf("invalid arguments: %v", err)
+		}
+	}
+	for k, v := range defaults {
+		if _, ok := args[k]; !ok {
+			args[k] = v
+		}
+	}
+	data, err := json.Marshal(args)
+	if err != nil {
+		return input, err
+	}
+	if err := json.Unmarshal(data, &input); err != nil {
+		return input, fmt.Errorf("invalid default arguments: %v", err)
+	}
+	return input, nil
+}
@@ -0,0 +1,290 @@
+package pkg
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"maps"
+	"os"
+	"slices"
+	"strings"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+)
+
+// runCall implements the call command:
+//
+//	gopls-mcp call [flags] <tool> [-arg key=value ...] [-json]
+//	gopls-mcp call [flags] -   (batch: one {"tool": ..., "args": {...}} per line on stdin)
+//
+// It invokes tools on an in-process server, exactly as an agent would, and
+// prints their text output, or their structured output with -json.
+//
+// It returns the exit code: 0 on success, 1 if a tool call failed, and 2
+// for usage errors or if the server could not be started.
+func runCall(args []string, stdin io.Reader, stdout, stderr io.Writer) int {
+	fs := flag.NewFlagSet("call", flag.ContinueOnError)
+	fs.SetOutput(stderr)
+	workdir := fs.String("workdir", *workdirFlag, "Path to the Go project directory (default is current directory)")
+	configFile := fs.String("config", *configFlag, "Path to gopls-mcp configuration file (JSON format)")
+	trustProjectConfig := fs.Bool("trust-project-config", *trustProjectConfigFlag, "Apply all the settings of the project config")
+	// The config flags are set on the flags of Execute, which default to
+	// the values given before the call command, for applyConfigFlags.
+	fs.BoolVar(readOnlyFlag, "read-only", *readOnlyFlag, "Serve only read-only tools: no tools that edit files, apply changes or run commands")
+	fs.StringVar(directoryFiltersFlag, "directory-filters", *directoryFiltersFlag, "Comma-separated directory filters (e.g. \"-**/node_modules,-vendor\")")
+	jsonOutput := fs.Bool("json", false, "Print the structured output as JSON instead of the text output")
+	verbose := fs.Bool("verbose", false, "Log server activity to stderr")
+	var toolArgs callArgs
+	fs.Var(&toolArgs, "arg", "Tool argument as key=value; may be repeated (values are parsed as JSON unless the argument is a string)")
+	fs.Usage = func() {
+		fmt.Fprint(stderr, `Usage: gopls-mcp call [flags] <tool> [-arg key=value ...]
+       gopls-mcp call [flags] -
+
+Invokes a gopls-mcp tool and prints its output. With "-" instead of a tool
+name, reads a batch of calls from stdin, one JSON object per line:
+
+  {"tool": "go_search", "args": {"query": "Server"}}
+
+Flags:
+`)
+		fs.PrintDefaults()
+	}
+
+	// Flags may come before or after the tool name.
+	var positional []string
+	for {
+		if err := fs.Parse(args); err != nil {
+			return 2
+		}
+		if fs.NArg() == 0 {
+			break
+		}
+		positional = append(positional, fs.Arg(0))
+		args = fs.Args()[1:]
+	}
+	if len(positional) != 1 {
+		fs.Usage()
+		return 2
+	}
+	tool := positional[0]
+	if tool == "-" && len(toolArgs) > 0 {
+		fmt.Fprintln(stderr, "call: -arg cannot be used with a batch from stdin")
+		return 2
+	}
+
+	quietLogs(*verbose)
+
+	dir := *workdir
+	if dir == "" {
+		var err error
+		if dir, err = os.Getwd(); err != nil {
+			fmt.Fprintf(stderr, "call: %v\n", err)
+			return 2
+		}
+	}
+	config, _, err := newConfigSource(*configFile, dir, *trustProjectConfig).load()
+	if err != nil {
+		fmt.Fprintf(stderr, "call: %v\n", err)
+		return 2
+	}
+	applyConfigFlags(config)
+
+	ctx := context.Background()
+	session, release, err := connectInProcess(ctx, dir, config, "gopls-mcp-call")
+	if err != nil {
+		fmt.Fprintf(stderr, "call: %v\n", err)
+		return 2
+	}
+	defer release()
+
+	c := &caller{session: session, stdout: stdout, stderr: stderr, json: *jsonOutput}
+	if tool == "-" {
+		return c.batch(ctx, stdin)
+	}
+	params, err := c.params(ctx, tool, toolArgs)
+	if err != nil {
+		fmt.Fprintf(stderr, "call: %v\n", err)
+		return 2
+	}
+	if !c.call(ctx, params, false) {
+		return 1
+	}
+	return 0
+}
+
+// callArgs collects the -arg flags.
+type callArgs []string
+
+func (a *callArgs) String() string { return strings.Join(*a, " ") }
+
+func (a *callArgs) Set(s string) error {
+	if !strings.Contains(s, "=") {
+		return fmt.Errorf("expected key=value, got %q", s)
+	}
+	*a = append(*a, s)
+	return nil
+}
+
+// caller invokes tools for the call command.
+type caller struct {
+	session *mcp.ClientSession
+	stdout  io.Writer
+	stderr  io.Writer
+	json    bool
+
+	schemas map[string]*jsonSchema // input schemas by tool, loaded on demand
+}
+
+// jsonSchema is the part of a tool input schema used to interpret -arg
+// values.
+type jsonSchema struct {
+	Properties map[string]struct {
+		Type any `json:"type"` // a type name, or a list of them
+	} `json:"properties"`
+}
+
+// params returns the call parameters for a tool invoked with -arg flags.
+func (c *caller) params(ctx context.Context, tool string, args callArgs) (*mcp.CallToolParams, error) {
+	schema, err := c.schema(ctx, tool)
+	if err != nil {
+		return nil, err
+	}
+	arguments := make(map[string]any)
+	for _, arg := range args {
+		key, value, _ := strings.Cut(arg, "=")
+		// String arguments are taken literally, so that e.g. "-arg query=123"
+		// searches for "123"; other values are parsed as JSON.
+		if isStringProperty(schema, key) {
+			arguments[key] = value
+			continue
+		}
+		var v any
+		if err := json.Unmarshal([]byte(value), &v); err != nil {
+			v = value // let the server report the type mismatch
+		}
+		arguments[key] = v
+	}
+	return &mcp.CallToolParams{Name: tool, Arguments: arguments}, nil
+}
+
+// schema returns the input schema of a tool.
+func (c *caller) schema(ctx context.Context, tool string) (*jsonSchema, error) {
+	if c.schemas == nil {
+		c.schemas = make(map[string]*jsonSchema)
+		for t, err := range c.session.Tools(ctx, nil) {
+			if err != nil {
+				return nil, fmt.Errorf("failed to list tools: %v", err)
+			}
+			var schema jsonSchema
+			if data, err := json.Marshal(t.InputSchema); err == nil {
+				json.Unmarshal(data, &schema)
+			}
+			c.schemas[t.Name] = &schema
+		}
+	}
+	schema, ok := c.schemas[tool]
+	if !ok {
+		names := slices.Sorted(maps.Keys(c.schemas))
+		return nil, fmt.Errorf("unknown tool %q; available tools: %s", tool, strings.Join(names, ", "))
+	}
+	return schema, nil
+}
+
+// isStringProperty reports whether the schema declares key as a string.
+func isStringProperty(schema *jsonSchema, key string) bool {
+	prop, ok := schema.Properties[key]
+	if !ok {
+		return false
+	}
+	switch typ := prop.Type.(type) {
+	case string:
+		return typ == "string"
+	case []any:
+		return len(typ) > 0 && typ[0] == "string"
+	}
+	return false
+}
+
+// call invokes a tool and prints its output. In a batch, the output of
+// each call is prefixed with a header (text) or is a single line (JSON).
+// It reports whether the call succeeded.
+func (c *caller) call(ctx context.Context, params *mcp.CallToolParams, batch bool) bool {
+	res, err := c.session.CallTool(ctx, params)
+	if err != nil {
+		res = &mcp.CallToolResult{
+			IsError: true,
+			Content: []mcp.Content{&mcp.TextContent{Text: err.Error()}},
+		}
+	}
+
+	var text strings.Builder
+	for _, content := range res.Content {
+		if t, ok := content.(*mcp.TextContent); ok {
+			text.WriteString(t.Text)
+		}
+	}
+
+	switch {
+	case c.json && batch:
+		line := struct {
+			Tool    string `json:"tool"`
+			IsError bool   `json:"is_error,omitempty"`
+			Text    string `json:"text,omitempty"`
+			Result  any    `json:"result,omitempty"`
+		}{Tool: params.Name, IsError: res.IsError, Result: res.StructuredContent}
+		if res.IsError || res.StructuredContent == nil {
+			line.Text = text.String()
+		}
+		data, _ := json.Marshal(line)
+		fmt.Fprintf(c.stdout, "%s\n", data)
+	case c.json && !res.IsError && res.StructuredContent != nil:
+		data, _ := json.MarshalIndent(res.StructuredContent, "", "  ")
+		fmt.Fprintf(c.stdout, "%s\n", data)
+	default:
+		out := c.stdout
+		if res.IsError && !batch {
+			out = c.stderr
+		}
+		if batch {
+			fmt.Fprintf(out, "==> %s <==\n", params.Name)
+		}
+		fmt.Fprint(out, text.String())
+		if !strings.HasSuffix(text.String(), "\n") {
+			fmt.Fprintln(out)
+		}
+	}
+	return !res.IsError
+}
+
+// batch invokes the calls read from r, one JSON object per line, and
+// returns the exit code.
+func (c *caller) batch(ctx context.Context, r io.Reader) int {
+	code := 0
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(nil, 16<<20) // arguments may include file contents
+	for lineNum := 1; scanner.Scan(); lineNum++ {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		var call struct {
+			Tool string         `json:"tool"`
+			Args map[string]any `json:"args"`
+		}
+		if err := json.Unmarshal([]byte(line), &call); err != nil || call.Tool == "" {
+			fmt.Fprintf(c.stderr, "call: stdin:%d: expected {\"tool\": ..., \"args\": {...}}\n", lineNum)
+			return 2
+		}
+		if !c.call(ctx, &mcp.CallToolParams{Name: call.Tool, Arguments: call.Args}, true) {
+			code = 1
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		fmt.Fprintf(c.stderr, "call: %v\n", err)
+		return 2
+	}
+	return code
+}
@@ -0,0 +1,287 @@
+package pkg
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"log"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"sync"
+
+	"golang.org/x/tools/gopls/internal/cache"
+	"golang.org/x/tools/gopls/internal/protocol"
+	"golang.org/x/tools/gopls/internal/settings"
+	"golang.org/x/tools/gopls/mcpbridge/core"
+)
+
+// Configuration sources, in increasing order of precedence:
+//
+//  1. the configuration file: the -config flag, or the GOPLS_MCP_CONFIG
+//     environment variable, or else the project configuration at the
+//     workspace root: .gopls-mcp.json if it exists, or else the gopls-mcp
+//     comment section of go.work or go.mod (see commentSection);
+//  2. the environment variables of core.MCPConfig.ApplyEnv;
+//  3. the command-line flags (see loadConfig).
+//
+// The project configuration comes with the workspace, which may not be
+// trusted: unless -trust-project-config is set, it may only set the settings
+// that cannot run commands or write files (see restrictProjectConfig).
+//
+// The configuration files are watched: when they change, the configuration
+// is reloaded and the new gopls options are applied to the session's views.
+// The other settings take effect after a restart.
+
+const (
+	// configFileName is the project configuration file.
+	configFileName = ".gopls-mcp.json"
+	// configFileEnv is the environment variable naming the configuration
+	// file, if -config is not set.
+	configFileEnv = "GOPLS_MCP_CONFIG"
+	// commentPrefix starts the lines of the configuration section of
+	// go.work and go.mod comments.
+	commentPrefix = "gopls-mcp:"
+)
+
+// configSource is where the configuration is loaded from.
+type configSource struct {
+	file    string // the configuration file set by the user, or "" for the project configuration
+	dir     string // the workspace root
+	trusted bool   // whether the project configuration may set all the settings
+}
+
+// newConfigSource returns the configuration source of the workspace at
+// dir, given the configuration file set by the user, if any, and whether
+// the project configuration is trusted.
+func newConfigSource(file, dir string, trusted bool) configSource {
+	if file == "" {
+		file = os.Getenv(configFileEnv)
+	}
+	return configSource{file: file, dir: dir, trusted: trusted}
+}
+
+// files returns the files the configuration may be read from.
+func (s configSource) files() []string {
+	if s.file != "" {
+		return []string{s.file}
+	}
+	return []string{
+		filepath.Join(s.dir, configFileName),
+		filepath.Join(s.dir, "go.work"),
+		filepath.Join(s.dir, "go.mod"),
+	}
+}
+
+// read returns the JSON configuration and the file it was read from, or
+// no data if there is no project configuration.
+func (s configSource) read() ([]byte, string, error) {
+	if s.file != "" {
+		data, err := os.ReadFile(s.file)
+		if err != nil {
+			return nil, "", fmt.Errorf("failed to read config file: %v", err)
+		}
+		return data, s.file, nil
+	}
+	for _, file := range s.files() {
+		data, err := os.ReadFile(file)
+		if errors.Is(err, fs.ErrNotExist) {
+			continue
+		}
+		if err != nil {
+			return nil, "", fmt.Errorf("failed to read config file: %v", err)
+		}
+		if filepath.Base(file) != configFileName {
+			if data = commentSection(data); data == nil {
+				continue
+			}
+		}
+		return data, file, nil
+	}
+	return nil, "", nil
+}
+
+// commentSection returns the gopls-mcp configuration in the comments of a
+// go.work or go.mod file, or nil if there is none: the text following
+// "gopls-mcp:" in the comment lines that start with it, such as
+//
+//	// gopls-mcp: {"tools": {"read_only": true}}
+//
+// A configuration may span several such lines.
+func commentSection(data []byte) []byte {
+	var section []string
+	for line := range strings.SplitSeq(string(data), "\n") {
+		comment, ok := strings.CutPrefix(strings.TrimSpace(line), "//")
+		if !ok {
+			continue
+		}
+		if text, ok := strings.CutPrefix(strings.TrimSpace(comment), commentPrefix); ok {
+			section = append(section, text)
+		}
+	}
+	if section == nil {
+		return nil
+	}
+	return []byte(strings.Join(section, "\n"))
+}
+
+// load loads the configuration, or the default configuration if there is
+// no project configuration, and applies the environment overrides. It
+// returns the file the configuration was read from, if any.
+func (s configSource) load() (*core.MCPConfig, string, error) {
+	data, file, err := s.read()
+	if err != nil {
+		return nil, "", err
+	}
+	config := core.DefaultConfig()
+	if data != nil {
+		if config, err = core.LoadConfig(data); err != nil {
+			return nil, "", fmt.Errorf("failed to parse config %s: %v", file, err)
+		}
+		if s.file == "" && !s.trusted {
+			if ignored := restrictProjectConfig(config); len(ignored) > 0 {
+				log.Printf("[gopls-mcp] Warning: ignoring %s in the project config %s (set -trust-project-config to allow them)", strings.Join(ignored, ", "), file)
+			}
+		}
+	}
+	if err := config.ApplyEnv(os.Getenv); err != nil {
+		return nil, "", err
+	}
+	return config, file, nil
+}
+
+// unsafeGoplsOptions are the gopls options of an untrusted project
+// configuration that are ignored: they are passed to the go command, and
+// can make it run arbitrary commands (e.g. with -toolexec or GOFLAGS).
+var unsafeGoplsOptions = []string{"buildFlags", "env"}
+
+// restrictProjectConfig clears the settings of an untrusted project
+// configuration that could make the server run commands, write files,
+// serve other clients or analyze another directory, and returns the names
+// of those that were set.
+func restrictProjectConfig(config *core.MCPConfig) []string {
+	var ignored []string
+	for _, name := range unsafeGoplsOptions {
+		if _, ok := config.Gopls[name]; ok {
+			delete(config.Gopls, name)
+			ignored = append(ignored, "gopls."+name)
+		}
+	}
+	if config.Workdir != "" {
+		config.Workdir = ""
+		ignored = append(ignored, "workdir")
+	}
+	if config.Audit != nil {
+		config.Audit = nil
+		ignored = append(ignored, "audit")
+	}
+	if config.HTTP != nil {
+		config.HTTP = nil
+		ignored = append(ignored, "http")
+	}
+	return ignored
+}
+
+// restartSettings returns the names of the top-level settings other than
+// gopls that differ between two configurations: they are only read at
+// startup.
+func restartSettings(old, new *core.MCPConfig) []string {
+	var changed []string
+	for _, setting := range []struct {
+		name     string
+		old, new any
+	}{
+		{"workdir", old.Workdir, new.Workdir},
+		{"max_response_bytes", old.MaxResponseBytes, new.MaxResponseBytes},
+		{"http", old.HTTP, new.HTTP},
+		{"audit", old.Audit, new.Audit},
+		{"tools", old.Tools, new.Tools},
+		{"search_index", old.SearchIndex, new.SearchIndex},
+	} {
+		if !reflect.DeepEqual(setting.old, setting.new) {
+			changed = append(changed, setting.name)
+		}
+	}
+	return changed
+}
+
+// watchConfig reloads the configuration with load whenever a file of the
+// configuration source changes, and applies the new gopls options to the
+// workspace's views. Changes to the other settings are logged: they take
+// effect after a restart.
+//
+// config is the configuration the workspace was created with.
+func (w *workspace) watchConfig(ctx context.Context, source configSource, config *core.MCPConfig, load func() (*core.MCPConfig, error)) {
+	if w.watcher == nil {
+		return
+	}
+
+	var mu sync.Mutex // guards current, against concurrent events
+	current := config
+	reload := func(file string) {
+		mu.Lock()
+		defer mu.Unlock()
+		config, err := load()
+		if err != nil {
+			log.Printf("[gopls-mcp] Warning: failed to reload config, keeping the current one: %v", err)
+			return
+		}
+		if restart := restartSettings(current, config); len(restart) > 0 {
+			log.Printf("[gopls-mcp] Reloaded config %s: changes to %s take effect after a restart", file, strings.Join(restart, ", "))
+		}
+		if reflect.DeepEqual(config.Gopls, current.Gopls) {
+			log.Printf("[gopls-mcp] Reloaded config %s: gopls options unchanged", file)
+			return
+		}
+		if err := w.setGoplsOptions(ctx, newGoplsOptions(config)); err != nil {
+			log.Printf("[gopls-mcp] Warning: failed to apply reloaded gopls options: %v", err)
+			return
+		}
+		current = config
+		log.Printf("[gopls-mcp] Reloaded config %s: applied gopls options to %d views", file, len(w.session.Views()))
+	}
+
+	for _, file := range source.files() {
+		file, err := filepath.Abs(file)
+		if err != nil {
+			log.Printf("[gopls-mcp] Not watching config file %s: %v", file, err)
+			continue
+		}
+		if rel, err := filepath.Rel(w.dir, file); err != nil || strings.HasPrefix(rel, "..") {
+			// The watcher only sees files in the workspace.
+			log.Printf("[gopls-mcp] Not watching config file %s: outside of %s", file, w.dir)
+			continue
+		}
+		w.watcher.HandleFile(file, func(protocol.FileEvent) { reload(file) })
+		log.Printf("[gopls-mcp] Watching config file %s", file)
+	}
+}
+
+// setGoplsOptions recreates the session's views with the given gopls
+// options, as gopls does when the client's configuration changes.
+func (w *workspace) setGoplsOptions(ctx context.Context, options *settings.Options) error {
+	var (
+		folders []*cache.Folder
+		seen    = make(map[protocol.DocumentURI]bool)
+	)
+	for _, view := range w.session.Views() {
+		folder := view.Folder()
+		if seen[folder.Dir] {
+			continue
+		}
+		seen[folder.Dir] = true
+		env, err := cache.FetchGoEnv(ctx, folder.Dir, options)
+		if err != nil {
+			return fmt.Errorf("failed to load Go env for %s: %v", folder.Dir.Path(), err)
+		}
+		folders = append(folders, &cache.Folder{
+			Dir:     folder.Dir,
+			Name:    folder.Name,
+			Options: options.Clone(),
+			Env:     *env,
+		})
+	}
+	return w.session.UpdateFolders(ctx, folders)
+}
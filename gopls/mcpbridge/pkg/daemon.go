@@ -0,0 +1,305 @@
+package pkg
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+	"golang.org/x/tools/gopls/internal/cache"
+	"golang.org/x/tools/gopls/internal/lsprpc"
+	"golang.org/x/tools/gopls/internal/protocol"
+	"golang.org/x/tools/gopls/internal/settings"
+	"golang.org/x/tools/gopls/mcpbridge/core"
+	"golang.org/x/tools/internal/jsonrpc2"
+)
+
+// gopls-mcp daemon mode lets several processes share one gopls cache:
+//
+//   - A gopls-mcp daemon is started with -listen, or automatically by
+//     -remote=auto. It serves LSP on its address and MCP on a side socket
+//     (<address>.mcp).
+//   - Editors connect to the daemon's address with the standard gopls
+//     forwarder (gopls -remote=unix;<address>), as with a gopls daemon.
+//   - Agents run gopls-mcp -remote=<address>, which proxies its stdio MCP
+//     stream to the daemon's MCP socket.
+//
+// A stock gopls daemon (gopls serve, or gopls -remote=auto) only speaks LSP
+// and has no MCP socket, so gopls-mcp cannot attach to it: editors must use
+// the gopls-mcp daemon instead.
+//
+// Editors and agents are all served from a single session owned by the
+// daemon, on which each editor adds the views of its workspace folders, and
+// each agent the view of its project directory. Agents therefore see the
+// unsaved buffers of the editors, and keep working after the editors exit:
+// the views of the agents' projects are never removed.
+//
+// With -idle-timeout, the daemon shuts down once no editor or agent has been
+// connected for the given duration. Daemons started automatically by
+// -remote=auto always have an idle timeout.
+
+// mcpSocketSuffix is appended to the daemon's LSP socket path to form the
+// path of its MCP socket.
+const mcpSocketSuffix = ".mcp"
+
+// attachRequest is the first line a daemon client sends on the MCP socket,
+// before the MCP stream itself.
+type attachRequest struct {
+	// Workdir is the project directory of the client.
+	Workdir string `json:"workdir"`
+}
+
+// mcpAddress returns the path of the MCP socket for a daemon listening on
+// the given LSP network address.
+func mcpAddress(network, address string) (string, error) {
+	if network != "unix" {
+		return "", fmt.Errorf("attaching to a daemon requires a unix socket address, got %s;%s", network, address)
+	}
+	return address + mcpSocketSuffix, nil
+}
+
+// daemon is a gopls-mcp daemon: it serves gopls LSP sessions to editors and
+// gopls-mcp tools to agents from a single gopls cache.
+type daemon struct {
+	session *cache.Session // shared by all editors and agents
+	lsp     *lsprpc.SharedSessionServer
+	config  *core.MCPConfig
+	options *settings.Options
+	cliOpts []core.HandlerOption // handler options set by the command line
+	idle    *idleMonitor
+
+	mu         sync.Mutex
+	workspaces map[string]*workspace // the agents' projects, by directory
+}
+
+// runDaemon runs the daemon on the given address until it receives SIGINT
+// or SIGTERM, or until it has been idle for idleTimeout (if positive).
+func runDaemon(ctx context.Context, rawAddr string, config *core.MCPConfig, idleTimeout time.Duration, cliOpts []core.HandlerOption) error {
+	network, address, err := lsprpc.ResolveRemote(rawAddr)
+	if err != nil {
+		return err
+	}
+	mcpAddr, err := mcpAddress(network, address)
+	if err != nil {
+		return err
+	}
+
+	ctx, stop := signal.NotifyContext(ctx, syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	session := cache.NewSession(ctx, cache.New(nil))
+	d := &daemon{
+		session: session,
+		lsp: lsprpc.NewSharedSessionServer(session, func(opts *settings.Options) {
+			if err := config.ApplyGoplsOptions(opts); err != nil {
+				log.Printf("[gopls-mcp] Warning: Failed to apply some gopls options: %v", err)
+			}
+		}),
+		config:     config,
+		options:    newGoplsOptions(config),
+		cliOpts:    cliOpts,
+		workspaces: make(map[string]*workspace),
+		idle:       newIdleMonitor(idleTimeout, cancel),
+	}
+	defer d.close()
+
+	mcpListener, err := listenUnix(mcpAddr)
+	if err != nil {
+		return err
+	}
+	defer os.Remove(mcpAddr)
+	go d.serveMCP(ctx, mcpListener)
+
+	log.Printf("[gopls-mcp] Daemon listening for MCP clients on %s", mcpAddr)
+	log.Printf("[gopls-mcp] Daemon listening for editors on %s;%s (configure gopls with -remote=%s;%s)", network, address, network, address)
+	if idleTimeout > 0 {
+		log.Printf("[gopls-mcp] Daemon shuts down after %v without clients", idleTimeout)
+	}
+	err = jsonrpc2.ListenAndServe(ctx, network, address, idleStreamServer{d.lsp, d.idle}, 0)
+	if ctx.Err() != nil {
+		// Shut down by signal or idle timeout.
+		return nil
+	}
+	return err
+}
+
+// listenUnix listens on a unix socket, removing a stale socket file left by a
+// daemon that did not shut down cleanly.
+func listenUnix(path string) (net.Listener, error) {
+	if _, err := os.Stat(path); err == nil {
+		if conn, err := net.DialTimeout("unix", path, time.Second); err == nil {
+			conn.Close()
+			return nil, fmt.Errorf("a daemon is already listening on %s", path)
+		}
+		if err := os.Remove(path); err != nil {
+			return nil, fmt.Errorf("failed to remove stale socket %s: %v", path, err)
+		}
+	}
+	return net.Listen("unix", path)
+}
+
+// serveMCP accepts MCP clients until ctx is done.
+func (d *daemon) serveMCP(ctx context.Context, listener net.Listener) {
+	go func() {
+		<-ctx.Done()
+		listener.Close()
+	}()
+	for {
+		conn, err := listener.Accept()
+		if err != nil {
+			if ctx.Err() == nil {
+				log.Printf("[gopls-mcp] Failed to accept MCP client: %v", err)
+			}
+			return
+		}
+		go func() {
+			if err := d.serveMCPConn(ctx, conn); err != nil {
+				log.Printf("[gopls-mcp] MCP client ended: %v", err)
+			}
+		}()
+	}
+}
+
+// serveMCPConn serves a single MCP client connection.
+func (d *daemon) serveMCPConn(ctx context.Context, conn net.Conn) error {
+	defer conn.Close()
+	d.idle.connected()
+	defer d.idle.disconnected()
+
+	r := bufio.NewReader(conn)
+	line, err := r.ReadBytes('\n')
+	if err != nil {
+		return fmt.Errorf("failed to read attach request: %v", err)
+	}
+	var req attachRequest
+	if err := json.Unmarshal(line, &req); err != nil {
+		return fmt.Errorf("invalid attach request: %v", err)
+	}
+	if !filepath.IsAbs(req.Workdir) {
+		return fmt.Errorf("invalid attach request: workdir must be absolute, got %q", req.Workdir)
+	}
+
+	session, symbler, opts, err := d.sessionFor(ctx, req.Workdir)
+	if err != nil {
+		return err
+	}
+	log.Printf("[gopls-mcp] MCP client attached to session %s for %s", session.ID(), req.Workdir)
+
+	server, handler := newMCPServer(session, symbler, d.config, nil, append(opts, d.cliOpts...)...)
+	defer handler.Close()
+	bc := bufferedConn{r: r, Conn: conn}
+	return server.Run(ctx, &mcp.IOTransport{Reader: bc, Writer: bc})
+}
+
+// sessionFor returns the session serving dir, adding a view of dir on first
+// use, and the handler options for tools served from the view.
+func (d *daemon) sessionFor(ctx context.Context, dir string) (*cache.Session, core.Symbler, []core.HandlerOption, error) {
+	dir = filepath.Clean(dir)
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if ws, ok := d.workspaces[dir]; ok {
+		return ws.session, ws.lspServer, ws.handlerOptions(), nil
+	}
+	// Keep the view before looking for it, so that an editor that added it
+	// cannot remove it in between.
+	d.lsp.KeepView(protocol.URIFromPath(dir))
+	ws, err := newSessionWorkspace(context.WithoutCancel(ctx), d.session, dir, d.options)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	d.workspaces[dir] = ws
+	return ws.session, ws.lspServer, ws.handlerOptions(), nil
+}
+
+// close stops watching the agents' projects, and shuts down the session.
+func (d *daemon) close() {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	for _, ws := range d.workspaces {
+		ws.close()
+	}
+	d.session.Shutdown(context.Background())
+}
+
+// idleMonitor cancels the daemon once no client has been connected for the
+// idle timeout. A monitor with a non-positive timeout never does.
+type idleMonitor struct {
+	timeout time.Duration
+	cancel  context.CancelFunc
+
+	mu      sync.Mutex
+	clients int
+	timer   *time.Timer // running while there are no clients
+}
+
+// newIdleMonitor returns a monitor whose idle period starts immediately, so
+// that a daemon nobody connects to also shuts down.
+func newIdleMonitor(timeout time.Duration, cancel context.CancelFunc) *idleMonitor {
+	m := &idleMonitor{timeout: timeout, cancel: cancel}
+	if timeout > 0 {
+		m.timer = time.AfterFunc(timeout, m.expire)
+	}
+	return m
+}
+
+func (m *idleMonitor) connected() {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.clients++
+	if m.timer != nil {
+		m.timer.Stop()
+	}
+}
+
+func (m *idleMonitor) disconnected() {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.clients--
+	if m.clients == 0 && m.timer != nil {
+		m.timer.Reset(m.timeout)
+	}
+}
+
+func (m *idleMonitor) expire() {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	// A client may have connected after the timer fired.
+	if m.clients == 0 {
+		log.Printf("[gopls-mcp] No clients for %v, shutting down daemon", m.timeout)
+		m.cancel()
+	}
+}
+
+// idleStreamServer is a jsonrpc2.StreamServer that reports its LSP clients
+// to the daemon's idle monitor.
+type idleStreamServer struct {
+	jsonrpc2.StreamServer
+	idle *idleMonitor
+}
+
+func (s idleStreamServer) ServeStream(ctx context.Context, conn jsonrpc2.Conn) error {
+	s.idle.connected()
+	defer s.idle.disconnected()
+	return s.StreamServer.ServeStream(ctx, conn)
+}
+
+// bufferedConn is a net.Conn whose reads go through a bufio.Reader that may
+// already hold data read past the attach request.
+type bufferedConn struct {
+	r *bufio.Reader
+	net.Conn
+}
+
+func (c bufferedConn) Read(p []byte) (int, error) { return c.r.Read(p) }
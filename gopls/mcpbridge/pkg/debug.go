@@ -0,0 +1,41 @@
+package pkg
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"os"
+
+	"golang.org/x/tools/gopls/internal/debug"
+	"golang.org/x/tools/gopls/mcpbridge/core"
+)
+
+// startDebug returns a context carrying a gopls debug instance, which
+// aggregates the gopls and tool call metrics, and starts the debug server
+// if debugAddr is set. If traceFile is set, tool call spans are appended to
+// it by the returned span writer, and the returned function closes it.
+func startDebug(ctx context.Context, debugAddr, traceFile string) (context.Context, *core.SpanWriter, func(), error) {
+	ctx = debug.WithInstance(ctx)
+	debug.GetInstance(ctx).AddMetrics(core.RegisterMetrics)
+
+	if debugAddr != "" {
+		addr, err := debug.GetInstance(ctx).Serve(ctx, debugAddr)
+		if err != nil {
+			return nil, nil, nil, fmt.Errorf("failed to start debug server: %v", err)
+		}
+		log.Printf("[gopls-mcp] Debug server listening at http://%s (metrics at /metrics/)", addr)
+	}
+
+	var spans *core.SpanWriter
+	closeTrace := func() {}
+	if traceFile != "" {
+		f, err := os.OpenFile(traceFile, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+		if err != nil {
+			return nil, nil, nil, fmt.Errorf("failed to open trace file: %v", err)
+		}
+		spans = core.NewSpanWriter(f)
+		closeTrace = func() { f.Close() }
+		log.Printf("[gopls-mcp] Writing tool call spans to %s", traceFile)
+	}
+	return ctx, spans, closeTrace, nil
+}
@@ -6,12 +6,13 @@ import (
 	"fmt"
 	"io"
 	"log"
-	"net/http"
 	"os"
 	"os/signal"
 	"path/filepath"
 	"strings"
+	"sync"
 	"syscall"
+	"time"
 
 	"github.com/modelcontextprotocol/go-sdk/mcp"
 	"golang.org/x/tools/gopls/internal/cache"
@@ -20,11 +21,16 @@ import (
 	"golang.org/x/tools/gopls/internal/settings"
 	"golang.org/x/tools/gopls/mcpbridge/core"
 	"golang.org/x/tools/gopls/mcpbridge/watcher"
+	"golang.org/x/tools/internal/event"
 )
 
 const (
 	// mcpName is the name of the MCP server.
 	mcpName = "gopls-mcp"
+
+	// shutdownTimeout bounds the graceful shutdown in HTTP mode: draining
+	// in-flight tool calls, then releasing the workspace.
+	shutdownTimeout = 30 * time.Second
 )
 
 func init() {
@@ -34,6 +40,12 @@ func init() {
 
 See docs: https://gopls-mcp.org
 
+Usage:
+  gopls-mcp [options]
+  gopls-mcp call [flags] <tool> [-arg key=value ...] [-json]   invoke a tool and print its output
+  gopls-mcp call [flags] -                                     invoke a batch of tools read from stdin
+  gopls-mcp replay [flags] <recording>                         replay a -record recording against a checkout
+
 Options:
 `)
 		flag.PrintDefaults()
@@ -49,18 +61,45 @@ var (
 	showHelp = flag.Bool("help", false, "Print help information and exit")
 	// addr is the address to listen on (enables HTTP mode).
 	addr = flag.String("addr", "", "Address to listen on (e.g., localhost:8080)")
+	// remote attaches to a shared gopls-mcp daemon instead of creating an
+	// embedded gopls session (enables daemon client mode).
+	// A stock gopls daemon cannot be attached to.
+	remote = flag.String("remote", "", "Attach to a gopls-mcp daemon instead of an embedded session (e.g. auto, unix;/path/to/socket); a gopls daemon is not supported")
+	// listen runs gopls-mcp as a shared daemon (enables gopls-mcp daemon mode).
+	// This is normally started automatically by -remote=auto.
+	listen = flag.String("listen", "", "Run as a gopls-mcp daemon serving LSP and MCP on this address (e.g. unix;/path/to/socket)")
+	// idleTimeoutFlag shuts down a daemon without clients after this duration.
+	idleTimeoutFlag = flag.Duration("idle-timeout", 0, "In daemon mode, shut down after no clients were connected for this long (default: never, or 10m when started by -remote=auto)")
+	// debugAddr serves the gopls debug pages and metrics (optional).
+	debugAddr = flag.String("debug", "", "Serve debug information and Prometheus metrics (/metrics/) on this address (e.g. localhost:6060)")
+	// traceFile records a span per tool call (optional).
+	traceFile = flag.String("trace-file", "", "Append a JSON line per tool call (tool, duration, status, response size) to this file")
+	// recordFlag records the MCP session for "gopls-mcp replay" (optional).
+	recordFlag = flag.String("record", "", "Record every MCP request and response, with the workspace identity, to this file (see gopls-mcp replay)")
+	// lspAddr additionally serves the gopls LSP server on the embedded session.
+	lspAddr = flag.String("lsp", "", "Also serve LSP on this address, sharing the session with MCP (e.g. localhost:37374, unix;/path/to/socket)")
 	// verbose enables verbose logging.
 	verbose = flag.Bool("verbose", false, "Enable verbose logging")
 	// workdirFlag is the Go project directory to analyze (flag).
 	workdirFlag = flag.String("workdir", "", "Path to the Go project directory (default is current directory)")
 	// configFlag is the path to the MCP configuration file (optional).
-	configFlag = flag.String("config", "", "Path to gopls-mcp configuration file (JSON format)")
+	configFlag = flag.String("config", "", "Path to gopls-mcp configuration file (JSON format) (default is $GOPLS_MCP_CONFIG, or the project config in the workdir: .gopls-mcp.json, or the gopls-mcp comments of go.work or go.mod)")
+	// trustProjectConfigFlag allows the project config to set the settings
+	// that can run commands or write files.
+	trustProjectConfigFlag = flag.Bool("trust-project-config", false, "Apply all the settings of the project config, including audit, http, workdir and the gopls env and buildFlags")
 	// logfile is the path to a log file for debugging (optional).
 	// When set, logs are written to this file even in stdio mode.
 	logfile = flag.String("logfile", "", "Path to log file for debugging (writes logs even in stdio mode)")
 	// directoryFiltersFlag allows setting gopls directoryFilters via CLI flag.
 	// Filters use the same syntax as gopls directoryFilters (e.g. "-**/node_modules,-vendor").
 	directoryFiltersFlag = flag.String("directory-filters", "", "Comma-separated directory filters (e.g. \"-**/node_modules,-vendor\")")
+	// tokenFileFlag is the static bearer token file for HTTP mode (optional).
+	tokenFileFlag = flag.String("token-file", "", "Path to a bearer token file; requires HTTP clients to authenticate (HTTP mode)")
+	// tlsCertFlag and tlsKeyFlag enable HTTPS in HTTP mode (optional).
+	tlsCertFlag = flag.String("tls-cert", "", "Path to a TLS certificate file; serves HTTPS when set with -tls-key (HTTP mode)")
+	tlsKeyFlag  = flag.String("tls-key", "", "Path to a TLS private key file (HTTP mode)")
+	// readOnlyFlag serves only the read-only tools (see core.ToolsConfig).
+	readOnlyFlag = flag.Bool("read-only", false, "Serve only read-only tools: no tools that edit files, apply changes or run commands")
 )
 
 const (
@@ -76,10 +115,20 @@ const (
 func Execute() {
 	helpAndUsage()
 
+	// Subcommands
+	if args := flag.Args(); len(args) > 0 {
+		switch args[0] {
+		case "call":
+			os.Exit(runCall(args[1:], os.Stdin, os.Stdout, os.Stderr))
+		case "replay":
+			os.Exit(runReplay(args[1:]))
+		}
+	}
+
 	// Configure logging based on transport mode
 	// CRITICAL: In stdio mode, NEVER log to stdout/stderr as it corrupts MCP protocol
-	if *addr != "" {
-		// HTTP mode: logging to stdout is OK
+	if *addr != "" || (*listen != "" && *logfile == "") {
+		// HTTP and daemon mode: logging to stdout is OK
 		if *verbose {
 			log.SetFlags(log.LstdFlags | log.Lshortfile)
 			log.SetOutput(os.Stdout)
@@ -110,24 +159,165 @@ func Execute() {
 		}
 	}
 
-	// Load configuration from file if provided
-	var config *core.MCPConfig
-	if *configFlag != "" {
-		log.Printf("[gopls-mcp] Loading configuration from %s", *configFlag)
-		data, err := os.ReadFile(*configFlag)
+	configSource := newConfigSource(*configFlag, projectDir, *trustProjectConfigFlag)
+	config := loadConfig(configSource)
+
+	// Override workdir from config if set
+	if config.Workdir != "" {
+		projectDir = config.Workdir
+		log.Printf("[gopls-mcp] Using workdir from config: %s", projectDir)
+	}
+
+	ctx := context.Background()
+
+	// A recording identifies a single workspace, while a daemon serves the
+	// sessions of many, and -remote leaves serving to the daemon
+	if *recordFlag != "" && (*remote != "" || *listen != "") {
+		fmt.Fprintf(os.Stderr, "[gopls-mcp] -record is not supported with -remote or -listen: record the session of a gopls-mcp without them\n")
+		os.Exit(1)
+	}
+
+	// Daemon client mode: attach to a shared daemon and proxy stdio to it
+	if *remote != "" {
+		if err := runRemote(ctx, *remote, projectDir); err != nil {
+			fmt.Fprintf(os.Stderr, "[gopls-mcp] %v\n", err)
+			os.Exit(1)
+		}
+		return
+	}
+
+	// Collect metrics and spans (see -debug and -trace-file)
+	ctx, spans, closeTrace, err := startDebug(ctx, *debugAddr, *traceFile)
+	if err != nil {
+		log.Fatalf("[gopls-mcp] %v", err)
+	}
+	defer closeTrace()
+
+	auditLog, err := core.OpenAuditLog(config.Audit)
+	if err != nil {
+		log.Fatalf("[gopls-mcp] %v", err)
+	}
+	if auditLog != nil {
+		log.Printf("[gopls-mcp] Writing audit log to %s", config.Audit.Path)
+		defer auditLog.Close()
+	}
+
+	// The handler options of every MCP server of the process
+	cliOpts := cliHandlerOptions(spans, auditLog)
+
+	// Daemon mode: serve LSP to editors and MCP to agents from one cache
+	if *listen != "" {
+		if err := runDaemon(ctx, *listen, config, *idleTimeoutFlag, cliOpts); err != nil {
+			log.Fatalf("[gopls-mcp] Daemon failed: %v", err)
+		}
+		return
+	}
+
+	// Record the session for replay if requested
+	var rec *recorder
+	if *recordFlag != "" {
+		rec, err = newRecorder(ctx, *recordFlag, projectDir, config)
 		if err != nil {
-			log.Fatalf("[gopls-mcp] Failed to read config file: %v", err)
+			log.Fatalf("[gopls-mcp] %v", err)
 		}
-		config, err = core.LoadConfig(data)
+		log.Printf("[gopls-mcp] Recording session to %s", *recordFlag)
+		defer rec.close()
+	}
+
+	// Create gopls cache
+	goplsCache := cache.New(nil)
+
+	// Initialize gopls options (REQUIRED for view creation)
+	options := newGoplsOptions(config)
+
+	ws, err := newWorkspace(ctx, goplsCache, projectDir, options)
+	if err != nil {
+		log.Fatalf("[gopls-mcp] %v", err)
+	}
+	defer ws.close()
+
+	// Reload the gopls options when the config file changes
+	ws.watchConfig(ctx, configSource, config, func() (*core.MCPConfig, error) {
+		config, _, err := configSource.load()
+		if err == nil {
+			applyConfigFlags(config)
+		}
+		return config, err
+	})
+
+	// Serve LSP alongside MCP if requested, sharing the session
+	if *lspAddr != "" {
+		go serveLSP(ctx, *lspAddr, ws.session, config)
+	}
+
+	server, handler := newMCPServer(ws.session, ws.lspServer, config, rec, append(ws.handlerOptions(), cliOpts...)...)
+
+	log.Printf("[gopls-mcp] Registered %d MCP tools for Go analysis", 18)
+	log.Printf("[gopls-mcp] Working directory: %s", projectDir)
+
+	if *addr != "" {
+		// HTTP mode: serve until SIGINT or SIGTERM, then drain in-flight
+		// requests and release the workspace
+		ctx, stop := signal.NotifyContext(ctx, syscall.SIGINT, syscall.SIGTERM)
+		defer stop()
+		err := serveHTTP(ctx, server, *addr, config, ws.ready)
+
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
+		defer cancel()
+		handler.Close()
+		ws.shutdown(shutdownCtx)
 		if err != nil {
-			log.Fatalf("[gopls-mcp] Failed to parse config: %v", err)
+			log.Fatalf("[gopls-mcp] HTTP server failed: %v", err)
 		}
-		log.Printf("[gopls-mcp] Configuration loaded successfully")
-	} else {
-		// Use default configuration
-		config = core.DefaultConfig()
+		log.Printf("[gopls-mcp] HTTP server stopped")
+		return
+	}
+
+	// Stdio mode (default)
+	log.Printf("[gopls-mcp] Starting %s in stdio mode", mcpName)
+
+	// Set up signal handling for graceful shutdown
+	sigCh := make(chan os.Signal, 1)
+	signal.Notify(sigCh, syscall.SIGINT, syscall.SIGTERM)
+
+	// Run server in a goroutine so we can handle signals
+	serverErrCh := make(chan error, 1)
+	go func() {
+		serverErrCh <- server.Run(ctx, &mcp.StdioTransport{})
+	}()
+
+	// Wait for either server error or signal
+	select {
+	case err := <-serverErrCh:
+		// Server ended (likely connection closed)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "[gopls-mcp] Server ended: %v\n", err)
+		}
+	case sig := <-sigCh:
+		// Received signal, exit gracefully
+		fmt.Fprintf(os.Stderr, "[gopls-mcp] Received signal: %v\n", sig)
 	}
+	handler.Close()
+	// Always exit cleanly - stdio mode ends when client closes connection
+}
+
+// loadConfig loads the MCP configuration from its source (see config.go)
+// and merges command-line overrides into it.
+func loadConfig(source configSource) *core.MCPConfig {
+	config, file, err := source.load()
+	if err != nil {
+		log.Fatalf("[gopls-mcp] %v", err)
+	}
+	if file != "" {
+		log.Printf("[gopls-mcp] Loaded configuration from %s", file)
+	}
+	applyConfigFlags(config)
+	return config
+}
 
+// applyConfigFlags merges the command-line overrides into the
+// configuration.
+func applyConfigFlags(config *core.MCPConfig) {
 	// Merge CLI directory filters into config (overrides config file value)
 	if *directoryFiltersFlag != "" {
 		parts := strings.Split(*directoryFiltersFlag, ",")
@@ -147,18 +337,48 @@ func Execute() {
 		log.Printf("[gopls-mcp] Directory filters from CLI: %v", filters)
 	}
 
-	// Override workdir from config if set
-	if config.Workdir != "" {
-		projectDir = config.Workdir
-		log.Printf("[gopls-mcp] Using workdir from config: %s", projectDir)
+	// Merge CLI HTTP settings into config (overrides config file values)
+	if *tokenFileFlag != "" || *tlsCertFlag != "" || *tlsKeyFlag != "" {
+		if config.HTTP == nil {
+			config.HTTP = &core.HTTPConfig{}
+		}
+		if *tokenFileFlag != "" {
+			config.HTTP.TokenFile = *tokenFileFlag
+		}
+		if *tlsCertFlag != "" {
+			config.HTTP.TLSCertFile = *tlsCertFlag
+		}
+		if *tlsKeyFlag != "" {
+			config.HTTP.TLSKeyFile = *tlsKeyFlag
+		}
 	}
 
-	// Create gopls cache
-	ctx := context.Background()
-	goplsCache := cache.New(nil)
-	session := cache.NewSession(ctx, goplsCache)
+	// Merge CLI read-only mode into config
+	if *readOnlyFlag {
+		if config.Tools == nil {
+			config.Tools = &core.ToolsConfig{}
+		}
+		config.Tools.ReadOnly = true
+		log.Printf("[gopls-mcp] Read-only mode: serving read-only tools only")
+	}
+}
 
-	// Initialize gopls options (REQUIRED for view creation)
+// loadConfigFile loads the MCP configuration from a JSON file.
+func loadConfigFile(file string) (*core.MCPConfig, error) {
+	data, err := os.ReadFile(file)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read config file: %v", err)
+	}
+	config, err := core.LoadConfig(data)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse config: %v", err)
+	}
+	return config, nil
+}
+
+// newGoplsOptions returns the default gopls options with the user's gopls
+// configuration from the MCP config applied.
+func newGoplsOptions(config *core.MCPConfig) *settings.Options {
 	// Start with defaults and apply user configuration
 	options := settings.DefaultOptions()
 
@@ -170,13 +390,36 @@ func Execute() {
 	} else {
 		log.Printf("[gopls-mcp] Gopls options applied successfully")
 	}
+	return options
+}
+
+// workspace is a gopls session with a view of a single project directory,
+// kept up to date by a file watcher.
+type workspace struct {
+	dir       string
+	session   *cache.Session
+	lspServer *minimalServer
+	watcher   *watcher.Watcher // nil if the watcher failed to start
+	ready     chan struct{}    // closed once the initial workspace load finished
+	closeOnce sync.Once
+}
+
+// newWorkspace creates a session on the given cache with a view for
+// projectDir, and starts watching projectDir for file changes.
+func newWorkspace(ctx context.Context, goplsCache *cache.Cache, projectDir string, options *settings.Options) (*workspace, error) {
+	return newSessionWorkspace(ctx, cache.NewSession(ctx, goplsCache), projectDir, options)
+}
 
+// newSessionWorkspace is like newWorkspace, but adds the view to an existing
+// session, which may be shared with other clients. If the session already
+// has a view of projectDir, the workspace uses it.
+func newSessionWorkspace(ctx context.Context, session *cache.Session, projectDir string, options *settings.Options) (*workspace, error) {
 	// Fetch Go environment for the working directory (REQUIRED for view creation)
 	// This loads GOROOT, GOPATH, GOVERSION, and other critical environment info
 	dirURI := protocol.URIFromPath(projectDir)
 	goEnv, err := cache.FetchGoEnv(ctx, dirURI, options)
 	if err != nil {
-		log.Fatalf("[gopls-mcp] Failed to load Go env: %v", err)
+		return nil, fmt.Errorf("failed to load Go env: %v", err)
 	}
 
 	// Create a view for the working directory with proper initialization
@@ -186,19 +429,30 @@ func Execute() {
 		Options: options,
 		Env:     *goEnv,
 	}
-	view, _, releaseView, err := session.NewView(ctx, folder)
+	view, snapshot, releaseView, err := session.NewView(ctx, folder)
+	if err == cache.ErrViewExists {
+		view, snapshot, releaseView, err = existingView(session, dirURI)
+	}
 	if err != nil {
-		log.Fatalf("[gopls-mcp] Failed to create view for %s: %v", projectDir, err)
+		return nil, fmt.Errorf("failed to create view for %s: %v", projectDir, err)
 	}
-	defer releaseView()
 
-	log.Printf("[gopls-mcp] Created view for %s (type: %v)", projectDir, view.Type())
-	releaseView() // Release the initial snapshot since we won't use it
+	log.Printf("[gopls-mcp] Using view for %s (type: %v)", projectDir, view.Type())
+
+	// Wait for the initial workspace load in the background, then release
+	// the initial snapshot since we won't use it otherwise
+	ready := make(chan struct{})
+	go func() {
+		defer close(ready)
+		defer releaseView()
+		snapshot.AwaitInitialized(ctx)
+		log.Printf("[gopls-mcp] Initial workspace load finished for %s", projectDir)
+	}()
 
 	// Create a minimal LSP server stub that implements the methods we need
 	// The gopls-mcp handlers use the Symbol method for search
 	// The watcher uses DidChangeWatchedFiles to notify gopls of file changes
-	lspServer := &minimalServer{session: session}
+	lspServer := &minimalServer{session: session, dir: projectDir}
 
 	// Build directory skip function from directoryFilters so the file
 	// watcher excludes the same directories that gopls analysis ignores
@@ -210,73 +464,151 @@ func Execute() {
 
 	// Start file change watcher
 	// This keeps the gopls cache up-to-date when files are edited
-	var fileWatcher *watcher.Watcher
-	fileWatcher, err = watcher.New(lspServer, projectDir, watcherOpts...)
+	fileWatcher, err := watcher.New(lspServer, projectDir, watcherOpts...)
 	if err != nil {
 		log.Printf("[gopls-mcp] Failed to start file watcher: %v", err)
 		// Continue anyway - tools will work but file changes won't be detected
+		fileWatcher = nil
 	} else {
-		defer fileWatcher.Close()
 		log.Printf("[gopls-mcp] File watcher started for %s", projectDir)
 	}
 
-	// Create gopls-mcp handler backed by gopls session
-	// Pass the config to enable response limits
-	var handlerOpts []core.HandlerOption
-	handlerOpts = append(handlerOpts, core.WithConfig(config))
+	return &workspace{
+		dir:       projectDir,
+		session:   session,
+		lspServer: lspServer,
+		watcher:   fileWatcher,
+		ready:     ready,
+	}, nil
+}
+
+// existingView returns the session's view of dir, and its current snapshot.
+func existingView(session *cache.Session, dir protocol.DocumentURI) (*cache.View, *cache.Snapshot, func(), error) {
+	for _, view := range session.Views() {
+		if view.Folder().Dir == dir {
+			snapshot, release, err := view.Snapshot()
+			return view, snapshot, release, err
+		}
+	}
+	return nil, nil, nil, cache.ErrViewExists // e.g. the view of a symlink to dir
+}
+
+// close stops the file watcher. It is safe to call close more than once.
+func (w *workspace) close() {
+	w.closeOnce.Do(func() {
+		if w.watcher != nil {
+			w.watcher.Close()
+		}
+	})
+}
+
+// shutdown stops the file watcher and releases the session's views, waiting
+// for in-flight work on their snapshots to finish until ctx is done.
+func (w *workspace) shutdown(ctx context.Context) {
+	w.close()
+	done := make(chan struct{})
+	go func() {
+		w.session.Shutdown(ctx)
+		close(done)
+	}()
+	select {
+	case <-done:
+		log.Printf("[gopls-mcp] Released views for %s", w.dir)
+	case <-ctx.Done():
+		log.Printf("[gopls-mcp] Timed out releasing views for %s", w.dir)
+	}
+}
+
+// handlerOptions returns the handler options for tools served from the
+// workspace.
+func (w *workspace) handlerOptions() []core.HandlerOption {
+	opts := []core.HandlerOption{core.WithDefaultDir(w.dir)}
+	if w.watcher != nil {
+		opts = append(opts, core.WithWatcher(w.watcher))
+	}
+	return opts
+}
+
+// cliHandlerOptions returns the handler options set by the command line
+// for the MCP servers of the process: the span writer and audit log, if
+// any, the tools added by RegisterTools, and dynamic views in tests.
+func cliHandlerOptions(spans *core.SpanWriter, audit *core.AuditLog) []core.HandlerOption {
+	var opts []core.HandlerOption
+	if spans != nil {
+		opts = append(opts, core.WithSpanWriter(spans))
+	}
+	if audit != nil {
+		opts = append(opts, core.WithAuditLog(audit))
+	}
+	if len(customTools) > 0 {
+		opts = append(opts, core.WithTools(customTools...))
+	}
 	// Check environment variable for dynamic view creation (test-only)
 	if os.Getenv(allowDynamicViewsEnv) == "true" || os.Getenv(allowDynamicViewsEnv) == "1" {
 		log.Printf("[gopls-mcp] Dynamic views enabled via %s (TEST-ONLY)", allowDynamicViewsEnv)
-		handlerOpts = append(handlerOpts, core.WithDynamicViews(true))
+		opts = append(opts, core.WithDynamicViews(true))
 	}
-	coreHandler := core.NewHandler(session, lspServer, handlerOpts...)
+	return opts
+}
+
+// newMCPServer creates an MCP server with all gopls-mcp tools registered,
+// backed by the given gopls session, and recording its sessions to rec if
+// it is not nil. It also returns the tools' handler, which must be closed
+// when the server is no longer used.
+func newMCPServer(session *cache.Session, symbler core.Symbler, config *core.MCPConfig, rec *recorder, opts ...core.HandlerOption) (*mcp.Server, *core.Handler) {
+	// Create gopls-mcp handler backed by gopls session
+	// Pass the config to enable response limits
+	handlerOpts := append([]core.HandlerOption{core.WithConfig(config)}, opts...)
+	coreHandler := core.NewHandler(session, symbler, handlerOpts...)
 
 	// Create MCP server and register all gopls-mcp tools
 	server := mcp.NewServer(&mcp.Implementation{Name: mcpName, Version: version}, nil)
 	core.RegisterTools(server, coreHandler)
-
-	log.Printf("[gopls-mcp] Registered %d MCP tools for Go analysis", 18)
-	log.Printf("[gopls-mcp] Working directory: %s", projectDir)
-
-	if *addr != "" {
-		// HTTP mode
-		handler := mcp.NewStreamableHTTPHandler(func(r *http.Request) *mcp.Server {
-			return server
-		}, &mcp.StreamableHTTPOptions{JSONResponse: true})
-
-		http.Handle("/", handler)
-		log.Printf("[gopls-mcp] Starting %s HTTP server at %s", mcpName, *addr)
-		if err := http.ListenAndServe(*addr, nil); err != nil {
-			log.Fatalf("[gopls-mcp] HTTP server failed: %v", err)
-		}
-		return
+	if rec != nil {
+		server.AddReceivingMiddleware(rec.middleware())
 	}
+	return server, coreHandler
+}
 
-	// Stdio mode (default)
-	log.Printf("[gopls-mcp] Starting %s in stdio mode", mcpName)
-
-	// Set up signal handling for graceful shutdown
-	sigCh := make(chan os.Signal, 1)
-	signal.Notify(sigCh, syscall.SIGINT, syscall.SIGTERM)
+// connectInProcess creates a server for dir and connects an in-process
+// client to it once the initial workspace load has finished. The returned
+// function closes the client and releases the server.
+func connectInProcess(ctx context.Context, dir string, config *core.MCPConfig, clientName string) (*mcp.ClientSession, func(), error) {
+	srv, err := NewServer(ctx, Options{Workdir: dir, Config: config, Tools: customTools})
+	if err != nil {
+		return nil, nil, err
+	}
+	<-srv.Ready()
 
-	// Run server in a goroutine so we can handle signals
-	serverErrCh := make(chan error, 1)
-	go func() {
-		serverErrCh <- server.Run(ctx, &mcp.StdioTransport{})
-	}()
+	serverTransport, clientTransport := mcp.NewInMemoryTransports()
+	serverSession, err := srv.MCPServer().Connect(ctx, serverTransport, nil)
+	if err != nil {
+		srv.Close(ctx)
+		return nil, nil, err
+	}
+	client := mcp.NewClient(&mcp.Implementation{Name: clientName, Version: version}, nil)
+	session, err := client.Connect(ctx, clientTransport, nil)
+	if err != nil {
+		serverSession.Close()
+		srv.Close(ctx)
+		return nil, nil, err
+	}
+	return session, func() {
+		session.Close()
+		serverSession.Close()
+		srv.Close(ctx)
+	}, nil
+}
 
-	// Wait for either server error or signal
-	select {
-	case err := <-serverErrCh:
-		// Server ended (likely connection closed)
-		if err != nil {
-			fmt.Fprintf(os.Stderr, "[gopls-mcp] Server ended: %v\n", err)
-		}
-	case sig := <-sigCh:
-		// Received signal, exit gracefully
-		fmt.Fprintf(os.Stderr, "[gopls-mcp] Received signal: %v\n", sig)
+// quietLogs discards the logs of the embedded server in command-line
+// commands (replay, call), unless verbose is set.
+func quietLogs(verbose bool) {
+	if verbose {
+		log.SetOutput(os.Stderr)
+	} else {
+		log.SetOutput(io.Discard)
+		event.SetExporter(nil) // gopls logs to stderr by default
 	}
-	// Always exit cleanly - stdio mode ends when client closes connection
 }
 
 func makeDirectoryFilterSkipFunc(filters []string, root string) filewatcher.Option {
@@ -0,0 +1,263 @@
+package pkg
+
+import (
+	"bufio"
+	"context"
+	"crypto/subtle"
+	"fmt"
+	"log"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"slices"
+	"strings"
+
+	"github.com/modelcontextprotocol/go-sdk/auth"
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+	"golang.org/x/tools/gopls/internal/debug"
+	"golang.org/x/tools/gopls/mcpbridge/core"
+)
+
+// serveHTTP serves the MCP server over streamable HTTP on address, with the
+// authentication, TLS and origin settings of config.HTTP, until ctx is done.
+// It then stops accepting requests and waits up to shutdownTimeout for
+// in-flight tool calls to finish.
+//
+// Besides MCP, the server answers unauthenticated health checks on /healthz
+// (the process is up) and /readyz (the initial workspace load, signaled by
+// closing ready, has finished and the server is not shutting down), and
+// serves Prometheus metrics on /metrics, with the same authentication as MCP.
+func serveHTTP(ctx context.Context, server *mcp.Server, address string, config *core.MCPConfig, ready <-chan struct{}) error {
+	httpConfig := config.HTTP
+	if httpConfig == nil {
+		httpConfig = &core.HTTPConfig{}
+	}
+	authenticate, err := newAuthenticator(httpConfig)
+	if err != nil {
+		return err
+	}
+	handler := newHTTPHandler(server, httpConfig, authenticate)
+
+	tls := httpConfig.TLSCertFile != "" || httpConfig.TLSKeyFile != ""
+	if tls && (httpConfig.TLSCertFile == "" || httpConfig.TLSKeyFile == "") {
+		return fmt.Errorf("TLS requires both a certificate and a key file")
+	}
+
+	// Standalone SSE streams (GET requests) stay open until the client goes
+	// away, so they are cancelled when shutdown starts instead of drained.
+	streamsCtx, cancelStreams := context.WithCancel(context.Background())
+	defer cancelStreams()
+
+	mux := http.NewServeMux()
+	mux.Handle("/", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodGet {
+			ctx, cancel := context.WithCancel(r.Context())
+			defer cancel()
+			defer context.AfterFunc(streamsCtx, cancel)()
+			r = r.WithContext(ctx)
+		}
+		handler.ServeHTTP(w, r)
+	}))
+	mux.HandleFunc("/healthz", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprintln(w, "ok")
+	})
+	if di := debug.GetInstance(ctx); di != nil {
+		mux.Handle("/metrics", authenticate(http.HandlerFunc(di.ServeMetrics)))
+	}
+	mux.HandleFunc("/readyz", func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case ctx.Err() != nil:
+			http.Error(w, "shutting down", http.StatusServiceUnavailable)
+		case !isClosed(ready):
+			http.Error(w, "loading workspace", http.StatusServiceUnavailable)
+		default:
+			fmt.Fprintln(w, "ok")
+		}
+	})
+
+	srv := &http.Server{
+		Addr:    address,
+		Handler: mux,
+		// Requests carry the debug instance for metrics, but are not
+		// cancelled by shutdown so that in-flight tool calls can finish.
+		BaseContext: func(net.Listener) context.Context { return context.WithoutCancel(ctx) },
+	}
+	errCh := make(chan error, 1)
+	go func() {
+		if tls {
+			log.Printf("[gopls-mcp] Starting %s HTTPS server at %s", mcpName, address)
+			errCh <- srv.ListenAndServeTLS(httpConfig.TLSCertFile, httpConfig.TLSKeyFile)
+		} else {
+			log.Printf("[gopls-mcp] Starting %s HTTP server at %s", mcpName, address)
+			errCh <- srv.ListenAndServe()
+		}
+	}()
+
+	select {
+	case err := <-errCh:
+		return err
+	case <-ctx.Done():
+	}
+
+	log.Printf("[gopls-mcp] Shutting down, draining in-flight requests")
+	cancelStreams()
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
+	defer cancel()
+	if err := srv.Shutdown(shutdownCtx); err != nil {
+		log.Printf("[gopls-mcp] Graceful shutdown incomplete: %v", err)
+		srv.Close()
+	}
+	return nil
+}
+
+// isClosed reports whether ch is closed.
+func isClosed(ch <-chan struct{}) bool {
+	select {
+	case <-ch:
+		return true
+	default:
+		return false
+	}
+}
+
+// newHTTPHandler returns the HTTP handler for the MCP server: the streamable
+// MCP handler behind authenticate and an origin check.
+func newHTTPHandler(server *mcp.Server, httpConfig *core.HTTPConfig, authenticate func(http.Handler) http.Handler) http.Handler {
+	handler := mcp.NewStreamableHTTPHandler(func(r *http.Request) *mcp.Server {
+		return server
+	}, &mcp.StreamableHTTPOptions{JSONResponse: true})
+	return checkOrigin(httpConfig.AllowedOrigins, authenticate(handler))
+}
+
+// newAuthenticator returns a function that puts a handler behind bearer
+// token authentication, if tokens are configured.
+func newAuthenticator(httpConfig *core.HTTPConfig) (func(http.Handler) http.Handler, error) {
+	tokens, err := loadTokens(httpConfig)
+	if err != nil {
+		return nil, err
+	}
+	if len(tokens) == 0 {
+		log.Printf("[gopls-mcp] Warning: HTTP authentication is disabled; do not expose this server beyond localhost")
+		return func(h http.Handler) http.Handler { return h }, nil
+	}
+	log.Printf("[gopls-mcp] HTTP authentication enabled (%d tokens)", len(tokens))
+	return auth.RequireBearerToken(tokenVerifier(tokens), nil), nil
+}
+
+// loadTokens returns the configured tokens and those of the token file.
+func loadTokens(httpConfig *core.HTTPConfig) ([]core.HTTPToken, error) {
+	tokens := slices.Clone(httpConfig.Tokens)
+	for i, token := range tokens {
+		if token.Token == "" {
+			return nil, fmt.Errorf("http.tokens[%d]: token must not be empty", i)
+		}
+	}
+	if httpConfig.TokenFile == "" {
+		return tokens, nil
+	}
+
+	f, err := os.Open(httpConfig.TokenFile)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read token file: %v", err)
+	}
+	defer f.Close()
+	scanner := bufio.NewScanner(f)
+	for lineNum := 1; scanner.Scan(); lineNum++ {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		fields := strings.Fields(line)
+		if len(fields) > 2 {
+			return nil, fmt.Errorf("%s:%d: expected a token and an optional comma-separated tool list", httpConfig.TokenFile, lineNum)
+		}
+		token := core.HTTPToken{
+			Name:  fmt.Sprintf("%s:%d", httpConfig.TokenFile, lineNum),
+			Token: fields[0],
+		}
+		if len(fields) == 2 {
+			for tool := range strings.SplitSeq(fields[1], ",") {
+				if tool != "" {
+					token.Tools = append(token.Tools, tool)
+				}
+			}
+		}
+		tokens = append(tokens, token)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read token file: %v", err)
+	}
+	return tokens, nil
+}
+
+// tokenVerifier returns an auth.TokenVerifier accepting the given tokens.
+func tokenVerifier(tokens []core.HTTPToken) auth.TokenVerifier {
+	return func(ctx context.Context, bearer string, req *http.Request) (*auth.TokenInfo, error) {
+		// Compare every token in constant time so that response timing
+		// reveals nothing about the configured tokens.
+		var match *core.HTTPToken
+		for i := range tokens {
+			if subtle.ConstantTimeCompare([]byte(tokens[i].Token), []byte(bearer)) == 1 {
+				match = &tokens[i]
+			}
+		}
+		if match == nil {
+			return nil, auth.ErrInvalidToken
+		}
+		return match.TokenInfo(), nil
+	}
+}
+
+// checkOrigin rejects browser requests from origins that are not allowed,
+// and answers CORS preflight requests from allowed ones.
+//
+// Requests without an Origin header (non-browser clients) are always let
+// through. With no allowed origins configured, only loopback origins are
+// allowed.
+func checkOrigin(allowed []string, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		origin := r.Header.Get("Origin")
+		if origin == "" {
+			next.ServeHTTP(w, r)
+			return
+		}
+		if !originAllowed(allowed, origin) {
+			http.Error(w, "origin not allowed", http.StatusForbidden)
+			return
+		}
+
+		w.Header().Set("Access-Control-Allow-Origin", origin)
+		w.Header().Add("Vary", "Origin")
+		w.Header().Set("Access-Control-Expose-Headers", "Mcp-Session-Id")
+		if r.Method == http.MethodOptions {
+			w.Header().Set("Access-Control-Allow-Methods", "GET, POST, DELETE, OPTIONS")
+			w.Header().Set("Access-Control-Allow-Headers", "Authorization, Content-Type, Mcp-Session-Id, Mcp-Protocol-Version, Last-Event-ID")
+			w.WriteHeader(http.StatusNoContent)
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// originAllowed reports whether a browser origin may call the server.
+func originAllowed(allowed []string, origin string) bool {
+	if len(allowed) == 0 {
+		u, err := url.Parse(origin)
+		if err != nil {
+			return false
+		}
+		host := u.Hostname()
+		if host == "localhost" {
+			return true
+		}
+		ip := net.ParseIP(host)
+		return ip != nil && ip.IsLoopback()
+	}
+	for _, a := range allowed {
+		if a == "*" || strings.EqualFold(strings.TrimSuffix(a, "/"), origin) {
+			return true
+		}
+	}
+	return false
+}
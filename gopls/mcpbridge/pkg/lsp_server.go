@@ -0,0 +1,32 @@
+package pkg
+
+import (
+	"context"
+	"log"
+
+	"golang.org/x/tools/gopls/internal/cache"
+	"golang.org/x/tools/gopls/internal/lsprpc"
+	"golang.org/x/tools/gopls/internal/settings"
+	"golang.org/x/tools/gopls/mcpbridge/core"
+	"golang.org/x/tools/internal/jsonrpc2"
+)
+
+// serveLSP serves the standard gopls LSP server on the given address, backed
+// by the same session as the MCP tools. Editor edits (overlays) and agent
+// queries therefore see identical snapshots, without a second cache.
+//
+// Editors connect directly (TCP) or through the gopls forwarder, e.g.
+// gopls -remote=unix;/path/to/socket.
+func serveLSP(ctx context.Context, rawAddr string, session *cache.Session, config *core.MCPConfig) {
+	network, address := lsprpc.ParseAddr(rawAddr)
+	ss := lsprpc.NewSharedSessionServer(session, func(opts *settings.Options) {
+		if err := config.ApplyGoplsOptions(opts); err != nil {
+			log.Printf("[gopls-mcp] Warning: Failed to apply some gopls options: %v", err)
+		}
+	})
+
+	log.Printf("[gopls-mcp] Serving LSP on %s network, address %s", network, address)
+	if err := jsonrpc2.ListenAndServe(ctx, network, address, ss, 0); err != nil && ctx.Err() == nil {
+		log.Printf("[gopls-mcp] LSP server failed: %v", err)
+	}
+}
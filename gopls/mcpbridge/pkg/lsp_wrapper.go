@@ -9,11 +9,13 @@ import (
 	"golang.org/x/tools/gopls/internal/golang"
 	"golang.org/x/tools/gopls/internal/protocol"
 	"golang.org/x/tools/gopls/internal/settings"
+	"golang.org/x/tools/gopls/internal/util/pathutil"
 )
 
 // minimalServer is a wrapper around cache.Session that implements for DidChangeWatchedFiles.
 type minimalServer struct {
 	session *cache.Session
+	dir     string // the project directory; other projects' views of a shared session are not searched
 }
 
 // Symbol implements workspace symbol search using gopls's internal golang package.
@@ -30,6 +32,9 @@ func (s *minimalServer) Symbol(ctx context.Context, params *protocol.WorkspaceSy
 	releases := make([]func(), 0, len(views))
 
 	for _, view := range views {
+		if !s.inProject(view) {
+			continue
+		}
 		snapshot, release, err := view.Snapshot()
 		if err != nil {
 			// Log but continue - skip views that can't produce snapshots
@@ -66,6 +71,16 @@ func (s *minimalServer) Symbol(ctx context.Context, params *protocol.WorkspaceSy
 	return symbols, nil
 }
 
+// inProject reports whether the view is one of the project's views: its
+// folder contains, or is contained in, the project directory.
+func (s *minimalServer) inProject(view *cache.View) bool {
+	if s.dir == "" {
+		return true
+	}
+	folder := view.Folder().Dir.Path()
+	return pathutil.InDir(folder, s.dir) || pathutil.InDir(s.dir, folder)
+}
+
 // DidChangeWatchedFiles notifies gopls that files have changed on disk.
 // This is called by the file watcher when it detects filesystem changes.
 // It implements the LSP protocol to ensure proper cache invalidation.
@@ -0,0 +1,219 @@
+package pkg
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io/fs"
+	"log"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+	"golang.org/x/tools/gopls/internal/debug"
+	"golang.org/x/tools/gopls/mcpbridge/core"
+)
+
+// Session recordings (-record) capture every MCP request and response of a
+// server, together with the identity of the workspace they were made
+// against, so that a misbehaving session can be reproduced elsewhere with
+// "gopls-mcp replay" (see replay.go).
+//
+// A recording is a JSONL file: a recordHeader line followed by one
+// recordEntry line per request or notification received by the server.
+
+// recordHeader is the first line of a recording.
+type recordHeader struct {
+	Version      string         `json:"version"` // gopls-mcp version
+	GoplsVersion string         `json:"gopls_version"`
+	Time         time.Time      `json:"time"`
+	Workspace    workspaceID    `json:"workspace"`
+	Gopls        map[string]any `json:"gopls,omitempty"` // gopls options of the config
+	MaxResponse  int            `json:"max_response_bytes,omitempty"`
+}
+
+// workspaceID identifies the state of a workspace directory.
+type workspaceID struct {
+	Dir       string `json:"dir"`
+	GoVersion string `json:"go_version,omitempty"`
+	GitCommit string `json:"git_commit,omitempty"`
+	GitDirty  bool   `json:"git_dirty,omitempty"`
+	// Fingerprint is a hash of the Go source and module files of the
+	// workspace (see fingerprintWorkspace).
+	Fingerprint string `json:"fingerprint"`
+}
+
+// recordEntry is a request or notification received by the server, with
+// the server's response.
+type recordEntry struct {
+	Time       time.Time       `json:"time"`
+	Session    string          `json:"session,omitempty"`
+	Method     string          `json:"method"`
+	Params     json.RawMessage `json:"params,omitempty"`
+	Result     json.RawMessage `json:"result,omitempty"`
+	Error      string          `json:"error,omitempty"`
+	DurationMs float64         `json:"duration_ms"`
+}
+
+// recorder writes a recording. It is safe for concurrent use, and may be
+// shared by several MCP servers.
+type recorder struct {
+	mu  sync.Mutex
+	f   *os.File
+	enc *json.Encoder
+}
+
+// newRecorder creates the recording file and writes its header, identifying
+// the workspace at projectDir.
+func newRecorder(ctx context.Context, file, projectDir string, config *core.MCPConfig) (*recorder, error) {
+	id, err := identifyWorkspace(ctx, projectDir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to identify workspace: %v", err)
+	}
+	f, err := os.Create(file)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create recording: %v", err)
+	}
+	r := &recorder{f: f, enc: json.NewEncoder(f)}
+	header := recordHeader{
+		Version:      version,
+		GoplsVersion: debug.VersionInfo().Version,
+		Time:         time.Now(),
+		Workspace:    id,
+		Gopls:        config.Gopls,
+		MaxResponse:  config.MaxResponseBytes,
+	}
+	if err := r.enc.Encode(header); err != nil {
+		f.Close()
+		return nil, fmt.Errorf("failed to write recording: %v", err)
+	}
+	return r, nil
+}
+
+// middleware returns the MCP middleware recording the requests received by
+// a server.
+func (r *recorder) middleware() mcp.Middleware {
+	return func(next mcp.MethodHandler) mcp.MethodHandler {
+		return func(ctx context.Context, method string, req mcp.Request) (mcp.Result, error) {
+			start := time.Now()
+			result, err := next(ctx, method, req)
+
+			entry := recordEntry{
+				Time:       start,
+				Method:     method,
+				DurationMs: float64(time.Since(start)) / float64(time.Millisecond),
+			}
+			if session := req.GetSession(); session != nil {
+				entry.Session = session.ID()
+			}
+			entry.Params = marshalRecorded(req.GetParams())
+			if err != nil {
+				entry.Error = err.Error()
+			} else {
+				entry.Result = marshalRecorded(result)
+			}
+			r.write(entry)
+			return result, err
+		}
+	}
+}
+
+// marshalRecorded marshals a request's params or result, or returns nil
+// for nil values.
+func marshalRecorded(v any) json.RawMessage {
+	if v == nil {
+		return nil
+	}
+	data, err := json.Marshal(v)
+	if err != nil || string(data) == "null" {
+		return nil
+	}
+	return data
+}
+
+func (r *recorder) write(entry recordEntry) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if err := r.enc.Encode(entry); err != nil {
+		log.Printf("[gopls-mcp] Failed to write recording: %v", err)
+	}
+}
+
+// close closes the recording file.
+func (r *recorder) close() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.f.Close()
+}
+
+// identifyWorkspace returns the identity of the workspace at dir: its Go
+// version, git commit (if it is a git checkout) and a fingerprint of its
+// sources.
+func identifyWorkspace(ctx context.Context, dir string) (workspaceID, error) {
+	id := workspaceID{Dir: dir}
+	run := func(name string, args ...string) (string, error) {
+		cmd := exec.CommandContext(ctx, name, args...)
+		cmd.Dir = dir
+		out, err := cmd.Output()
+		return strings.TrimSpace(string(out)), err
+	}
+	if goVersion, err := run("go", "env", "GOVERSION"); err == nil {
+		id.GoVersion = goVersion
+	}
+	if commit, err := run("git", "rev-parse", "HEAD"); err == nil {
+		id.GitCommit = commit
+		if status, err := run("git", "status", "--porcelain"); err == nil {
+			id.GitDirty = status != ""
+		}
+	}
+	fingerprint, err := fingerprintWorkspace(dir)
+	if err != nil {
+		return workspaceID{}, err
+	}
+	id.Fingerprint = fingerprint
+	return id, nil
+}
+
+// fingerprintWorkspace returns a hash of the paths and contents of the Go
+// files and module files under dir, skipping hidden directories. Tools are
+// expected to answer the same in two directories with the same fingerprint.
+func fingerprintWorkspace(dir string) (string, error) {
+	h := sha256.New()
+	err := filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() {
+			if path != dir && strings.HasPrefix(d.Name(), ".") {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		switch name := d.Name(); {
+		case strings.HasSuffix(name, ".go"), name == "go.mod", name == "go.sum", name == "go.work", name == "go.work.sum":
+		default:
+			return nil
+		}
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(dir, path)
+		if err != nil {
+			return err
+		}
+		fmt.Fprintf(h, "%s\x00%d\x00", filepath.ToSlash(rel), len(data))
+		h.Write(data)
+		return nil
+	})
+	if err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
@@ -0,0 +1,109 @@
+package pkg
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net"
+	"os"
+	"path/filepath"
+	"time"
+
+	"golang.org/x/tools/gopls/internal/lsprpc"
+)
+
+// runRemote attaches to the gopls-mcp daemon at rawAddr and proxies the MCP
+// stream between stdio and the daemon until either side closes it.
+//
+// If rawAddr is on the 'auto' network and no daemon is running, one is
+// started in the background, like gopls -remote=auto does. The 'auto'
+// socket is derived from the gopls-mcp executable, so it is never the one
+// of a gopls daemon.
+func runRemote(ctx context.Context, rawAddr, projectDir string) error {
+	network, address, err := lsprpc.ResolveRemote(rawAddr)
+	if err != nil {
+		return err
+	}
+	mcpAddr, err := mcpAddress(network, address)
+	if err != nil {
+		return err
+	}
+	projectDir, err = filepath.Abs(projectDir)
+	if err != nil {
+		return err
+	}
+
+	conn, err := net.DialTimeout("unix", mcpAddr, time.Second)
+	if err != nil {
+		// Let the gopls dialer start the daemon (for 'auto' addresses) and
+		// wait for it to accept LSP connections, then retry.
+		log.Printf("[gopls-mcp] No daemon at %s, connecting through %s", mcpAddr, rawAddr)
+		lspConn, err := lsprpc.DialRemote(ctx, rawAddr, daemonArgs)
+		if err != nil {
+			return fmt.Errorf("failed to connect to daemon: %v", err)
+		}
+		lspConn.Close()
+		if conn, err = dialWithRetry(mcpAddr); err != nil {
+			// A stock gopls daemon serves LSP on the address, but has no
+			// MCP socket.
+			return fmt.Errorf("the daemon at %s is not a gopls-mcp daemon (no MCP socket %s): start one with gopls-mcp -listen=%q, and point the editor's gopls -remote at it", rawAddr, mcpAddr, rawAddr)
+		}
+	}
+	defer conn.Close()
+
+	if err := json.NewEncoder(conn).Encode(attachRequest{Workdir: projectDir}); err != nil {
+		return fmt.Errorf("failed to attach to daemon: %v", err)
+	}
+	log.Printf("[gopls-mcp] Attached to daemon at %s for %s", mcpAddr, projectDir)
+
+	go func() {
+		io.Copy(conn, os.Stdin)
+		// Signal end of input to the daemon, which then closes the session.
+		if uc, ok := conn.(*net.UnixConn); ok {
+			uc.CloseWrite()
+		}
+	}()
+	if _, err := io.Copy(os.Stdout, conn); err != nil {
+		return fmt.Errorf("daemon connection failed: %v", err)
+	}
+	return nil
+}
+
+// defaultDaemonIdleTimeout is the idle timeout of automatically started
+// daemons when -idle-timeout is not set. It is long enough to keep the
+// workspace loaded between consecutive agent sessions.
+const defaultDaemonIdleTimeout = 10 * time.Minute
+
+// daemonArgs returns the arguments for an automatically started daemon.
+func daemonArgs(network, address string) []string {
+	timeout := *idleTimeoutFlag
+	if timeout <= 0 {
+		timeout = defaultDaemonIdleTimeout
+	}
+	args := []string{"-listen", network + ";" + address, "-idle-timeout", timeout.String()}
+	if *configFlag != "" {
+		if config, err := filepath.Abs(*configFlag); err == nil {
+			args = append(args, "-config", config)
+		}
+	}
+	if *directoryFiltersFlag != "" {
+		args = append(args, "-directory-filters", *directoryFiltersFlag)
+	}
+	return args
+}
+
+// dialWithRetry dials a unix socket of a daemon that may still be starting.
+func dialWithRetry(path string) (net.Conn, error) {
+	const retries = 10
+	var err error
+	for range retries {
+		var conn net.Conn
+		if conn, err = net.DialTimeout("unix", path, time.Second); err == nil {
+			return conn, nil
+		}
+		time.Sleep(200 * time.Millisecond)
+	}
+	return nil, err
+}
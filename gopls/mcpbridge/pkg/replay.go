@@ -0,0 +1,258 @@
+package pkg
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+	"golang.org/x/tools/gopls/mcpbridge/core"
+	"golang.org/x/tools/internal/diff"
+)
+
+// runReplay implements the replay command:
+//
+//	gopls-mcp replay [-workdir dir] [-config file] <recording>
+//
+// It replays the tool calls of a recording (see record.go) against the
+// workspace at dir, in a fresh in-process server, and prints a diff for
+// every response that differs from the recorded one. Paths under the
+// recorded workspace directory are mapped to dir. Calls of tools that are
+// not read-only, which may edit files or run commands, are skipped unless
+// -allow-writes is set.
+//
+// It returns the exit code: 0 if all responses match, 1 if some differ,
+// and 2 if the replay failed.
+func runReplay(args []string) int {
+	fs := flag.NewFlagSet("replay", flag.ExitOnError)
+	workdir := fs.String("workdir", "", "Path to the checkout to replay against (default is current directory)")
+	configFile := fs.String("config", "", "Path to a gopls-mcp configuration file (default is the recorded gopls options)")
+	verbose := fs.Bool("verbose", false, "Log server activity to stderr")
+	allowWrites := fs.Bool("allow-writes", false, "Also replay the calls of tools that are not read-only, which may edit the checkout")
+	fs.Usage = func() {
+		fmt.Fprint(os.Stderr, `Usage: gopls-mcp replay [flags] <recording>
+
+Replays the tool calls of a recording made with -record against a checkout,
+and reports the responses that differ from the recorded ones.
+
+Flags:
+`)
+		fs.PrintDefaults()
+	}
+	fs.Parse(args)
+	if fs.NArg() != 1 {
+		fs.Usage()
+		return 2
+	}
+
+	quietLogs(*verbose)
+
+	dir := *workdir
+	if dir == "" {
+		var err error
+		if dir, err = os.Getwd(); err != nil {
+			fmt.Fprintf(os.Stderr, "replay: %v\n", err)
+			return 2
+		}
+	}
+	header, entries, err := readRecording(fs.Arg(0))
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "replay: %v\n", err)
+		return 2
+	}
+	differ, err := replay(context.Background(), os.Stdout, header, entries, dir, *configFile, *allowWrites)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "replay: %v\n", err)
+		return 2
+	}
+	if differ {
+		return 1
+	}
+	return 0
+}
+
+// readRecording reads the header and entries of a recording.
+func readRecording(file string) (*recordHeader, []recordEntry, error) {
+	f, err := os.Open(file)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer f.Close()
+
+	var (
+		header  *recordHeader
+		entries []recordEntry
+	)
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(nil, 64<<20) // responses may be large
+	for lineNum := 1; scanner.Scan(); lineNum++ {
+		if lineNum == 1 {
+			header = new(recordHeader)
+			if err := json.Unmarshal(scanner.Bytes(), header); err != nil {
+				return nil, nil, fmt.Errorf("%s:1: invalid recording header: %v", file, err)
+			}
+			continue
+		}
+		var entry recordEntry
+		if err := json.Unmarshal(scanner.Bytes(), &entry); err != nil {
+			return nil, nil, fmt.Errorf("%s:%d: invalid recording entry: %v", file, lineNum, err)
+		}
+		entries = append(entries, entry)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, nil, err
+	}
+	if header == nil {
+		return nil, nil, fmt.Errorf("%s: empty recording", file)
+	}
+	return header, entries, nil
+}
+
+// replay replays the tool calls among entries against the workspace at dir,
+// writing a report to w. It reports whether any response differs. Unless
+// allowWrites is set, calls of tools that are not read-only are skipped.
+func replay(ctx context.Context, w io.Writer, header *recordHeader, entries []recordEntry, dir, configFile string, allowWrites bool) (bool, error) {
+	config := core.DefaultConfig()
+	if configFile != "" {
+		var err error
+		if config, err = loadConfigFile(configFile); err != nil {
+			return false, err
+		}
+	} else {
+		if header.Gopls != nil {
+			config.Gopls = header.Gopls
+		}
+		if header.MaxResponse > 0 {
+			config.MaxResponseBytes = header.MaxResponse
+		}
+	}
+
+	recorded := header.Workspace
+	current, err := identifyWorkspace(ctx, dir)
+	if err != nil {
+		return false, fmt.Errorf("failed to identify workspace: %v", err)
+	}
+	fmt.Fprintf(w, "Replaying %s (recorded against %s) against %s\n", recordingSummary(entries), recorded.Dir, dir)
+	if current.Fingerprint != recorded.Fingerprint {
+		fmt.Fprintf(w, "Note: the workspace sources differ from the recording")
+		if recorded.GitCommit != "" {
+			fmt.Fprintf(w, " (recorded at commit %s", recorded.GitCommit)
+			if recorded.GitDirty {
+				fmt.Fprintf(w, " with local changes")
+			}
+			fmt.Fprintf(w, ")")
+		}
+		fmt.Fprintln(w)
+	}
+	if current.GoVersion != recorded.GoVersion {
+		fmt.Fprintf(w, "Note: recorded with %s, replaying with %s\n", recorded.GoVersion, current.GoVersion)
+	}
+
+	session, release, err := connectInProcess(ctx, dir, config, "gopls-mcp-replay")
+	if err != nil {
+		return false, err
+	}
+	defer release()
+
+	readOnly := make(map[string]bool)
+	for tool, err := range session.Tools(ctx, nil) {
+		if err != nil {
+			return false, fmt.Errorf("failed to list tools: %v", err)
+		}
+		readOnly[tool.Name] = tool.Annotations != nil && tool.Annotations.ReadOnlyHint
+	}
+
+	// Map paths of the recorded workspace to the replayed one in arguments,
+	// and both to a placeholder in responses.
+	toCurrent := strings.NewReplacer(recorded.Dir, dir)
+	toPlaceholder := strings.NewReplacer(recorded.Dir, "$WORKDIR", dir, "$WORKDIR")
+
+	var calls, skipped, differ int
+	for _, entry := range entries {
+		if entry.Method != "tools/call" {
+			continue
+		}
+		calls++
+		var params mcp.CallToolParams
+		if err := json.Unmarshal([]byte(toCurrent.Replace(string(entry.Params))), &params); err != nil {
+			return false, fmt.Errorf("invalid tools/call params: %v", err)
+		}
+		if !allowWrites && !readOnly[params.Name] {
+			skipped++
+			fmt.Fprintf(w, "[%d] %s: skipped (not a read-only tool; replay it with -allow-writes)\n", calls, params.Name)
+			continue
+		}
+
+		want := entry.Error
+		if want == "" {
+			var res mcp.CallToolResult
+			if err := json.Unmarshal(entry.Result, &res); err != nil {
+				return false, fmt.Errorf("invalid tools/call result: %v", err)
+			}
+			want = renderResult(&res)
+		}
+		var got string
+		if res, err := session.CallTool(ctx, &params); err != nil {
+			got = err.Error()
+		} else {
+			got = renderResult(res)
+		}
+		want, got = toPlaceholder.Replace(want), toPlaceholder.Replace(got)
+
+		if want == got {
+			fmt.Fprintf(w, "[%d] %s: ok\n", calls, params.Name)
+			continue
+		}
+		differ++
+		fmt.Fprintf(w, "[%d] %s: response differs\n", calls, params.Name)
+		fmt.Fprint(w, diff.Unified("recorded", "replayed", want, got))
+	}
+	fmt.Fprintf(w, "%d tool calls replayed, %d skipped, %d differ\n", calls-skipped, skipped, differ)
+	return differ > 0, nil
+}
+
+// recordingSummary describes the recorded requests.
+func recordingSummary(entries []recordEntry) string {
+	calls := 0
+	for _, entry := range entries {
+		if entry.Method == "tools/call" {
+			calls++
+		}
+	}
+	return fmt.Sprintf("%d requests, %d tool calls", len(entries), calls)
+}
+
+// renderResult renders a tool result for comparison: its text content
+// followed by its structured content, indented.
+func renderResult(res *mcp.CallToolResult) string {
+	var b strings.Builder
+	if res.IsError {
+		b.WriteString("(error result)\n")
+	}
+	for _, content := range res.Content {
+		if text, ok := content.(*mcp.TextContent); ok {
+			b.WriteString(text.Text)
+			if !strings.HasSuffix(text.Text, "\n") {
+				b.WriteString("\n")
+			}
+		}
+	}
+	if res.StructuredContent != nil {
+		// Round-trip through any so that object keys are sorted, however
+		// the content was produced.
+		var v any
+		if data, err := json.Marshal(res.StructuredContent); err == nil && json.Unmarshal(data, &v) == nil {
+			if data, err := json.MarshalIndent(v, "", "  "); err == nil {
+				b.WriteString("structured content:\n")
+				b.Write(data)
+				b.WriteString("\n")
+			}
+		}
+	}
+	return b.String()
+}
@@ -0,0 +1,134 @@
+package pkg
+
+import (
+	"context"
+	"fmt"
+	"os"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+	"golang.org/x/tools/gopls/internal/cache"
+	"golang.org/x/tools/gopls/mcpbridge/core"
+)
+
+// Embedding API: programs can build their own gopls-mcp binary with extra
+// tools, either by registering them before calling Execute:
+//
+//	func main() {
+//		pkg.RegisterTools(myTool)
+//		pkg.Execute()
+//	}
+//
+// or by creating a Server and serving it on any MCP transport:
+//
+//	srv, err := pkg.NewServer(ctx, pkg.Options{Workdir: dir, Tools: []core.Tool{myTool}})
+//	...
+//	defer srv.Close(ctx)
+//	err = srv.Run(ctx, &mcp.StdioTransport{})
+//
+// Custom tools are core.GenericTool values; see core.WithTools.
+
+// customTools are the tools added by RegisterTools.
+var customTools []core.Tool
+
+// RegisterTools adds custom tools to the servers started by Execute, and by
+// its subcommands. It must be called before Execute.
+func RegisterTools(tools ...core.Tool) {
+	customTools = append(customTools, tools...)
+}
+
+// Options configures a Server created by NewServer.
+type Options struct {
+	// Workdir is the Go project directory to analyze.
+	// Default: the current directory.
+	Workdir string
+
+	// Config is the gopls-mcp configuration, including its audit log.
+	// Default: core.DefaultConfig().
+	Config *core.MCPConfig
+
+	// Tools are custom tools served in addition to the built-in tools.
+	Tools []core.Tool
+}
+
+// Server is a gopls-mcp server: a gopls session with a view of the project
+// directory, kept up to date by a file watcher, and an MCP server serving
+// the gopls-mcp tools from it.
+type Server struct {
+	ws      *workspace
+	server  *mcp.Server
+	handler *core.Handler
+	audit   *core.AuditLog // nil if not configured
+}
+
+// NewServer creates a Server for the project in opts.Workdir. The initial
+// workspace load continues in the background (see Ready).
+//
+// The server is built from opts only: it does not serve the tools added by
+// RegisterTools, nor use the command line flags of Execute.
+func NewServer(ctx context.Context, opts Options) (*Server, error) {
+	dir := opts.Workdir
+	if dir == "" {
+		var err error
+		if dir, err = os.Getwd(); err != nil {
+			return nil, fmt.Errorf("failed to get working directory: %v", err)
+		}
+	}
+	config := opts.Config
+	if config == nil {
+		config = core.DefaultConfig()
+	}
+
+	audit, err := core.OpenAuditLog(config.Audit)
+	if err != nil {
+		return nil, err
+	}
+	ws, err := newWorkspace(ctx, cache.New(nil), dir, newGoplsOptions(config))
+	if err != nil {
+		if audit != nil {
+			audit.Close()
+		}
+		return nil, err
+	}
+	handlerOpts := ws.handlerOptions()
+	if audit != nil {
+		handlerOpts = append(handlerOpts, core.WithAuditLog(audit))
+	}
+	if len(opts.Tools) > 0 {
+		handlerOpts = append(handlerOpts, core.WithTools(opts.Tools...))
+	}
+	server, handler := newMCPServer(ws.session, ws.lspServer, config, nil, handlerOpts...)
+	return &Server{ws: ws, server: server, handler: handler, audit: audit}, nil
+}
+
+// MCPServer returns the MCP server, e.g. to serve it over HTTP with
+// mcp.NewStreamableHTTPHandler.
+func (s *Server) MCPServer() *mcp.Server {
+	return s.server
+}
+
+// Handler returns the handler of the server's tools.
+func (s *Server) Handler() *core.Handler {
+	return s.handler
+}
+
+// Ready returns a channel that is closed once the initial workspace load
+// has finished. Tools may be called before, but wait for it.
+func (s *Server) Ready() <-chan struct{} {
+	return s.ws.ready
+}
+
+// Run serves the MCP server on the transport until the client disconnects
+// or ctx is done.
+func (s *Server) Run(ctx context.Context, transport mcp.Transport) error {
+	return s.server.Run(ctx, transport)
+}
+
+// Close stops the file watcher, releases the workspace, waiting for
+// in-flight work until ctx is done, and closes the audit log.
+func (s *Server) Close(ctx context.Context) {
+	s.handler.Close()
+	s.ws.shutdown(ctx)
+	if s.audit != nil {
+		s.audit.Close()
+	}
+}
@@ -0,0 +1,117 @@
+package integration
+
+// End-to-end test for the audit log of tool invocations.
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+	"golang.org/x/tools/gopls/mcpbridge/test/testutil"
+)
+
+// TestAuditLog verifies that tool calls are recorded in the audit log with
+// redacted arguments, and that the log is rotated by size.
+func TestAuditLog(t *testing.T) {
+	goplsMcpPath := testutil.BuildGoplsMcp(t)
+	projectDir := testutil.CopyProjectTo(t, "simple")
+	dir := t.TempDir()
+	auditPath := filepath.Join(dir, "audit.jsonl")
+
+	configPath := filepath.Join(dir, "config.json")
+	config := fmt.Sprintf(`{
+  "audit": {
+    "path": %q,
+    "max_size_bytes": 1024,
+    "max_backups": 1,
+    "redact": [
+      {"tool": "go_search", "args": ["query"]},
+      {"pattern": "main\\.go"}
+    ]
+  }
+}`, auditPath)
+	if err := os.WriteFile(configPath, []byte(config), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	session, ctx, cleanup := testutil.StartMCPServerWithArgs(t, goplsMcpPath, "-workdir", projectDir, "-config", configPath)
+	defer cleanup()
+
+	call := func(t *testing.T, tool string, args map[string]any) {
+		t.Helper()
+		res, err := session.CallTool(ctx, &mcp.CallToolParams{Name: tool, Arguments: args})
+		if err != nil {
+			t.Fatalf("Failed to call tool %s: %v", tool, err)
+		}
+		testutil.ResultText(t, res, "")
+	}
+
+	type record struct {
+		Client      string         `json:"client"`
+		Tool        string         `json:"tool"`
+		Args        map[string]any `json:"args"`
+		ResultBytes int            `json:"result_bytes"`
+		Error       string         `json:"error"`
+	}
+	readRecords := func(t *testing.T, path string) []record {
+		t.Helper()
+		data, err := os.ReadFile(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var records []record
+		for line := range strings.SplitSeq(strings.TrimSpace(string(data)), "\n") {
+			var r record
+			if err := json.Unmarshal([]byte(line), &r); err != nil {
+				t.Fatalf("Invalid audit record %q: %v", line, err)
+			}
+			records = append(records, r)
+		}
+		return records
+	}
+
+	t.Run("Redaction", func(t *testing.T) {
+		call(t, "go_search", map[string]any{"query": "Hello"})
+		call(t, "go_read_file", map[string]any{"file": filepath.Join(projectDir, "main.go")})
+
+		records := readRecords(t, auditPath)
+		if len(records) != 2 {
+			t.Fatalf("Expected 2 audit records, got %d: %+v", len(records), records)
+		}
+		search, read := records[0], records[1]
+		if search.Tool != "go_search" || search.Args["query"] != "[REDACTED]" {
+			t.Errorf("Expected the go_search query to be redacted, got %+v", search)
+		}
+		// Stdio sessions have no session ID; HTTP sessions do.
+		if search.Client != "test-client" || search.ResultBytes == 0 {
+			t.Errorf("Expected client and result size in the record, got %+v", search)
+		}
+		file, _ := read.Args["file"].(string)
+		if read.Tool != "go_read_file" || !strings.HasSuffix(file, "[REDACTED]") || strings.Contains(file, "main.go") {
+			t.Errorf("Expected main.go to be redacted from the go_read_file path, got %+v", read)
+		}
+	})
+
+	t.Run("Rotation", func(t *testing.T) {
+		for range 10 {
+			call(t, "go_search", map[string]any{"query": "Hello"})
+		}
+		info, err := os.Stat(auditPath)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if info.Size() > 1024 {
+			t.Errorf("Expected the audit log to be rotated at 1024 bytes, got %d bytes", info.Size())
+		}
+		if len(readRecords(t, auditPath+".1")) == 0 {
+			t.Errorf("Expected records in the rotated audit log")
+		}
+		if _, err := os.Stat(auditPath + ".2"); !os.IsNotExist(err) {
+			t.Errorf("Expected only 1 backup to be kept, got %s.2 (err=%v)", auditPath, err)
+		}
+	})
+}
@@ -0,0 +1,109 @@
+package integration
+
+// End-to-end test for the call command.
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"golang.org/x/tools/gopls/mcpbridge/test/testutil"
+)
+
+// TestCallCommand verifies that "gopls-mcp call" invokes tools from the
+// command line, singly and in batches.
+func TestCallCommand(t *testing.T) {
+	goplsMcpPath := testutil.BuildGoplsMcp(t)
+	projectDir := testutil.CopyProjectTo(t, "simple")
+
+	// run runs the call command and returns its stdout, stderr and exit code.
+	run := func(t *testing.T, stdin string, args ...string) (string, string, int) {
+		t.Helper()
+		cmd := exec.Command(goplsMcpPath, append([]string{"call", "-workdir", projectDir}, args...)...)
+		cmd.Stdin = strings.NewReader(stdin)
+		var stdout, stderr bytes.Buffer
+		cmd.Stdout, cmd.Stderr = &stdout, &stderr
+		err := cmd.Run()
+		var exitErr *exec.ExitError
+		if errors.As(err, &exitErr) {
+			return stdout.String(), stderr.String(), exitErr.ExitCode()
+		} else if err != nil {
+			t.Fatalf("Failed to run call: %v", err)
+		}
+		return stdout.String(), stderr.String(), 0
+	}
+
+	t.Run("Text", func(t *testing.T) {
+		stdout, stderr, code := run(t, "", "go_search", "--arg", "query=Hello")
+		if code != 0 {
+			t.Fatalf("Expected exit code 0, got %d: %s", code, stderr)
+		}
+		testutil.AssertStringContains(t, stdout, "Hello (function")
+	})
+
+	t.Run("JSON", func(t *testing.T) {
+		stdout, stderr, code := run(t, "", "go_search", "--arg", "query=Add", "--arg", "max_results=5", "--json")
+		if code != 0 {
+			t.Fatalf("Expected exit code 0, got %d: %s", code, stderr)
+		}
+		var result struct {
+			Symbols []struct {
+				Name string `json:"name"`
+			} `json:"symbols"`
+		}
+		if err := json.Unmarshal([]byte(stdout), &result); err != nil {
+			t.Fatalf("Expected structured JSON output, got %q: %v", stdout, err)
+		}
+		if len(result.Symbols) == 0 || result.Symbols[0].Name != "Add" {
+			t.Errorf("Expected Add in the symbols, got %+v", result.Symbols)
+		}
+	})
+
+	t.Run("UnknownTool", func(t *testing.T) {
+		_, stderr, code := run(t, "", "go_nope")
+		if code != 2 {
+			t.Errorf("Expected exit code 2 for an unknown tool, got %d", code)
+		}
+		testutil.AssertStringContains(t, stderr, "available tools:")
+	})
+
+	t.Run("ReadOnly", func(t *testing.T) {
+		stdin := `{"tool": "go_sync_document", "args": {"path": "` + filepath.Join(projectDir, "main.go") + `", "action": "open", "content": "package main\n"}}`
+		stdout, _, code := run(t, stdin, "-read-only", "-")
+		if code != 1 {
+			t.Errorf("Expected exit code 1 for a write tool under -read-only, got %d", code)
+		}
+		testutil.AssertStringContains(t, stdout, `unknown tool "go_sync_document"`)
+	})
+
+	t.Run("Batch", func(t *testing.T) {
+		stdin := `{"tool": "go_search", "args": {"query": "Person"}}
+# comments and blank lines are ignored
+
+{"tool": "go_read_file", "args": {"file": "/does/not/exist.go"}}
+`
+		stdout, _, code := run(t, stdin, "--json", "-")
+		if code != 1 {
+			t.Errorf("Expected exit code 1 when a call fails, got %d", code)
+		}
+		lines := strings.Split(strings.TrimSpace(stdout), "\n")
+		if len(lines) != 2 {
+			t.Fatalf("Expected one JSON line per call, got:\n%s", stdout)
+		}
+		var search, read struct {
+			Tool    string `json:"tool"`
+			IsError bool   `json:"is_error"`
+			Result  any    `json:"result"`
+		}
+		if err := json.Unmarshal([]byte(lines[0]), &search); err != nil || search.Tool != "go_search" || search.IsError || search.Result == nil {
+			t.Errorf("Unexpected go_search line %q (err=%v)", lines[0], err)
+		}
+		if err := json.Unmarshal([]byte(lines[1]), &read); err != nil || read.Tool != "go_read_file" || !read.IsError {
+			t.Errorf("Unexpected go_read_file line %q (err=%v)", lines[1], err)
+		}
+	})
+}
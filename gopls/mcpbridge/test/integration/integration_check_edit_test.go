@@ -0,0 +1,198 @@
+package integration
+
+// End-to-end test for go_check_edit functionality.
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+	"golang.org/x/tools/gopls/mcpbridge/test/testutil"
+)
+
+// TestGoCheckEditE2E verifies that go_check_edit type-checks proposed changes
+// without writing them to disk or affecting later tool calls.
+func TestGoCheckEditE2E(t *testing.T) {
+	projectDir := t.TempDir()
+
+	goModContent := `module example.com/checkedit
+
+go 1.21
+`
+	if err := os.WriteFile(filepath.Join(projectDir, "go.mod"), []byte(goModContent), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	mainPath := filepath.Join(projectDir, "main.go")
+	mainCode := `package main
+
+func Add(a, b int) int {
+	return a + b
+}
+
+func main() {
+	_ = Add(1, 2)
+}
+`
+	if err := os.WriteFile(mainPath, []byte(mainCode), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	t.Run("EditIntroducesTypeError", func(t *testing.T) {
+		tool := "go_check_edit"
+		res, err := globalSession.CallTool(globalCtx, &mcp.CallToolParams{Name: tool, Arguments: map[string]any{
+			"Cwd": projectDir,
+			"files": []map[string]any{{
+				"path": mainPath,
+				"edits": []map[string]any{{
+					"old_text": "_ = Add(1, 2)",
+					"new_text": `_ = Add(1, "two")`,
+				}},
+			}},
+		}})
+		if err != nil {
+			t.Fatalf("Failed to call tool %s: %v", tool, err)
+		}
+
+		content := testutil.ResultText(t, res, testutil.GoldenCheckEditTypeError)
+		t.Logf("Check edit result:\n%s", content)
+
+		if !strings.Contains(content, "NOT written to disk") {
+			t.Errorf("Expected summary to state that changes were not written, got: %s", content)
+		}
+		if !strings.Contains(content, `Add(1, "two")`) {
+			t.Errorf("Expected diagnostic snippet from the proposed content, got: %s", content)
+		}
+
+		// The file on disk must be untouched.
+		onDisk, err := os.ReadFile(mainPath)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(onDisk) != mainCode {
+			t.Errorf("go_check_edit modified the file on disk:\n%s", onDisk)
+		}
+	})
+
+	t.Run("WorkspaceUnaffectedAfterCheck", func(t *testing.T) {
+		// The speculative error must not leak into the real snapshot.
+		res, err := globalSession.CallTool(globalCtx, &mcp.CallToolParams{Name: "go_build_check", Arguments: map[string]any{
+			"Cwd": projectDir,
+		}})
+		if err != nil {
+			t.Fatalf("Failed to call tool go_build_check: %v", err)
+		}
+		content := testutil.ResultText(t, res, "")
+		if !strings.Contains(content, "No issues found") {
+			t.Errorf("Expected clean workspace after go_check_edit, got: %s", content)
+		}
+	})
+
+	t.Run("NewFileWithCallerChange", func(t *testing.T) {
+		// Adding a new file and using it from an existing one must type-check.
+		helperPath := filepath.Join(projectDir, "helper.go")
+		helperCode := `package main
+
+func Double(x int) int {
+	return Add(x, x)
+}
+`
+		tool := "go_check_edit"
+		res, err := globalSession.CallTool(globalCtx, &mcp.CallToolParams{Name: tool, Arguments: map[string]any{
+			"Cwd": projectDir,
+			"files": []map[string]any{
+				{"path": helperPath, "content": helperCode},
+				{
+					"path": mainPath,
+					"edits": []map[string]any{{
+						"old_text": "_ = Add(1, 2)",
+						"new_text": "_ = Double(2)",
+					}},
+				},
+			},
+		}})
+		if err != nil {
+			t.Fatalf("Failed to call tool %s: %v", tool, err)
+		}
+
+		content := testutil.ResultText(t, res, testutil.GoldenCheckEditNewFile)
+		t.Logf("Check edit result:\n%s", content)
+
+		if !strings.Contains(content, "No issues found") {
+			t.Errorf("Expected new file to type-check, got: %s", content)
+		}
+		if _, err := os.Stat(helperPath); !os.IsNotExist(err) {
+			t.Errorf("Expected %s not to be created on disk", helperPath)
+		}
+	})
+
+	t.Run("ReferencesInEditedWorkspace", func(t *testing.T) {
+		// The references include the calls of a proposed new file.
+		helperPath := filepath.Join(projectDir, "helper.go")
+		tool := "go_check_edit"
+		res, err := globalSession.CallTool(globalCtx, &mcp.CallToolParams{Name: tool, Arguments: map[string]any{
+			"Cwd": projectDir,
+			"files": []map[string]any{
+				{"path": helperPath, "content": "package main\n\nfunc Double(x int) int {\n\treturn Add(x, x) + Add(0, 0)\n}\n"},
+			},
+			"references": map[string]any{
+				"symbol_name":  "Add",
+				"context_file": mainPath,
+				"kind":         "function",
+			},
+		}})
+		if err != nil {
+			t.Fatalf("Failed to call tool %s: %v", tool, err)
+		}
+
+		content := testutil.ResultText(t, res, "")
+		t.Logf("Check edit result:\n%s", content)
+
+		if !strings.Contains(content, `Found 3 reference(s) to "Add" with the edits applied`) {
+			t.Errorf("Expected the references of the edited workspace, got: %s", content)
+		}
+		if !strings.Contains(content, helperPath+":4:9 (call)") {
+			t.Errorf("Expected a call in the proposed file, got: %s", content)
+		}
+	})
+
+	t.Run("AmbiguousEditRejected", func(t *testing.T) {
+		tool := "go_check_edit"
+		res, err := globalSession.CallTool(globalCtx, &mcp.CallToolParams{Name: tool, Arguments: map[string]any{
+			"Cwd": projectDir,
+			"files": []map[string]any{{
+				"path": mainPath,
+				"edits": []map[string]any{{
+					"old_text": "int",
+					"new_text": "int64",
+				}},
+			}},
+		}})
+		if err != nil {
+			t.Fatalf("Failed to call tool %s: %v", tool, err)
+		}
+		if !res.IsError {
+			t.Fatalf("Expected an error for a non-unique old_text, got: %s", testutil.ResultText(t, res, ""))
+		}
+	})
+
+	t.Run("DuplicatePathRejected", func(t *testing.T) {
+		tool := "go_check_edit"
+		res, err := globalSession.CallTool(globalCtx, &mcp.CallToolParams{Name: tool, Arguments: map[string]any{
+			"Cwd": projectDir,
+			"files": []map[string]any{
+				{"path": mainPath, "content": mainCode},
+				{"path": mainPath, "content": "package main\n\nfunc main() { undefined() }\n"},
+			},
+		}})
+		if err != nil {
+			t.Fatalf("Failed to call tool %s: %v", tool, err)
+		}
+		content := testutil.ResultText(t, res, "")
+		if !res.IsError || !strings.Contains(content, "duplicate path") {
+			t.Errorf("Expected an error for a duplicate path, got: %s", content)
+		}
+	})
+}
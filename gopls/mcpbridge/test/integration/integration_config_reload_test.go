@@ -0,0 +1,216 @@
+package integration
+
+// End-to-end test for project-local config discovery, environment
+// overrides and hot reload.
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+	"golang.org/x/tools/gopls/mcpbridge/test/testutil"
+)
+
+// viewBuildFlags returns the build flags reported by go_server_status.
+func viewBuildFlags(t *testing.T, session *mcp.ClientSession) string {
+	t.Helper()
+	res, err := session.CallTool(t.Context(), &mcp.CallToolParams{Name: "go_server_status", Arguments: map[string]any{}})
+	if err != nil {
+		t.Fatalf("Failed to call go_server_status: %v", err)
+	}
+	for line := range strings.SplitSeq(testutil.ResultText(t, res, ""), "\n") {
+		if flags, ok := strings.CutPrefix(strings.TrimSpace(line), "Build flags:"); ok {
+			return strings.TrimSpace(flags)
+		}
+	}
+	return ""
+}
+
+// TestConfigDiscoveryAndReload verifies that a trusted .gopls-mcp.json at
+// the workspace root is loaded, and that changing it applies the new gopls
+// options to the views without restarting the server.
+func TestConfigDiscoveryAndReload(t *testing.T) {
+	goplsMcpPath := testutil.BuildGoplsMcp(t)
+	projectDir := testutil.CopyProjectTo(t, "simple")
+	configPath := filepath.Join(projectDir, ".gopls-mcp.json")
+	writeConfig := func(t *testing.T, config string) {
+		t.Helper()
+		if err := os.WriteFile(configPath, []byte(config), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	writeConfig(t, `{"gopls": {"buildFlags": ["-tags=special"]}}`)
+
+	session, _, cleanup := testutil.StartMCPServerWithArgs(t, goplsMcpPath, "-workdir", projectDir, "-trust-project-config")
+	defer cleanup()
+
+	t.Run("Discovery", func(t *testing.T) {
+		if flags := viewBuildFlags(t, session); flags != "-tags=special" {
+			t.Errorf("Expected the build flags of .gopls-mcp.json, got %q", flags)
+		}
+	})
+
+	t.Run("Reload", func(t *testing.T) {
+		writeConfig(t, `{"gopls": {"buildFlags": ["-tags=other"]}}`)
+		if !waitFor(30*time.Second, func() bool { return viewBuildFlags(t, session) == "-tags=other" }) {
+			t.Fatalf("Expected the reloaded build flags, got %q", viewBuildFlags(t, session))
+		}
+
+		writeConfig(t, `{"gopls": {}}`)
+		if !waitFor(30*time.Second, func() bool { return viewBuildFlags(t, session) == "" }) {
+			t.Fatalf("Expected no build flags once removed, got %q", viewBuildFlags(t, session))
+		}
+	})
+
+	t.Run("InvalidConfigKeepsCurrent", func(t *testing.T) {
+		writeConfig(t, `{"gopls": {"buildFlags": ["-tags=special"]}}`)
+		if !waitFor(30*time.Second, func() bool { return viewBuildFlags(t, session) == "-tags=special" }) {
+			t.Fatalf("Expected the reloaded build flags, got %q", viewBuildFlags(t, session))
+		}
+		writeConfig(t, `{invalid json`)
+		time.Sleep(2 * time.Second) // let the watcher process the change
+		if flags := viewBuildFlags(t, session); flags != "-tags=special" {
+			t.Errorf("Expected an invalid config to leave the gopls options unchanged, got %q", flags)
+		}
+	})
+}
+
+// TestUntrustedProjectConfig verifies that, without -trust-project-config,
+// the project config may not set the gopls options passed to the go
+// command, while its other settings apply.
+func TestUntrustedProjectConfig(t *testing.T) {
+	goplsMcpPath := testutil.BuildGoplsMcp(t)
+	projectDir := testutil.CopyProjectTo(t, "simple")
+	config := `{"gopls": {"buildFlags": ["-toolexec=/bin/false"]}, "tools": {"disabled": ["refactoring"]}}`
+	if err := os.WriteFile(filepath.Join(projectDir, ".gopls-mcp.json"), []byte(config), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	session, _, cleanup := testutil.StartMCPServerWithArgs(t, goplsMcpPath, "-workdir", projectDir)
+	defer cleanup()
+
+	if flags := viewBuildFlags(t, session); flags != "" {
+		t.Errorf("Expected the build flags of an untrusted project config to be ignored, got %q", flags)
+	}
+	if tools := listToolNames(t, session); tools["go_dryrun_rename_symbol"] {
+		t.Error("Expected go_dryrun_rename_symbol to be disabled by the project config")
+	}
+}
+
+// TestConfigModComment verifies that the gopls-mcp comment section of
+// go.mod is loaded when there is no .gopls-mcp.json, and reloaded when
+// go.mod changes.
+func TestConfigModComment(t *testing.T) {
+	goplsMcpPath := testutil.BuildGoplsMcp(t)
+	projectDir := testutil.CopyProjectTo(t, "simple")
+	modPath := filepath.Join(projectDir, "go.mod")
+	mod, err := os.ReadFile(modPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	writeMod := func(t *testing.T, comment string) {
+		t.Helper()
+		if err := os.WriteFile(modPath, append([]byte(comment), mod...), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	writeMod(t, "// gopls-mcp: {\"gopls\": {\"buildFlags\":\n// gopls-mcp: [\"-tags=special\"]}}\n")
+
+	session, _, cleanup := testutil.StartMCPServerWithArgs(t, goplsMcpPath, "-workdir", projectDir, "-trust-project-config")
+	defer cleanup()
+
+	if flags := viewBuildFlags(t, session); flags != "-tags=special" {
+		t.Errorf("Expected the build flags of the go.mod comments, got %q", flags)
+	}
+	writeMod(t, "// gopls-mcp: {\"gopls\": {\"buildFlags\": [\"-tags=other\"]}}\n")
+	if !waitFor(30*time.Second, func() bool { return viewBuildFlags(t, session) == "-tags=other" }) {
+		t.Fatalf("Expected the reloaded build flags, got %q", viewBuildFlags(t, session))
+	}
+}
+
+// TestConfigModEditReachesGopls verifies that edits of go.mod, which is
+// watched as a configuration file, still reach gopls.
+func TestConfigModEditReachesGopls(t *testing.T) {
+	goplsMcpPath := testutil.BuildGoplsMcp(t)
+	projectDir := testutil.CopyProjectTo(t, "simple")
+	depDir := t.TempDir()
+	for name, content := range map[string]string{
+		filepath.Join(depDir, "go.mod"):      "module example.com/dep\n\ngo 1.21\n",
+		filepath.Join(depDir, "dep.go"):      "package dep\n\nfunc Hello() string { return \"hello\" }\n",
+		filepath.Join(projectDir, "uses.go"): "package main\n\nimport \"example.com/dep\"\n\nvar _ = dep.Hello\n",
+	} {
+		if err := os.WriteFile(name, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	session, _, cleanup := testutil.StartMCPServerWithArgs(t, goplsMcpPath, "-workdir", projectDir)
+	defer cleanup()
+
+	buildCheck := func() string {
+		res, err := session.CallTool(t.Context(), &mcp.CallToolParams{Name: "go_build_check", Arguments: map[string]any{}})
+		if err != nil {
+			t.Fatalf("Failed to call go_build_check: %v", err)
+		}
+		return testutil.ResultText(t, res, "")
+	}
+	if content := buildCheck(); !strings.Contains(content, "example.com/dep") {
+		t.Fatalf("Expected an error for the missing module example.com/dep, got:\n%s", content)
+	}
+
+	modPath := filepath.Join(projectDir, "go.mod")
+	mod, err := os.ReadFile(modPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	mod = fmt.Appendf(mod, "\nrequire example.com/dep v0.0.0\n\nreplace example.com/dep => %s\n", depDir)
+	if err := os.WriteFile(modPath, mod, 0644); err != nil {
+		t.Fatal(err)
+	}
+	if !waitFor(30*time.Second, func() bool { return !strings.Contains(buildCheck(), "example.com/dep") }) {
+		t.Fatalf("Expected the go.mod edit to resolve the missing module, got:\n%s", buildCheck())
+	}
+}
+
+// TestConfigEnvOverrides verifies that environment variables override the
+// configuration file.
+func TestConfigEnvOverrides(t *testing.T) {
+	goplsMcpPath := testutil.BuildGoplsMcp(t)
+	projectDir := testutil.CopyProjectTo(t, "simple")
+	configPath := filepath.Join(t.TempDir(), "config.json")
+	if err := os.WriteFile(configPath, []byte(`{"gopls": {"buildFlags": ["-tags=file"]}}`), 0644); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("GOPLS_MCP_CONFIG", configPath)
+	t.Setenv("GOPLS_MCP_GOPLS", `{"buildFlags": ["-tags=env"]}`)
+	t.Setenv("GOPLS_MCP_DISABLED_TOOLS", "refactoring")
+
+	session, _, cleanup := testutil.StartMCPServerWithArgs(t, goplsMcpPath, "-workdir", projectDir)
+	defer cleanup()
+
+	if flags := viewBuildFlags(t, session); flags != "-tags=env" {
+		t.Errorf("Expected the build flags of GOPLS_MCP_GOPLS, got %q", flags)
+	}
+	if tools := listToolNames(t, session); tools["go_dryrun_rename_symbol"] {
+		t.Error("Expected go_dryrun_rename_symbol to be disabled by GOPLS_MCP_DISABLED_TOOLS")
+	}
+}
+
+// waitFor polls cond until it holds or the timeout expires, and reports
+// whether it held.
+func waitFor(timeout time.Duration, cond func() bool) bool {
+	deadline := time.Now().Add(timeout)
+	for {
+		if cond() {
+			return true
+		}
+		if time.Now().After(deadline) {
+			return false
+		}
+		time.Sleep(500 * time.Millisecond)
+	}
+}
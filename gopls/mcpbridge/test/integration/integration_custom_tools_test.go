@@ -0,0 +1,187 @@
+package integration
+
+// End-to-end test for the embedding API and custom tools.
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+	"golang.org/x/tools/gopls/mcpbridge/api"
+	"golang.org/x/tools/gopls/mcpbridge/core"
+	"golang.org/x/tools/gopls/mcpbridge/pkg"
+	"golang.org/x/tools/gopls/mcpbridge/test/testutil"
+)
+
+// symbolTypeParams is the input of the custom symbol type tool.
+type symbolTypeParams struct {
+	Locator api.SymbolLocator `json:"locator"`
+}
+
+// symbolTypeResult is the output of the custom symbol type tool.
+type symbolTypeResult struct {
+	Type       string `json:"type"`
+	Line       int    `json:"line"`
+	Package    string `json:"package"`
+	References int    `json:"references"`
+}
+
+// symbolTypeTool reports the type, package and number of references of a
+// symbol, using the handler's snapshot.
+var symbolTypeTool = core.GenericTool[symbolTypeParams, *symbolTypeResult]{
+	Name:        "acme_symbol_type",
+	Description: "Report the type of a symbol.",
+	Doc:         "Reports the type of the symbol identified by a locator.",
+	Category:    "acme",
+	Handler: func(ctx context.Context, h *core.Handler, req *mcp.CallToolRequest, input symbolTypeParams) (*mcp.CallToolResult, *symbolTypeResult, error) {
+		snapshot, release, err := h.Snapshot(filepath.Dir(input.Locator.ContextFile))
+		if err != nil {
+			return nil, nil, err
+		}
+		defer release()
+		sym, err := snapshot.ResolveSymbol(ctx, input.Locator)
+		if err != nil {
+			return nil, nil, err
+		}
+		pkg, err := snapshot.Package(ctx, input.Locator.ContextFile)
+		if err != nil {
+			return nil, nil, err
+		}
+		refs, err := snapshot.References(ctx, input.Locator)
+		if err != nil {
+			return nil, nil, err
+		}
+		result := &symbolTypeResult{Type: sym.Object.Type().String(), Line: sym.Position.Line, Package: pkg.PkgPath, References: len(refs)}
+		summary := fmt.Sprintf("%s has type %s (line %d of package %s, %d references)", input.Locator.SymbolName, result.Type, result.Line, result.Package, result.References)
+		return &mcp.CallToolResult{Content: []mcp.Content{&mcp.TextContent{Text: summary}}}, result, nil
+	},
+}
+
+// bigOutputTool returns a response larger than max_response_bytes.
+var bigOutputTool = core.GenericTool[struct{}, any]{
+	Name:        "acme_big_output",
+	Description: "Return a large response.",
+	Doc:         "Returns a large response.",
+	Category:    "acme",
+	Handler: func(ctx context.Context, h *core.Handler, req *mcp.CallToolRequest, input struct{}) (*mcp.CallToolResult, any, error) {
+		text := strings.Repeat("0123456789\n", 1000)
+		return &mcp.CallToolResult{Content: []mcp.Content{&mcp.TextContent{Text: text}}}, nil, nil
+	},
+}
+
+// TestCustomTools verifies that a server created with pkg.NewServer serves
+// custom tools next to the built-in ones.
+func TestCustomTools(t *testing.T) {
+	projectDir := testutil.CopyProjectTo(t, "simple")
+	ctx := t.Context()
+
+	config := core.DefaultConfig()
+	config.MaxResponseBytes = 2000
+	srv, err := pkg.NewServer(ctx, pkg.Options{
+		Workdir: projectDir,
+		Config:  config,
+		Tools:   []core.Tool{symbolTypeTool, bigOutputTool},
+	})
+	if err != nil {
+		t.Fatalf("Failed to create server: %v", err)
+	}
+	defer srv.Close(context.Background())
+	<-srv.Ready()
+
+	serverTransport, clientTransport := mcp.NewInMemoryTransports()
+	go srv.Run(ctx, serverTransport)
+	client := mcp.NewClient(&mcp.Implementation{Name: "test-client", Version: "v0.0.1"}, nil)
+	session, err := client.Connect(ctx, clientTransport, nil)
+	if err != nil {
+		t.Fatalf("Failed to connect: %v", err)
+	}
+	defer session.Close()
+
+	t.Run("CustomTool", func(t *testing.T) {
+		res, err := session.CallTool(ctx, &mcp.CallToolParams{
+			Name: "acme_symbol_type",
+			Arguments: map[string]any{"locator": map[string]any{
+				"symbol_name":  "Add",
+				"context_file": filepath.Join(projectDir, "main.go"),
+			}},
+		})
+		if err != nil {
+			t.Fatalf("Failed to call custom tool: %v", err)
+		}
+		content := testutil.ResultText(t, res, "")
+		testutil.AssertStringContains(t, content, "Add has type func(a int, b int) int (line 11 of package example.com/simple, 1 references)")
+	})
+
+	t.Run("BuiltinTool", func(t *testing.T) {
+		res, err := session.CallTool(ctx, &mcp.CallToolParams{Name: "go_search", Arguments: map[string]any{"query": "Hello"}})
+		if err != nil {
+			t.Fatalf("Failed to call go_search: %v", err)
+		}
+		testutil.AssertStringContains(t, testutil.ResultText(t, res, ""), "Hello")
+	})
+
+	t.Run("ResponseLimit", func(t *testing.T) {
+		res, err := session.CallTool(ctx, &mcp.CallToolParams{Name: "acme_big_output"})
+		if err != nil {
+			t.Fatalf("Failed to call custom tool: %v", err)
+		}
+		content := testutil.ResultText(t, res, "")
+		if len(content) > 2500 {
+			t.Errorf("Expected the custom tool response to be limited to about 2000 bytes, got %d", len(content))
+		}
+	})
+
+	t.Run("ListTools", func(t *testing.T) {
+		res, err := session.CallTool(ctx, &mcp.CallToolParams{Name: "go_list_tools", Arguments: map[string]any{"category_filter": "acme"}})
+		if err != nil {
+			t.Fatalf("Failed to call go_list_tools: %v", err)
+		}
+		content := testutil.ResultText(t, res, "")
+		testutil.AssertStringContains(t, content, "acme_symbol_type")
+		testutil.AssertStringContains(t, content, "acme_big_output")
+		testutil.AssertStringNotContains(t, content, "go_search")
+	})
+}
+
+// TestNewServerIgnoresRegisteredTools verifies that servers created with
+// pkg.NewServer are built from their options only, and do not serve the
+// tools added for Execute by pkg.RegisterTools.
+func TestNewServerIgnoresRegisteredTools(t *testing.T) {
+	projectDir := testutil.CopyProjectTo(t, "simple")
+	ctx := t.Context()
+
+	pkg.RegisterTools(core.GenericTool[struct{}, any]{
+		Name:        "acme_registered",
+		Description: "A tool registered for Execute.",
+		Handler: func(ctx context.Context, h *core.Handler, req *mcp.CallToolRequest, input struct{}) (*mcp.CallToolResult, any, error) {
+			return &mcp.CallToolResult{}, nil, nil
+		},
+	})
+	for _, tools := range [][]core.Tool{{bigOutputTool}, nil} {
+		srv, err := pkg.NewServer(ctx, pkg.Options{Workdir: projectDir, Tools: tools})
+		if err != nil {
+			t.Fatalf("Failed to create server: %v", err)
+		}
+		defer srv.Close(context.Background())
+
+		serverTransport, clientTransport := mcp.NewInMemoryTransports()
+		go srv.Run(ctx, serverTransport)
+		client := mcp.NewClient(&mcp.Implementation{Name: "test-client", Version: "v0.0.1"}, nil)
+		session, err := client.Connect(ctx, clientTransport, nil)
+		if err != nil {
+			t.Fatalf("Failed to connect: %v", err)
+		}
+		defer session.Close()
+
+		names := listToolNames(t, session)
+		if names["acme_registered"] {
+			t.Errorf("Expected NewServer not to serve the tools of RegisterTools")
+		}
+		if got, want := names["acme_big_output"], len(tools) > 0; got != want {
+			t.Errorf("Server with tools %v serves acme_big_output: %v, want %v", tools, got, want)
+		}
+	}
+}
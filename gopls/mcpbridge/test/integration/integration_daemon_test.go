@@ -0,0 +1,210 @@
+package integration
+
+// End-to-end test for the gopls-mcp daemon mode (-listen / -remote).
+
+import (
+	"context"
+	"net"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+	"golang.org/x/tools/gopls/internal/protocol"
+	"golang.org/x/tools/gopls/mcpbridge/test/testutil"
+)
+
+// TestDaemonAttach verifies that several gopls-mcp processes started with
+// -remote attach to one daemon and are served by it.
+func TestDaemonAttach(t *testing.T) {
+	goplsMcpPath := testutil.BuildGoplsMcp(t)
+	projectDir := testutil.CopyProjectTo(t, "simple")
+
+	socket := filepath.Join(t.TempDir(), "daemon.sock")
+	daemon := exec.Command(goplsMcpPath, "-listen", "unix;"+socket)
+	if err := daemon.Start(); err != nil {
+		t.Fatalf("Failed to start daemon: %v", err)
+	}
+	t.Cleanup(func() {
+		daemon.Process.Signal(syscall.SIGTERM)
+		daemon.Wait()
+	})
+
+	// Wait for the daemon's MCP socket.
+	deadline := time.Now().Add(30 * time.Second)
+	for {
+		if _, err := os.Stat(socket + ".mcp"); err == nil {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("Daemon did not create its MCP socket")
+		}
+		time.Sleep(100 * time.Millisecond)
+	}
+
+	for _, client := range []string{"FirstClient", "SecondClient"} {
+		t.Run(client, func(t *testing.T) {
+			session, ctx, cleanup := testutil.StartMCPServerWithArgs(t, goplsMcpPath, "-remote", "unix;"+socket, "-workdir", projectDir)
+			defer cleanup()
+
+			res, err := session.CallTool(ctx, &mcp.CallToolParams{Name: "go_list_modules", Arguments: map[string]any{
+				"direct_only": true,
+			}})
+			if err != nil {
+				t.Fatalf("Failed to call tool go_list_modules: %v", err)
+			}
+			content := testutil.ResultText(t, res, "")
+			if !strings.Contains(content, "example.com/simple") {
+				t.Errorf("Expected the daemon to serve the attached project, got: %s", content)
+			}
+		})
+	}
+
+	t.Run("EditorBufferVisibleToAgent", func(t *testing.T) {
+		editor, conn := connectEditor(t, context.Background(), socket, projectDir)
+		defer conn.Close()
+
+		session, ctx, cleanup := testutil.StartMCPServerWithArgs(t, goplsMcpPath, "-remote", "unix;"+socket, "-workdir", projectDir)
+		defer cleanup()
+		mainPath := filepath.Join(projectDir, "main.go")
+		readMain := func() string {
+			res, err := session.CallTool(ctx, &mcp.CallToolParams{Name: "go_read_file", Arguments: map[string]any{"file": mainPath}})
+			if err != nil {
+				t.Fatalf("Failed to call tool go_read_file: %v", err)
+			}
+			return testutil.ResultText(t, res, "")
+		}
+
+		content, err := os.ReadFile(mainPath)
+		if err != nil {
+			t.Fatal(err)
+		}
+		const marker = "// unsaved edit from the editor"
+		if err := editor.DidOpen(ctx, &protocol.DidOpenTextDocumentParams{
+			TextDocument: protocol.TextDocumentItem{
+				URI:        protocol.URIFromPath(mainPath),
+				LanguageID: "go",
+				Version:    1,
+				Text:       string(content) + marker + "\n",
+			},
+		}); err != nil {
+			t.Fatalf("LSP didOpen failed: %v", err)
+		}
+		// didOpen is a notification, so it may not have been processed yet.
+		if !waitFor(10*time.Second, func() bool { return strings.Contains(readMain(), marker) }) {
+			t.Fatalf("Expected the agent to see the editor's unsaved buffer, got: %s", readMain())
+		}
+
+		// The agent keeps working after the editor exits, on the file on disk.
+		if err := editor.Shutdown(ctx); err != nil {
+			t.Fatalf("LSP shutdown failed: %v", err)
+		}
+		editor.Exit(ctx)
+		conn.Close()
+		if !waitFor(10*time.Second, func() bool { return !strings.Contains(readMain(), marker) }) {
+			t.Errorf("Expected the editor's buffer to be closed when it exits, got: %s", readMain())
+		}
+		res, err := session.CallTool(ctx, &mcp.CallToolParams{Name: "go_list_modules", Arguments: map[string]any{"direct_only": true}})
+		if err != nil {
+			t.Fatalf("Failed to call tool go_list_modules: %v", err)
+		}
+		if got := testutil.ResultText(t, res, ""); !strings.Contains(got, "example.com/simple") {
+			t.Errorf("Expected the agent to keep working after the editor exits, got: %s", got)
+		}
+	})
+
+	t.Run("GoplsDaemonRejected", func(t *testing.T) {
+		// A daemon that serves LSP only, like gopls.
+		goplsSocket := filepath.Join(t.TempDir(), "gopls.sock")
+		listener, err := net.Listen("unix", goplsSocket)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer listener.Close()
+		go func() {
+			for {
+				conn, err := listener.Accept()
+				if err != nil {
+					return
+				}
+				conn.Close()
+			}
+		}()
+
+		out, err := exec.Command(goplsMcpPath, "-remote", "unix;"+goplsSocket, "-workdir", projectDir).CombinedOutput()
+		if err == nil {
+			t.Fatalf("Expected -remote with a gopls daemon to fail, got: %s", out)
+		}
+		if !strings.Contains(string(out), "not a gopls-mcp daemon") {
+			t.Errorf("Expected an explanatory error, got: %s", out)
+		}
+	})
+
+	t.Run("NonUnixAddressRejected", func(t *testing.T) {
+		out, err := exec.Command(goplsMcpPath, "-remote", "localhost:0", "-workdir", projectDir).CombinedOutput()
+		if err == nil {
+			t.Fatalf("Expected -remote with a tcp address to fail, got: %s", out)
+		}
+		if !strings.Contains(string(out), "unix socket") {
+			t.Errorf("Expected an explanatory error, got: %s", out)
+		}
+	})
+}
+
+// TestDaemonSharedSessionAndIdleTimeout verifies that concurrent clients
+// attached to the same project share one session, and that the daemon shuts
+// down once the last client is gone for the idle timeout.
+func TestDaemonSharedSessionAndIdleTimeout(t *testing.T) {
+	goplsMcpPath := testutil.BuildGoplsMcp(t)
+	projectDir := testutil.CopyProjectTo(t, "simple")
+
+	socket := filepath.Join(t.TempDir(), "daemon.sock")
+	daemon := exec.Command(goplsMcpPath, "-listen", "unix;"+socket, "-idle-timeout", "2s")
+	if err := daemon.Start(); err != nil {
+		t.Fatalf("Failed to start daemon: %v", err)
+	}
+	exited := make(chan error, 1)
+	go func() { exited <- daemon.Wait() }()
+	t.Cleanup(func() {
+		daemon.Process.Signal(syscall.SIGTERM)
+	})
+
+	first, ctx, closeFirst := testutil.StartMCPServerWithArgs(t, goplsMcpPath, "-remote", "unix;"+socket, "-workdir", projectDir)
+	second, _, closeSecond := testutil.StartMCPServerWithArgs(t, goplsMcpPath, "-remote", "unix;"+socket, "-workdir", projectDir)
+
+	// Connected clients keep the daemon alive past the idle timeout.
+	time.Sleep(3 * time.Second)
+
+	mainPath := filepath.Join(projectDir, "main.go")
+	const marker = "// pushed by the first client"
+	if _, err := first.CallTool(ctx, &mcp.CallToolParams{Name: "go_sync_document", Arguments: map[string]any{
+		"path":    mainPath,
+		"action":  "open",
+		"content": "package main\n\n" + marker + "\n\nfunc main() {}\n",
+	}}); err != nil {
+		t.Fatalf("Failed to call tool go_sync_document: %v", err)
+	}
+	res, err := second.CallTool(ctx, &mcp.CallToolParams{Name: "go_read_file", Arguments: map[string]any{"file": mainPath}})
+	if err != nil {
+		t.Fatalf("Failed to call tool go_read_file: %v", err)
+	}
+	if content := testutil.ResultText(t, res, ""); !strings.Contains(content, marker) {
+		t.Errorf("Expected the second client to share the first client's session, got: %s", content)
+	}
+
+	closeFirst()
+	closeSecond()
+
+	select {
+	case <-exited:
+	case <-time.After(30 * time.Second):
+		t.Fatalf("Daemon did not shut down after the idle timeout")
+	}
+	if _, err := os.Stat(socket + ".mcp"); !os.IsNotExist(err) {
+		t.Errorf("Expected the daemon to remove its MCP socket on shutdown")
+	}
+}
@@ -0,0 +1,185 @@
+package integration
+
+// End-to-end test for go_search qualified queries, members, filters and
+// dependencies.
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+	"golang.org/x/tools/gopls/mcpbridge/test/testutil"
+)
+
+// TestGoSearchFilters verifies that go_search finds methods, fields and
+// interface methods by qualified name, and applies its filters.
+func TestGoSearchFilters(t *testing.T) {
+	goplsMcpPath := testutil.BuildGoplsMcp(t)
+	projectDir := testutil.CopyProjectTo(t, "simple")
+
+	files := map[string]string{
+		"storage/store.go": `package storage
+
+// Closer closes.
+type Closer interface {
+	Close() error
+}
+
+// Store is a store.
+type Store struct {
+	path string
+}
+
+func (s *Store) Close() error { return nil }
+`,
+		"storage/store_test.go": `package storage
+
+type fakeStore struct{}
+
+func (fakeStore) Close() error { return nil }
+`,
+		"storage/cache/cache.go": `package cache
+
+type Cache struct{}
+
+func (c *Cache) Close() error { return nil }
+`,
+		"network/conn.go": `package network
+
+type Conn struct{}
+
+func (c *Conn) Close() error { return nil }
+`,
+	}
+	for name, content := range files {
+		path := filepath.Join(projectDir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	session, ctx, cleanup := testutil.StartMCPServerWithArgs(t, goplsMcpPath, "-workdir", projectDir)
+	defer cleanup()
+
+	search := func(t *testing.T, args map[string]any) *mcp.CallToolResult {
+		t.Helper()
+		if _, ok := args["max_results"]; !ok {
+			args["max_results"] = 50
+		}
+		res, err := session.CallTool(ctx, &mcp.CallToolParams{Name: "go_search", Arguments: args})
+		if err != nil {
+			t.Fatalf("Failed to call go_search: %v", err)
+		}
+		return res
+	}
+	searchText := func(t *testing.T, args map[string]any) string {
+		t.Helper()
+		return testutil.ResultText(t, search(t, args), "")
+	}
+
+	t.Run("QualifiedMembers", func(t *testing.T) {
+		testutil.AssertStringContains(t, searchText(t, map[string]any{"query": "Person.Greeting"}), "Person.Greeting (method in")
+		testutil.AssertStringContains(t, searchText(t, map[string]any{"query": "Person.Age"}), "Person.Age (field in")
+		testutil.AssertStringContains(t, searchText(t, map[string]any{"query": "Closer.Close"}), "Closer.Close (method in")
+		testutil.AssertStringContains(t, searchText(t, map[string]any{"query": "Store.path"}), "Store.path (field in")
+
+		// A package name qualifies the package's symbols.
+		content := searchText(t, map[string]any{"query": "cache.Cache"})
+		testutil.AssertStringContains(t, content, "Cache (struct in")
+		testutil.AssertStringNotContains(t, content, "Person")
+	})
+
+	t.Run("KindAndDirectory", func(t *testing.T) {
+		content := searchText(t, map[string]any{"query": "Close", "kinds": []string{"method"}, "package": "./storage/..."})
+		for _, want := range []string{"Store.Close", "Closer.Close", "Cache.Close", "fakeStore.Close"} {
+			testutil.AssertStringContains(t, content, want)
+		}
+		testutil.AssertStringNotContains(t, content, "Conn.Close")
+
+		// Without /..., only the directory itself.
+		content = searchText(t, map[string]any{"query": "Close", "kinds": []string{"method"}, "package": "./storage"})
+		testutil.AssertStringContains(t, content, "Store.Close")
+		testutil.AssertStringNotContains(t, content, "Cache.Close")
+
+		// Kinds filter out the other symbols.
+		content = searchText(t, map[string]any{"query": "Close", "kinds": []string{"interface"}})
+		testutil.AssertStringContains(t, content, "Closer (interface in")
+		testutil.AssertStringNotContains(t, content, "(method in")
+	})
+
+	t.Run("ImportPath", func(t *testing.T) {
+		content := searchText(t, map[string]any{"query": "Close", "kinds": []string{"method"}, "package": "example.com/simple/storage"})
+		testutil.AssertStringContains(t, content, "Store.Close")
+		testutil.AssertStringContains(t, content, "Cache.Close")
+		testutil.AssertStringNotContains(t, content, "Conn.Close")
+
+		res := search(t, map[string]any{"query": "Conn"})
+		var found bool
+		for _, sym := range structuredSymbols(t, res) {
+			if sym["name"] == "Conn" && sym["package_path"] == "example.com/simple/network" {
+				found = true
+			}
+		}
+		if !found {
+			t.Errorf("Expected Conn with package path example.com/simple/network, got:\n%s", testutil.ResultText(t, res, ""))
+		}
+	})
+
+	t.Run("ExportedOnly", func(t *testing.T) {
+		content := searchText(t, map[string]any{"query": "Close", "exported_only": true})
+		testutil.AssertStringContains(t, content, "Store.Close")
+		testutil.AssertStringNotContains(t, content, "fakeStore.Close")
+	})
+
+	t.Run("Tests", func(t *testing.T) {
+		content := searchText(t, map[string]any{"query": "Close", "tests": "exclude"})
+		testutil.AssertStringContains(t, content, "Store.Close")
+		testutil.AssertStringNotContains(t, content, "fakeStore")
+
+		content = searchText(t, map[string]any{"query": "Close", "tests": "only"})
+		testutil.AssertStringContains(t, content, "fakeStore.Close")
+		testutil.AssertStringNotContains(t, content, "Cache.Close")
+	})
+
+	t.Run("Dependencies", func(t *testing.T) {
+		testutil.AssertStringContains(t, searchText(t, map[string]any{"query": "fmt.Sprintf"}), "No symbols found")
+
+		content := searchText(t, map[string]any{"query": "fmt.Sprintf", "include_dependencies": true})
+		testutil.AssertStringContains(t, content, "Sprintf (function in")
+		testutil.AssertStringContains(t, content, filepath.Join("fmt", "print.go"))
+	})
+
+	t.Run("InvalidInput", func(t *testing.T) {
+		for _, args := range []map[string]any{
+			{"query": "Close", "kinds": []string{"procedure"}},
+			{"query": "Close", "tests": "sometimes"},
+			{"query": "a.b.c"},
+			{"query": "close the store"},
+		} {
+			if res := search(t, args); !res.IsError {
+				t.Errorf("Expected an error for %v, got:\n%s", args, testutil.ResultText(t, res, ""))
+			}
+		}
+	})
+}
+
+// structuredSymbols returns the symbols of the structured go_search result.
+func structuredSymbols(t *testing.T, res *mcp.CallToolResult) []map[string]any {
+	t.Helper()
+	result, ok := res.StructuredContent.(map[string]any)
+	if !ok {
+		t.Fatalf("Expected a structured result, got %T", res.StructuredContent)
+	}
+	var symbols []map[string]any
+	list, _ := result["symbols"].([]any)
+	for _, sym := range list {
+		if m, ok := sym.(map[string]any); ok {
+			symbols = append(symbols, m)
+		}
+	}
+	return symbols
+}
@@ -0,0 +1,295 @@
+package integration
+
+// End-to-end test for authentication, TLS and access control in HTTP mode.
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"net"
+	"net/http"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+	"golang.org/x/tools/gopls/mcpbridge/test/testutil"
+)
+
+// TestHTTPAuth verifies that HTTP mode enforces bearer tokens, per-token tool
+// allowlists and allowed origins, over TLS.
+func TestHTTPAuth(t *testing.T) {
+	goplsMcpPath := testutil.BuildGoplsMcp(t)
+	projectDir := testutil.CopyProjectTo(t, "simple")
+	dir := t.TempDir()
+
+	certFile, keyFile, certPool := writeSelfSignedCert(t, dir)
+
+	configPath := filepath.Join(dir, "config.json")
+	config := `{
+  "http": {
+    "tokens": [
+      {"name": "full", "token": "full-access"},
+      {"name": "restricted", "token": "restricted-access", "tools": ["go_list_modules"]}
+    ],
+    "allowed_origins": ["https://editor.example.com"]
+  }
+}`
+	if err := os.WriteFile(configPath, []byte(config), 0644); err != nil {
+		t.Fatal(err)
+	}
+	tokenFile := filepath.Join(dir, "tokens")
+	if err := os.WriteFile(tokenFile, []byte("# token file\nfrom-file go_list_modules,go_build_check\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	addr := freeAddr(t)
+	server := exec.Command(goplsMcpPath,
+		"-addr", addr,
+		"-workdir", projectDir,
+		"-config", configPath,
+		"-token-file", tokenFile,
+		"-tls-cert", certFile,
+		"-tls-key", keyFile,
+	)
+	if err := server.Start(); err != nil {
+		t.Fatalf("Failed to start server: %v", err)
+	}
+	t.Cleanup(func() {
+		server.Process.Signal(syscall.SIGTERM)
+		server.Wait()
+	})
+
+	endpoint := "https://" + addr
+	httpClient := &http.Client{Transport: &http.Transport{TLSClientConfig: &tls.Config{RootCAs: certPool}}}
+	waitForHTTP(t, httpClient, endpoint)
+
+	// post sends an initialize request with the given headers.
+	post := func(t *testing.T, header http.Header) *http.Response {
+		body := `{"jsonrpc":"2.0","id":1,"method":"initialize","params":{"protocolVersion":"2025-06-18","capabilities":{},"clientInfo":{"name":"test","version":"v0"}}}`
+		req, err := http.NewRequest(http.MethodPost, endpoint, strings.NewReader(body))
+		if err != nil {
+			t.Fatal(err)
+		}
+		req.Header = header
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("Accept", "application/json, text/event-stream")
+		resp, err := httpClient.Do(req)
+		if err != nil {
+			t.Fatalf("Request failed: %v", err)
+		}
+		resp.Body.Close()
+		return resp
+	}
+
+	t.Run("MissingToken", func(t *testing.T) {
+		if resp := post(t, http.Header{}); resp.StatusCode != http.StatusUnauthorized {
+			t.Errorf("Expected 401 without a token, got %s", resp.Status)
+		}
+	})
+
+	t.Run("InvalidToken", func(t *testing.T) {
+		if resp := post(t, http.Header{"Authorization": {"Bearer wrong"}}); resp.StatusCode != http.StatusUnauthorized {
+			t.Errorf("Expected 401 for an invalid token, got %s", resp.Status)
+		}
+	})
+
+	t.Run("DisallowedOrigin", func(t *testing.T) {
+		resp := post(t, http.Header{
+			"Authorization": {"Bearer full-access"},
+			"Origin":        {"https://evil.example.com"},
+		})
+		if resp.StatusCode != http.StatusForbidden {
+			t.Errorf("Expected 403 for a disallowed origin, got %s", resp.Status)
+		}
+	})
+
+	t.Run("AllowedOrigin", func(t *testing.T) {
+		resp := post(t, http.Header{
+			"Authorization": {"Bearer full-access"},
+			"Origin":        {"https://editor.example.com"},
+		})
+		if resp.StatusCode != http.StatusOK {
+			t.Errorf("Expected 200 for an allowed origin, got %s", resp.Status)
+		}
+		if got := resp.Header.Get("Access-Control-Allow-Origin"); got != "https://editor.example.com" {
+			t.Errorf("Expected CORS header for the allowed origin, got %q", got)
+		}
+	})
+
+	t.Run("MetricsRequireToken", func(t *testing.T) {
+		get := func(path, token string) int {
+			req, err := http.NewRequest(http.MethodGet, endpoint+path, nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if token != "" {
+				req.Header.Set("Authorization", "Bearer "+token)
+			}
+			resp, err := httpClient.Do(req)
+			if err != nil {
+				t.Fatalf("Request failed: %v", err)
+			}
+			resp.Body.Close()
+			return resp.StatusCode
+		}
+		for _, tc := range []struct {
+			path, token string
+			want        int
+		}{
+			{"/metrics", "", http.StatusUnauthorized},
+			{"/metrics", "wrong", http.StatusUnauthorized},
+			{"/metrics", "full-access", http.StatusOK},
+			{"/healthz", "", http.StatusOK},
+		} {
+			if got := get(tc.path, tc.token); got != tc.want {
+				t.Errorf("GET %s with token %q: got status %d, want %d", tc.path, tc.token, got, tc.want)
+			}
+		}
+	})
+
+	// connect connects an MCP client authenticating with the given token.
+	connect := func(t *testing.T, token string) *mcp.ClientSession {
+		client := mcp.NewClient(&mcp.Implementation{Name: "test-client", Version: "v0.0.1"}, nil)
+		session, err := client.Connect(t.Context(), &mcp.StreamableClientTransport{
+			Endpoint: endpoint,
+			HTTPClient: &http.Client{Transport: &bearerTransport{
+				token: token,
+				base:  httpClient.Transport,
+			}},
+		}, nil)
+		if err != nil {
+			t.Fatalf("Failed to connect: %v", err)
+		}
+		t.Cleanup(func() { session.Close() })
+		return session
+	}
+
+	t.Run("ListedTools", func(t *testing.T) {
+		if names := listToolNames(t, connect(t, "restricted-access")); len(names) != 1 || !names["go_list_modules"] {
+			t.Errorf("Expected the restricted token to list only go_list_modules, got %v", names)
+		}
+		if names := listToolNames(t, connect(t, "full-access")); !names["go_search"] || !names["go_build_check"] {
+			t.Errorf("Expected the full token to list every tool, got %v", names)
+		}
+	})
+
+	for _, tc := range []struct {
+		name       string
+		token      string
+		tool       string
+		args       map[string]any
+		wantDenied bool
+	}{
+		{"FullTokenAllowed", "full-access", "go_search", map[string]any{"query": "Hello"}, false},
+		{"RestrictedTokenAllowed", "restricted-access", "go_list_modules", map[string]any{"direct_only": true}, false},
+		{"RestrictedTokenDenied", "restricted-access", "go_build_check", nil, true},
+		{"TokenFileAllowed", "from-file", "go_build_check", nil, false},
+		{"TokenFileDenied", "from-file", "go_search", map[string]any{"query": "Hello"}, true},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			session := connect(t, tc.token)
+			res, err := session.CallTool(t.Context(), &mcp.CallToolParams{Name: tc.tool, Arguments: tc.args})
+			if err != nil {
+				t.Fatalf("Failed to call tool %s: %v", tc.tool, err)
+			}
+			content := testutil.ResultText(t, res, "")
+			denied := res.IsError && strings.Contains(content, "not allowed for this token")
+			if denied != tc.wantDenied {
+				t.Errorf("%s with token %q: denied = %v, want %v\n%s", tc.tool, tc.token, denied, tc.wantDenied, content)
+			}
+		})
+	}
+}
+
+// bearerTransport adds a bearer token to every request.
+type bearerTransport struct {
+	token string
+	base  http.RoundTripper
+}
+
+func (t *bearerTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	req = req.Clone(req.Context())
+	req.Header.Set("Authorization", "Bearer "+t.token)
+	return t.base.RoundTrip(req)
+}
+
+// freeAddr returns a currently unused loopback TCP address.
+func freeAddr(t *testing.T) string {
+	t.Helper()
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer l.Close()
+	return l.Addr().String()
+}
+
+// waitForHTTP waits until the server at endpoint accepts requests.
+func waitForHTTP(t *testing.T, client *http.Client, endpoint string) {
+	t.Helper()
+	deadline := time.Now().Add(30 * time.Second)
+	for {
+		resp, err := client.Get(endpoint)
+		if err == nil {
+			resp.Body.Close()
+			return
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("Server did not start: %v", err)
+		}
+		time.Sleep(100 * time.Millisecond)
+	}
+}
+
+// writeSelfSignedCert writes a self-signed certificate for 127.0.0.1 and its
+// key to dir, and returns their paths and a pool trusting the certificate.
+func writeSelfSignedCert(t *testing.T, dir string) (certFile, keyFile string, pool *x509.CertPool) {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: "gopls-mcp test"},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		IPAddresses:  []net.IP{net.ParseIP("127.0.0.1")},
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cert, err := x509.ParseCertificate(der)
+	if err != nil {
+		t.Fatal(err)
+	}
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	certFile = filepath.Join(dir, "cert.pem")
+	keyFile = filepath.Join(dir, "key.pem")
+	if err := os.WriteFile(certFile, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(keyFile, pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER}), 0600); err != nil {
+		t.Fatal(err)
+	}
+	pool = x509.NewCertPool()
+	pool.AddCert(cert)
+	return certFile, keyFile, pool
+}
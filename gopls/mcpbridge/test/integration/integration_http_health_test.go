@@ -0,0 +1,110 @@
+package integration
+
+// End-to-end test for health endpoints and graceful shutdown in HTTP mode.
+
+import (
+	"bytes"
+	"net/http"
+	"os/exec"
+	"strings"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+	"golang.org/x/tools/gopls/mcpbridge/test/testutil"
+)
+
+// TestHTTPHealthAndShutdown verifies the /healthz and /readyz endpoints and
+// that SIGTERM shuts the HTTP server down cleanly, releasing the workspace.
+func TestHTTPHealthAndShutdown(t *testing.T) {
+	goplsMcpPath := testutil.BuildGoplsMcp(t)
+	projectDir := testutil.CopyProjectTo(t, "simple")
+
+	addr := freeAddr(t)
+	var output bytes.Buffer
+	server := exec.Command(goplsMcpPath, "-addr", addr, "-workdir", projectDir, "-verbose")
+	server.Stdout = &output
+	server.Stderr = &output
+	if err := server.Start(); err != nil {
+		t.Fatalf("Failed to start server: %v", err)
+	}
+	exited := make(chan error, 1)
+	go func() { exited <- server.Wait() }()
+	t.Cleanup(func() { server.Process.Kill() })
+
+	endpoint := "http://" + addr
+	waitForHTTP(t, http.DefaultClient, endpoint+"/healthz")
+
+	t.Run("Healthz", func(t *testing.T) {
+		resp, err := http.Get(endpoint + "/healthz")
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp.Body.Close()
+		if resp.StatusCode != http.StatusOK {
+			t.Errorf("Expected /healthz to return 200, got %s", resp.Status)
+		}
+	})
+
+	t.Run("ReadyzAfterWorkspaceLoad", func(t *testing.T) {
+		deadline := time.Now().Add(60 * time.Second)
+		for {
+			resp, err := http.Get(endpoint + "/readyz")
+			if err != nil {
+				t.Fatal(err)
+			}
+			resp.Body.Close()
+			if resp.StatusCode == http.StatusOK {
+				return
+			}
+			if resp.StatusCode != http.StatusServiceUnavailable {
+				t.Fatalf("Expected /readyz to return 200 or 503, got %s", resp.Status)
+			}
+			if time.Now().After(deadline) {
+				t.Fatalf("Server did not become ready")
+			}
+			time.Sleep(100 * time.Millisecond)
+		}
+	})
+
+	t.Run("GracefulShutdown", func(t *testing.T) {
+		client := mcp.NewClient(&mcp.Implementation{Name: "test-client", Version: "v0.0.1"}, nil)
+		session, err := client.Connect(t.Context(), &mcp.StreamableClientTransport{Endpoint: endpoint}, nil)
+		if err != nil {
+			t.Fatalf("Failed to connect: %v", err)
+		}
+		defer session.Close()
+
+		// Send SIGTERM while a tool call is in flight; it must still complete.
+		type callResult struct {
+			res *mcp.CallToolResult
+			err error
+		}
+		done := make(chan callResult, 1)
+		go func() {
+			res, err := session.CallTool(t.Context(), &mcp.CallToolParams{Name: "go_build_check"})
+			done <- callResult{res, err}
+		}()
+		time.Sleep(50 * time.Millisecond)
+		if err := server.Process.Signal(syscall.SIGTERM); err != nil {
+			t.Fatal(err)
+		}
+
+		if r := <-done; r.err != nil {
+			t.Errorf("In-flight tool call failed during shutdown: %v", r.err)
+		}
+
+		select {
+		case err := <-exited:
+			if err != nil {
+				t.Errorf("Expected a clean exit, got: %v\n%s", err, output.String())
+			}
+		case <-time.After(60 * time.Second):
+			t.Fatalf("Server did not shut down after SIGTERM")
+		}
+		if !strings.Contains(output.String(), "Released views") {
+			t.Errorf("Expected the workspace to be released on shutdown, got:\n%s", output.String())
+		}
+	})
+}
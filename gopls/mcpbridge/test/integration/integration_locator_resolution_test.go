@@ -0,0 +1,100 @@
+package integration
+
+// End-to-end test for the ambiguity candidates and "did you mean"
+// suggestions of the locator tools.
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+	"golang.org/x/tools/gopls/mcpbridge/test/testutil"
+)
+
+// TestLocatorResolution verifies that the locator tools report the
+// candidates of an ambiguous locator, and suggest similar symbols for a
+// locator that matches none, in their text and structured output.
+func TestLocatorResolution(t *testing.T) {
+	goplsMcpPath := testutil.BuildGoplsMcp(t)
+	projectDir := testutil.CopyProjectTo(t, "simple")
+	mainFile := filepath.Join(projectDir, "main.go")
+
+	session, ctx, cleanup := testutil.StartMCPServerWithArgs(t, goplsMcpPath, "-workdir", projectDir)
+	defer cleanup()
+
+	call := func(t *testing.T, tool string, locator map[string]any) (*mcp.CallToolResult, map[string]any) {
+		t.Helper()
+		res, err := session.CallTool(ctx, &mcp.CallToolParams{Name: tool, Arguments: map[string]any{"locator": locator}})
+		if err != nil {
+			t.Fatalf("Failed to call %s: %v", tool, err)
+		}
+		result, _ := res.StructuredContent.(map[string]any)
+		resolution, _ := result["resolution"].(map[string]any)
+		return res, resolution
+	}
+	names := func(resolution map[string]any, field string) []any {
+		var names []any
+		list, _ := resolution[field].([]any)
+		for _, c := range list {
+			c, _ := c.(map[string]any)
+			names = append(names, c["parent_scope"], c["name"])
+		}
+		return names
+	}
+
+	t.Run("Ambiguous", func(t *testing.T) {
+		// The receiver of Greeting, and the local variable of main: without
+		// a line hint, the first declaration wins.
+		res, resolution := call(t, "go_symbol_references", map[string]any{"symbol_name": "p", "context_file": mainFile})
+		if res.IsError {
+			t.Fatalf("go_symbol_references failed: %s", testutil.ResultText(t, res, ""))
+		}
+		content := testutil.ResultText(t, res, "")
+		testutil.AssertStringContains(t, content, "the locator matched 2 symbols; using p (variable of (*Person).Greeting, main.go:22)")
+		testutil.AssertStringContains(t, content, "  - p (variable of main, main.go:29)")
+
+		candidates, _ := resolution["candidates"].([]any)
+		if len(candidates) != 2 {
+			t.Fatalf("Expected 2 candidates, got %v", resolution)
+		}
+		second, _ := candidates[1].(map[string]any)
+		if second["line"] != float64(29) || second["kind"] != "variable" || second["parent_scope"] != "main" || second["is_definition"] != true {
+			t.Errorf("Unexpected second candidate: %v", second)
+		}
+
+		// A locator that designates one symbol has no resolution.
+		res, resolution = call(t, "go_definition", map[string]any{"symbol_name": "Greeting", "context_file": mainFile})
+		if res.IsError || resolution != nil {
+			t.Errorf("Expected an unambiguous definition, got %v:\n%s", resolution, testutil.ResultText(t, res, ""))
+		}
+	})
+
+	t.Run("Suggestions", func(t *testing.T) {
+		for _, tool := range []string{"go_definition", "go_symbol_references", "go_implementation", "go_get_call_hierarchy"} {
+			res, resolution := call(t, tool, map[string]any{"symbol_name": "Greting", "context_file": mainFile})
+			if !res.IsError {
+				t.Fatalf("Expected %s to fail, got:\n%s", tool, testutil.ResultText(t, res, ""))
+			}
+			testutil.AssertStringContains(t, testutil.ResultText(t, res, ""), "did you mean Greeting (method of *Person, main.go:22)?")
+			if got := names(resolution, "suggestions"); len(got) == 0 || got[1] != "Greeting" {
+				t.Errorf("%s: expected the suggestion Greeting, got %v", tool, got)
+			}
+		}
+
+		// Symbol paths are resolved in the package scope.
+		res, resolution := call(t, "go_definition", map[string]any{"symbol_path": "example.com/simple.Person.Nam"})
+		if !res.IsError {
+			t.Fatalf("Expected go_definition to fail, got:\n%s", testutil.ResultText(t, res, ""))
+		}
+		if got := names(resolution, "suggestions"); len(got) == 0 || got[0] != "Person" || got[1] != "Name" {
+			t.Errorf("Expected the suggestion Person.Name, got %v", got)
+		}
+
+		// Without suggestions, the error is plain.
+		res, resolution = call(t, "go_definition", map[string]any{"symbol_name": "Quux", "context_file": mainFile})
+		if !res.IsError || resolution != nil {
+			t.Errorf("Expected an error without resolution, got %v", resolution)
+		}
+		testutil.AssertStringNotContains(t, testutil.ResultText(t, res, ""), "did you mean")
+	})
+}
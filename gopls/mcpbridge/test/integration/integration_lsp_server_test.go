@@ -0,0 +1,148 @@
+package integration
+
+// End-to-end test for serving LSP and MCP from the same process (-lsp).
+
+import (
+	"context"
+	"net"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+	"golang.org/x/tools/gopls/internal/protocol"
+	"golang.org/x/tools/gopls/mcpbridge/test/testutil"
+	"golang.org/x/tools/internal/jsonrpc2"
+)
+
+// TestLSPSharedSession verifies that documents opened by an LSP client are
+// visible to MCP tools of the same process, that an LSP client's shutdown
+// does not affect the MCP session, and that the documents it left open are
+// closed when it disconnects.
+func TestLSPSharedSession(t *testing.T) {
+	goplsMcpPath := testutil.BuildGoplsMcp(t)
+	projectDir := testutil.CopyProjectTo(t, "simple")
+
+	socket := filepath.Join(t.TempDir(), "lsp.sock")
+	session, ctx, cleanup := testutil.StartMCPServerWithArgs(t, goplsMcpPath, "-workdir", projectDir, "-lsp", "unix;"+socket)
+	defer cleanup()
+
+	editor, conn := connectEditor(t, ctx, socket, projectDir)
+	defer conn.Close()
+
+	mainPath := filepath.Join(projectDir, "main.go")
+	content, err := os.ReadFile(mainPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	const marker = "// unsaved edit from the editor"
+	if err := editor.DidOpen(ctx, &protocol.DidOpenTextDocumentParams{
+		TextDocument: protocol.TextDocumentItem{
+			URI:        protocol.URIFromPath(mainPath),
+			LanguageID: "go",
+			Version:    1,
+			Text:       string(content) + marker + "\n",
+		},
+	}); err != nil {
+		t.Fatalf("LSP didOpen failed: %v", err)
+	}
+
+	t.Run("EditorOverlayVisibleToMCP", func(t *testing.T) {
+		// didOpen is a notification, so it may not have been processed yet.
+		var got string
+		for range 50 {
+			res, err := session.CallTool(ctx, &mcp.CallToolParams{Name: "go_read_file", Arguments: map[string]any{"file": mainPath}})
+			if err != nil {
+				t.Fatalf("Failed to call tool go_read_file: %v", err)
+			}
+			if got = testutil.ResultText(t, res, ""); strings.Contains(got, marker) {
+				return
+			}
+			time.Sleep(100 * time.Millisecond)
+		}
+		t.Errorf("Expected go_read_file to see the editor's buffer, got: %s", got)
+	})
+
+	t.Run("EditorShutdownKeepsMCPSession", func(t *testing.T) {
+		if err := editor.Shutdown(ctx); err != nil {
+			t.Fatalf("LSP shutdown failed: %v", err)
+		}
+		if err := editor.Exit(ctx); err != nil {
+			t.Fatalf("LSP exit failed: %v", err)
+		}
+		res, err := session.CallTool(ctx, &mcp.CallToolParams{Name: "go_list_modules", Arguments: map[string]any{"direct_only": true}})
+		if err != nil {
+			t.Fatalf("Failed to call tool go_list_modules: %v", err)
+		}
+		if got := testutil.ResultText(t, res, ""); !strings.Contains(got, "example.com/simple") {
+			t.Errorf("Expected MCP tools to keep working after LSP shutdown, got: %s", got)
+		}
+	})
+
+	t.Run("EditorDisconnectClosesOverlays", func(t *testing.T) {
+		// The editor's unsaved buffer is dropped once it disconnects, and
+		// MCP tools read the file on disk again.
+		conn.Close()
+		var got string
+		for range 50 {
+			res, err := session.CallTool(ctx, &mcp.CallToolParams{Name: "go_read_file", Arguments: map[string]any{"file": mainPath}})
+			if err != nil {
+				t.Fatalf("Failed to call tool go_read_file: %v", err)
+			}
+			if got = testutil.ResultText(t, res, ""); !strings.Contains(got, marker) {
+				break
+			}
+			time.Sleep(100 * time.Millisecond)
+		}
+		if strings.Contains(got, marker) || !strings.Contains(got, "func main()") {
+			t.Errorf("Expected go_read_file to read main.go from disk after the editor disconnected, got: %s", got)
+		}
+
+		// The MCP view, which the editor shared, is kept.
+		res, err := session.CallTool(ctx, &mcp.CallToolParams{Name: "go_list_modules", Arguments: map[string]any{"direct_only": true}})
+		if err != nil {
+			t.Fatalf("Failed to call tool go_list_modules: %v", err)
+		}
+		if got := testutil.ResultText(t, res, ""); !strings.Contains(got, "example.com/simple") {
+			t.Errorf("Expected the MCP view to survive the editor, got: %s", got)
+		}
+	})
+}
+
+// connectEditor connects an LSP client to the unix socket, once it is up,
+// and initializes it with projectDir as workspace folder.
+func connectEditor(t *testing.T, ctx context.Context, socket, projectDir string) (protocol.Server, jsonrpc2.Conn) {
+	t.Helper()
+	var netConn net.Conn
+	deadline := time.Now().Add(30 * time.Second)
+	for {
+		var err error
+		if netConn, err = net.Dial("unix", socket); err == nil {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("Failed to connect to LSP socket: %v", err)
+		}
+		time.Sleep(100 * time.Millisecond)
+	}
+	conn := jsonrpc2.NewConn(jsonrpc2.NewHeaderStream(netConn))
+	conn.Go(ctx, jsonrpc2.MethodNotFound)
+	editor := protocol.ServerDispatcher(conn)
+
+	if _, err := editor.Initialize(ctx, &protocol.ParamInitialize{
+		XInitializeParams: protocol.XInitializeParams{
+			ProcessID: int32(os.Getpid()),
+		},
+		WorkspaceFoldersInitializeParams: protocol.WorkspaceFoldersInitializeParams{
+			WorkspaceFolders: []protocol.WorkspaceFolder{{URI: string(protocol.URIFromPath(projectDir)), Name: filepath.Base(projectDir)}},
+		},
+	}); err != nil {
+		t.Fatalf("LSP initialize failed: %v", err)
+	}
+	if err := editor.Initialized(ctx, &protocol.InitializedParams{}); err != nil {
+		t.Fatalf("LSP initialized failed: %v", err)
+	}
+	return editor, conn
+}
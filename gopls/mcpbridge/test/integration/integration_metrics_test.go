@@ -0,0 +1,113 @@
+package integration
+
+// End-to-end test for tool call metrics and the trace file.
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"syscall"
+	"testing"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+	"golang.org/x/tools/gopls/mcpbridge/test/testutil"
+)
+
+// TestToolMetrics verifies that tool calls are counted on /metrics in HTTP
+// mode and written as spans to the -trace-file.
+func TestToolMetrics(t *testing.T) {
+	goplsMcpPath := testutil.BuildGoplsMcp(t)
+	projectDir := testutil.CopyProjectTo(t, "simple")
+	traceFile := filepath.Join(t.TempDir(), "trace.jsonl")
+
+	addr := freeAddr(t)
+	server := exec.Command(goplsMcpPath, "-addr", addr, "-workdir", projectDir, "-trace-file", traceFile)
+	if err := server.Start(); err != nil {
+		t.Fatalf("Failed to start server: %v", err)
+	}
+	t.Cleanup(func() {
+		server.Process.Signal(syscall.SIGTERM)
+		server.Wait()
+	})
+
+	endpoint := "http://" + addr
+	waitForHTTP(t, http.DefaultClient, endpoint+"/healthz")
+
+	client := mcp.NewClient(&mcp.Implementation{Name: "test-client", Version: "v0.0.1"}, nil)
+	session, err := client.Connect(t.Context(), &mcp.StreamableClientTransport{Endpoint: endpoint}, nil)
+	if err != nil {
+		t.Fatalf("Failed to connect: %v", err)
+	}
+	defer session.Close()
+
+	res, err := session.CallTool(t.Context(), &mcp.CallToolParams{
+		Name:      "go_list_modules",
+		Arguments: map[string]any{"direct_only": true},
+	})
+	if err != nil {
+		t.Fatalf("Failed to call go_list_modules: %v", err)
+	}
+	testutil.ResultText(t, res, "")
+
+	t.Run("Metrics", func(t *testing.T) {
+		resp, err := http.Get(endpoint + "/metrics")
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer resp.Body.Close()
+		body, err := io.ReadAll(resp.Body)
+		if err != nil {
+			t.Fatal(err)
+		}
+		// Match lines by metric name and labels, leaving the exact label
+		// syntax to the gopls Prometheus exporter.
+		hasRow := func(prefix string, labels ...string) bool {
+			for line := range strings.SplitSeq(string(body), "\n") {
+				if !strings.HasPrefix(line, prefix+"{") || !strings.HasSuffix(line, " 1") {
+					continue
+				}
+				matched := true
+				for _, l := range labels {
+					matched = matched && strings.Contains(line, l)
+				}
+				if matched {
+					return true
+				}
+			}
+			return false
+		}
+		for _, row := range [][]string{
+			{"mcp_tool_calls", `tool="go_list_modules"`, `status="ok"`},
+			{"mcp_tool_latency_count", `tool="go_list_modules"`},
+			{"mcp_tool_response_bytes_count", `tool="go_list_modules"`},
+		} {
+			if !hasRow(row[0], row[1:]...) {
+				t.Errorf("Expected /metrics to have a %s row with %v, got:\n%s", row[0], row[1:], body)
+			}
+		}
+	})
+
+	t.Run("TraceFile", func(t *testing.T) {
+		data, err := os.ReadFile(traceFile)
+		if err != nil {
+			t.Fatal(err)
+		}
+		lines := strings.Split(strings.TrimSpace(string(data)), "\n")
+		var span struct {
+			Tool          string  `json:"tool"`
+			DurationMs    float64 `json:"duration_ms"`
+			Status        string  `json:"status"`
+			ResponseBytes int     `json:"response_bytes"`
+		}
+		if err := json.Unmarshal([]byte(lines[len(lines)-1]), &span); err != nil {
+			t.Fatalf("Invalid span %q: %v", lines[len(lines)-1], err)
+		}
+		if span.Tool != "go_list_modules" || span.Status != "ok" || span.ResponseBytes == 0 {
+			t.Errorf("Unexpected span: %+v", span)
+		}
+	})
+}
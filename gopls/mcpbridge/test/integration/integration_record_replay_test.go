@@ -0,0 +1,124 @@
+package integration
+
+// End-to-end test for session recording (-record) and gopls-mcp replay.
+
+import (
+	"errors"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+	"golang.org/x/tools/gopls/mcpbridge/test/testutil"
+)
+
+// TestRecordReplay records a session against one copy of a project and
+// replays it against other copies, with and without changes.
+func TestRecordReplay(t *testing.T) {
+	goplsMcpPath := testutil.BuildGoplsMcp(t)
+	recordedDir := testutil.CopyProjectTo(t, "simple")
+	recording := filepath.Join(t.TempDir(), "session.jsonl")
+
+	// Record a session.
+	session, ctx, cleanup := testutil.StartMCPServerWithArgs(t, goplsMcpPath, "-workdir", recordedDir, "-record", recording)
+	for _, call := range []*mcp.CallToolParams{
+		{Name: "go_search", Arguments: map[string]any{"query": "Hello"}},
+		{Name: "go_read_file", Arguments: map[string]any{"file": filepath.Join(recordedDir, "main.go")}},
+		{Name: "go_build_check"},
+		{Name: "go_sync_document", Arguments: map[string]any{
+			"path":    filepath.Join(recordedDir, "main.go"),
+			"action":  "open",
+			"content": "package main\n\nfunc main() {}\n",
+		}},
+	} {
+		res, err := session.CallTool(ctx, call)
+		if err != nil {
+			t.Fatalf("Failed to call tool %s: %v", call.Name, err)
+		}
+		testutil.ResultText(t, res, "")
+	}
+	cleanup()
+
+	replay := func(t *testing.T, dir string, flags ...string) (string, int) {
+		t.Helper()
+		args := append(append([]string{"replay", "-workdir", dir}, flags...), recording)
+		out, err := exec.Command(goplsMcpPath, args...).CombinedOutput()
+		var exitErr *exec.ExitError
+		if errors.As(err, &exitErr) {
+			return string(out), exitErr.ExitCode()
+		} else if err != nil {
+			t.Fatalf("Failed to run replay: %v", err)
+		}
+		return string(out), 0
+	}
+
+	t.Run("SameSources", func(t *testing.T) {
+		out, code := replay(t, testutil.CopyProjectTo(t, "simple"))
+		if code != 0 {
+			t.Fatalf("Expected replay to succeed, got exit code %d:\n%s", code, out)
+		}
+		if !strings.Contains(out, "3 tool calls replayed, 1 skipped, 0 differ") {
+			t.Errorf("Expected all 3 read-only responses to match, got:\n%s", out)
+		}
+		if !strings.Contains(out, "[4] go_sync_document: skipped") {
+			t.Errorf("Expected go_sync_document, which is not read-only, to be skipped, got:\n%s", out)
+		}
+		if strings.Contains(out, "sources differ") {
+			t.Errorf("Expected the workspace fingerprint to match, got:\n%s", out)
+		}
+	})
+
+	t.Run("AllowWrites", func(t *testing.T) {
+		out, code := replay(t, testutil.CopyProjectTo(t, "simple"), "-allow-writes")
+		if code != 0 {
+			t.Fatalf("Expected replay to succeed, got exit code %d:\n%s", code, out)
+		}
+		if !strings.Contains(out, "4 tool calls replayed, 0 skipped, 0 differ") {
+			t.Errorf("Expected all 4 responses to match, got:\n%s", out)
+		}
+	})
+
+	t.Run("ChangedSources", func(t *testing.T) {
+		dir := testutil.CopyProjectTo(t, "simple")
+		mainFile := filepath.Join(dir, "main.go")
+		data, err := os.ReadFile(mainFile)
+		if err != nil {
+			t.Fatal(err)
+		}
+		changed := strings.Replace(string(data), `"hello world"`, `"goodbye world"`, 1)
+		if err := os.WriteFile(mainFile, []byte(changed), 0644); err != nil {
+			t.Fatal(err)
+		}
+
+		out, code := replay(t, dir)
+		if code != 1 {
+			t.Fatalf("Expected replay to report differences (exit code 1), got %d:\n%s", code, out)
+		}
+		for _, want := range []string{
+			"sources differ from the recording",
+			"[2] go_read_file: response differs",
+			`+	return "goodbye world"`,
+		} {
+			if !strings.Contains(out, want) {
+				t.Errorf("Expected replay output to contain %q, got:\n%s", want, out)
+			}
+		}
+	})
+	t.Run("RecordWithDaemonRejected", func(t *testing.T) {
+		for _, mode := range [][]string{
+			{"-remote", "unix;" + filepath.Join(t.TempDir(), "daemon.sock")},
+			{"-listen", "unix;" + filepath.Join(t.TempDir(), "daemon.sock")},
+		} {
+			args := append([]string{"-workdir", recordedDir, "-record", filepath.Join(t.TempDir(), "session.jsonl")}, mode...)
+			out, err := exec.Command(goplsMcpPath, args...).CombinedOutput()
+			if err == nil {
+				t.Fatalf("Expected -record with %s to fail, got: %s", mode[0], out)
+			}
+			if !strings.Contains(string(out), "-record is not supported") {
+				t.Errorf("Expected an explanatory error for -record with %s, got: %s", mode[0], out)
+			}
+		}
+	})
+}
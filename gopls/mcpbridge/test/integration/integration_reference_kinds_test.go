@@ -0,0 +1,189 @@
+package integration
+
+// End-to-end test for the kinds of references of go_symbol_references.
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+	"golang.org/x/tools/gopls/mcpbridge/test/testutil"
+)
+
+// TestReferenceKinds verifies that go_symbol_references reports how each
+// reference uses the symbol, its enclosing function and whether it is in a
+// test file, and filters the references on those.
+func TestReferenceKinds(t *testing.T) {
+	goplsMcpPath := testutil.BuildGoplsMcp(t)
+	projectDir := t.TempDir()
+	for name, content := range map[string]string{
+		"go.mod": "module example.com/store\n\ngo 1.21\n",
+		"store.go": `package store
+
+type Store struct {
+	Items []string
+	count int
+}
+
+type Lister interface {
+	List() []string
+}
+
+type Cached struct {
+	*Store
+}
+
+var _ Lister = (*Store)(nil)
+
+func New() *Store {
+	return &Store{Items: nil}
+}
+
+func (s *Store) List() []string {
+	s.count++
+	return s.Items
+}
+
+func (s *Store) Add(item string) {
+	s.Items = append(s.Items, item)
+}
+
+func Use() {
+	s := New()
+	s.Add("a")
+	p := &s.Items
+	_ = p
+}
+`,
+		"store_test.go": `package store
+
+import "testing"
+
+func TestAdd(t *testing.T) {
+	s := New()
+	s.Add("x")
+	if len(s.Items) != 1 {
+		t.Fatal(s.Items)
+	}
+}
+`,
+	} {
+		if err := os.WriteFile(filepath.Join(projectDir, name), []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	session, ctx, cleanup := testutil.StartMCPServerWithArgs(t, goplsMcpPath, "-workdir", projectDir)
+	defer cleanup()
+
+	// references calls go_symbol_references and returns its text, and its
+	// references as "kind in function [test]" sorted.
+	references := func(t *testing.T, handle string, filters map[string]any) (*mcp.CallToolResult, []string) {
+		t.Helper()
+		args := map[string]any{"locator": map[string]any{"handle": handle}}
+		for k, v := range filters {
+			args[k] = v
+		}
+		res, err := session.CallTool(ctx, &mcp.CallToolParams{Name: "go_symbol_references", Arguments: args})
+		if err != nil {
+			t.Fatalf("Failed to call go_symbol_references: %v", err)
+		}
+		if res.IsError {
+			return res, nil
+		}
+		result, _ := res.StructuredContent.(map[string]any)
+		refs, _ := result["references"].([]any)
+		var got []string
+		for _, r := range refs {
+			r, _ := r.(map[string]any)
+			desc := fmt.Sprintf("%v in %v", r["kind"], r["enclosing_function"])
+			if r["in_test_file"] == true {
+				desc += " test"
+			}
+			got = append(got, desc)
+		}
+		slices.Sort(got)
+		return res, got
+	}
+	check := func(t *testing.T, handle string, filters map[string]any, want ...string) {
+		t.Helper()
+		res, got := references(t, handle, filters)
+		if res.IsError {
+			t.Fatalf("go_symbol_references failed: %s", testutil.ResultText(t, res, ""))
+		}
+		slices.Sort(want)
+		if !slices.Equal(got, want) {
+			t.Errorf("references to %s %v:\ngot  %q\nwant %q", handle, filters, got, want)
+		}
+	}
+
+	t.Run("Kinds", func(t *testing.T) {
+		check(t, "example.com/store#Store", nil,
+			"embedding in <nil>",
+			"interface in <nil>",
+			"type in New",
+			"type in New",
+			"type in (*Store).List",
+			"type in (*Store).Add",
+		)
+		check(t, "example.com/store#Store.Items", nil,
+			"field_key in New",
+			"read in (*Store).List",
+			"write in (*Store).Add",
+			"read in (*Store).Add",
+			"address in Use",
+			"read in TestAdd test",
+			"read in TestAdd test",
+		)
+		check(t, "example.com/store#Store.Add", nil,
+			"call in Use",
+			"call in TestAdd test",
+		)
+		check(t, "example.com/store#Store.count", nil,
+			"write in (*Store).List",
+		)
+	})
+
+	t.Run("Filters", func(t *testing.T) {
+		check(t, "example.com/store#Store.Items", map[string]any{"kinds": []string{"write", "address"}},
+			"write in (*Store).Add",
+			"address in Use",
+		)
+		check(t, "example.com/store#Store.Items", map[string]any{"tests": "only"},
+			"read in TestAdd test",
+			"read in TestAdd test",
+		)
+		check(t, "example.com/store#Store.Add", map[string]any{"tests": "exclude"},
+			"call in Use",
+		)
+
+		res, _ := references(t, "example.com/store#Store.Items", map[string]any{"kinds": []string{"read"}, "tests": "exclude"})
+		content := testutil.ResultText(t, res, "")
+		testutil.AssertStringContains(t, content, "Found 2 of 7 reference(s) to \"Items\" matching kinds read and tests exclude:")
+		testutil.AssertStringContains(t, content, "(read in (*Store).List)")
+		testutil.AssertStringNotContains(t, content, "TestAdd")
+		if result, _ := res.StructuredContent.(map[string]any); result["total_count"] != float64(7) || result["returned"] != float64(2) {
+			t.Errorf("Expected 2 of 7 references, got %v of %v", result["returned"], result["total_count"])
+		}
+
+		for _, filters := range []map[string]any{
+			{"kinds": []string{"mutation"}},
+			{"tests": "all"},
+		} {
+			if res, _ := references(t, "example.com/store#Store.Items", filters); !res.IsError {
+				t.Errorf("Expected an error for filters %v, got:\n%s", filters, testutil.ResultText(t, res, ""))
+			}
+		}
+	})
+
+	t.Run("Summary", func(t *testing.T) {
+		res, _ := references(t, "example.com/store#Store.Add", nil)
+		content := testutil.ResultText(t, res, "")
+		testutil.AssertStringContains(t, content, "By kind: 2 call")
+		testutil.AssertStringContains(t, content, "(call in TestAdd, test)")
+		testutil.AssertStringContains(t, content, "(call in Use)")
+	})
+}
@@ -0,0 +1,131 @@
+package integration
+
+// End-to-end test for go_search_by_signature.
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+	"golang.org/x/tools/gopls/mcpbridge/test/testutil"
+)
+
+// TestSearchBySignature verifies that go_search_by_signature matches
+// functions and methods by assignability, with wildcards.
+func TestSearchBySignature(t *testing.T) {
+	goplsMcpPath := testutil.BuildGoplsMcp(t)
+	projectDir := testutil.CopyProjectTo(t, "simple")
+
+	content := `package config
+
+import (
+	"context"
+	"errors"
+	"io"
+	"os"
+)
+
+type Config struct{ Name string }
+
+type ParseError struct{}
+
+func (*ParseError) Error() string { return "parse error" }
+
+// Parse reads a Config.
+func Parse(r io.Reader) (*Config, error) { return nil, nil }
+
+// ParseStrict reports a *ParseError.
+func ParseStrict(r io.Reader) (*Config, *ParseError) { return nil, nil }
+
+// Load takes a file, which is an io.Reader.
+func Load(f *os.File) (*Config, error) { return Parse(f) }
+
+func (c *Config) Validate(ctx context.Context) error { return errors.New("invalid") }
+
+func (c *Config) Names(prefix string, more ...string) []string { return nil }
+
+func Apply(ctx context.Context, c *Config, opts map[string]int) error { return nil }
+`
+	dir := filepath.Join(projectDir, "config")
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "config.go"), []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	session, ctx, cleanup := testutil.StartMCPServerWithArgs(t, goplsMcpPath, "-workdir", projectDir)
+	defer cleanup()
+
+	search := func(t *testing.T, args map[string]any) *mcp.CallToolResult {
+		t.Helper()
+		res, err := session.CallTool(ctx, &mcp.CallToolParams{Name: "go_search_by_signature", Arguments: args})
+		if err != nil {
+			t.Fatalf("Failed to call go_search_by_signature: %v", err)
+		}
+		return res
+	}
+	searchText := func(t *testing.T, signature string) string {
+		t.Helper()
+		return testutil.ResultText(t, search(t, map[string]any{"signature": signature}), "")
+	}
+
+	t.Run("Assignability", func(t *testing.T) {
+		// io.Reader is assignable to the parameter of Parse, but not to
+		// that of Load; *ParseError is assignable to error.
+		content := searchText(t, "func(io.Reader) (*Config, error)")
+		testutil.AssertStringContains(t, content, "example.com/simple/config.Parse func(r io.Reader) (*Config, error)")
+		testutil.AssertStringContains(t, content, "config.ParseStrict")
+		testutil.AssertStringNotContains(t, content, "config.Load")
+
+		// *os.File is assignable to io.Reader.
+		content = searchText(t, "func(*os.File) (*Config, error)")
+		testutil.AssertStringContains(t, content, "config.Parse ")
+		testutil.AssertStringContains(t, content, "config.Load ")
+	})
+
+	t.Run("Methods", func(t *testing.T) {
+		content := searchText(t, "func(context.Context) error")
+		testutil.AssertStringContains(t, content, "config.Config.Validate")
+		testutil.AssertStringNotContains(t, content, "config.Apply")
+	})
+
+	t.Run("Wildcards", func(t *testing.T) {
+		content := searchText(t, "func(context.Context, ...) error")
+		testutil.AssertStringContains(t, content, "config.Config.Validate")
+		testutil.AssertStringContains(t, content, "config.Apply")
+
+		content = searchText(t, "func(_, _, map[string]_) _")
+		testutil.AssertStringContains(t, content, "config.Apply")
+		testutil.AssertStringNotContains(t, content, "config.Parse")
+
+		content = searchText(t, "(io.Reader) (*Config, ...)")
+		testutil.AssertStringContains(t, content, "config.Parse ")
+	})
+
+	t.Run("Variadic", func(t *testing.T) {
+		for _, signature := range []string{"func(string) []string", "func(string, string, string) []string", "func(string, ...string) []string"} {
+			testutil.AssertStringContains(t, searchText(t, signature), "config.Config.Names")
+		}
+		testutil.AssertStringNotContains(t, searchText(t, "func(string, int) []string"), "config.Config.Names")
+	})
+
+	t.Run("Dependencies", func(t *testing.T) {
+		res := search(t, map[string]any{"signature": "func(string, ...any) string"})
+		testutil.AssertStringNotContains(t, testutil.ResultText(t, res, ""), "fmt.Sprintf")
+
+		res = search(t, map[string]any{"signature": "func(string, ...any) string", "include_dependencies": true})
+		content := testutil.ResultText(t, res, "")
+		testutil.AssertStringContains(t, content, "fmt.Sprintf func(format string, a ...any) string")
+		testutil.AssertStringContains(t, content, filepath.Join("fmt", "print.go"))
+	})
+
+	t.Run("InvalidSignature", func(t *testing.T) {
+		for _, signature := range []string{"", "func(", "struct{}", "func(interface{ M() })"} {
+			if res := search(t, map[string]any{"signature": signature}); !res.IsError {
+				t.Errorf("Expected an error for %q, got:\n%s", signature, testutil.ResultText(t, res, ""))
+			}
+		}
+	})
+}
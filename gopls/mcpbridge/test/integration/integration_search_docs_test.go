@@ -0,0 +1,115 @@
+package integration
+
+// End-to-end test for go_search_docs.
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+	"golang.org/x/tools/gopls/mcpbridge/test/testutil"
+)
+
+// TestSearchDocs verifies that go_search_docs ranks symbols by their names,
+// doc comments and string literals, and follows file changes.
+func TestSearchDocs(t *testing.T) {
+	goplsMcpPath := testutil.BuildGoplsMcp(t)
+	projectDir := testutil.CopyProjectTo(t, "simple")
+
+	content := `package main
+
+import "time"
+
+// Do calls fn until it succeeds, waiting exponentially longer between
+// attempts. It gives up after maxAttempts retries.
+func Do(fn func() error, maxAttempts int) error {
+	delay := time.Millisecond
+	for i := 0; ; i++ {
+		if err := fn(); err == nil || i == maxAttempts {
+			return err
+		}
+		time.Sleep(delay)
+		delay *= 2
+	}
+}
+
+// BackoffPolicy configures the delays between attempts.
+type BackoffPolicy struct {
+	// Initial is the first delay.
+	Initial time.Duration
+}
+
+// loadSettings reads the settings file.
+func loadSettings() error {
+	return errorf("cannot open the configuration file")
+}
+
+func errorf(msg string) error { return nil }
+`
+	if err := os.WriteFile(filepath.Join(projectDir, "retry.go"), []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	session, ctx, cleanup := testutil.StartMCPServerWithArgs(t, goplsMcpPath, "-workdir", projectDir)
+	defer cleanup()
+
+	search := func(t *testing.T, query string) *mcp.CallToolResult {
+		t.Helper()
+		res, err := session.CallTool(ctx, &mcp.CallToolParams{Name: "go_search_docs", Arguments: map[string]any{"query": query}})
+		if err != nil {
+			t.Fatalf("Failed to call go_search_docs: %v", err)
+		}
+		return res
+	}
+	searchText := func(t *testing.T, query string) string {
+		t.Helper()
+		return testutil.ResultText(t, search(t, query), "")
+	}
+
+	t.Run("DocComments", func(t *testing.T) {
+		content := searchText(t, "retrying with backoff")
+		testutil.AssertStringContains(t, content, "Do (function in")
+		testutil.AssertStringContains(t, content, "BackoffPolicy (struct in")
+		testutil.AssertStringContains(t, content, "It gives up after maxAttempts retries")
+
+		// The name weighs more than the doc comment.
+		if strings.Index(content, "BackoffPolicy (struct") > strings.Index(content, "Do (function") {
+			t.Errorf("Expected BackoffPolicy to rank before Do:\n%s", content)
+		}
+	})
+
+	t.Run("Identifiers", func(t *testing.T) {
+		// camelCase identifiers are split: maxAttempts and "attempts".
+		testutil.AssertStringContains(t, searchText(t, "max attempts"), "Do (function in")
+		testutil.AssertStringContains(t, searchText(t, "initial delay"), "BackoffPolicy.Initial (field in")
+	})
+
+	t.Run("StringLiterals", func(t *testing.T) {
+		content := searchText(t, "configuration file")
+		testutil.AssertStringContains(t, content, "loadSettings (function in")
+		testutil.AssertStringContains(t, content, `"cannot open the configuration file"`)
+	})
+
+	t.Run("NoMatch", func(t *testing.T) {
+		testutil.AssertStringContains(t, searchText(t, "kubernetes"), "No symbols found")
+		if res := search(t, "the of"); !res.IsError {
+			t.Errorf("Expected an error for a query of stop words, got:\n%s", testutil.ResultText(t, res, ""))
+		}
+	})
+
+	t.Run("FileChanges", func(t *testing.T) {
+		changed := "package main\n\n// Throttle limits the request rate with a token bucket.\nfunc Throttle() {}\n"
+		if err := os.WriteFile(filepath.Join(projectDir, "retry.go"), []byte(changed), 0644); err != nil {
+			t.Fatal(err)
+		}
+		if !waitFor(30*time.Second, func() bool {
+			return strings.Contains(searchText(t, "token bucket"), "Throttle (function in") &&
+				!strings.Contains(searchText(t, "backoff"), "BackoffPolicy")
+		}) {
+			t.Fatalf("Expected go_search_docs to reflect the changed file:\n%s", searchText(t, "token bucket"))
+		}
+	})
+}
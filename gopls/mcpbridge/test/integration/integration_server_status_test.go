@@ -0,0 +1,41 @@
+package integration
+
+// End-to-end test for go_server_status functionality.
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+	"golang.org/x/tools/gopls/mcpbridge/test/testutil"
+)
+
+// TestGoServerStatusE2E verifies that go_server_status reports the views of
+// the session along with process information.
+func TestGoServerStatusE2E(t *testing.T) {
+	projectDir := testutil.CopyProjectTo(t, "simple")
+
+	// Load the project so that it has a view with type-checked packages.
+	if _, err := globalSession.CallTool(globalCtx, &mcp.CallToolParams{Name: "go_build_check", Arguments: map[string]any{
+		"Cwd": projectDir,
+	}}); err != nil {
+		t.Fatalf("Failed to call tool go_build_check: %v", err)
+	}
+
+	tool := "go_server_status"
+	res, err := globalSession.CallTool(globalCtx, &mcp.CallToolParams{Name: tool, Arguments: map[string]any{
+		"include_file_cache": true,
+	}})
+	if err != nil {
+		t.Fatalf("Failed to call tool %s: %v", tool, err)
+	}
+
+	content := testutil.ResultText(t, res, testutil.GoldenServerStatus)
+	t.Logf("Server status:\n%s", content)
+
+	for _, want := range []string{projectDir, "GoMod", "Snapshot:", "type-checked in memory", "File watcher:", "File cache:", "Memory:"} {
+		if !strings.Contains(content, want) {
+			t.Errorf("Expected server status to contain %q, got: %s", want, content)
+		}
+	}
+}
@@ -0,0 +1,213 @@
+package integration
+
+// End-to-end test for go_structural_replace.
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+	"golang.org/x/tools/gopls/internal/protocol"
+	"golang.org/x/tools/gopls/mcpbridge/test/testutil"
+)
+
+// TestStructuralReplace verifies that go_structural_replace rewrites the
+// matches of a pattern, fixes imports, and only writes files when applied.
+func TestStructuralReplace(t *testing.T) {
+	goplsMcpPath := testutil.BuildGoplsMcp(t)
+	projectDir := testutil.CopyProjectTo(t, "simple")
+
+	files := map[string]string{
+		"errs/errs.go": `package errs
+
+// Wrap annotates err with msg.
+func Wrap(err error, msg string) error { return err }
+`,
+		"store/store.go": `package store
+
+import (
+	"os"
+
+	"example.com/simple/errs"
+)
+
+func Open(name string) error {
+	if _, err := os.Open(name); err != nil {
+		return errs.Wrap(err, "open "+name)
+	}
+	return nil
+}
+
+func double(n int) int { return n * 2 }
+
+func Sizes(a, b int) (int, int) {
+	return double(a + b), -double(a)
+}
+`,
+	}
+	for name, content := range files {
+		path := filepath.Join(projectDir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	storeFile := filepath.Join(projectDir, "store", "store.go")
+
+	session, ctx, cleanup := testutil.StartMCPServerWithArgs(t, goplsMcpPath, "-workdir", projectDir)
+	defer cleanup()
+
+	replace := func(t *testing.T, args map[string]any) *mcp.CallToolResult {
+		t.Helper()
+		res, err := session.CallTool(ctx, &mcp.CallToolParams{Name: "go_structural_replace", Arguments: args})
+		if err != nil {
+			t.Fatalf("Failed to call go_structural_replace: %v", err)
+		}
+		return res
+	}
+	wrapArgs := map[string]any{"pattern": "errs.Wrap($e, $m)", "replacement": `fmt.Errorf($m+": %w", $e)`}
+
+	t.Run("Preview", func(t *testing.T) {
+		content := testutil.ResultText(t, replace(t, wrapArgs), "")
+		testutil.AssertStringContains(t, content, "DRY RUN")
+		testutil.AssertStringContains(t, content, "1 match(es) of errs.Wrap($e, $m) would be replaced in 1 file(s)")
+		testutil.AssertStringContains(t, content, `+		return fmt.Errorf("open "+name+": %w", err)`)
+		testutil.AssertStringContains(t, content, `+	"fmt"`)
+		testutil.AssertStringContains(t, content, `-	"example.com/simple/errs"`)
+
+		data, err := os.ReadFile(storeFile)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(data) != files["store/store.go"] {
+			t.Errorf("Expected the preview to leave the file unchanged, got:\n%s", data)
+		}
+	})
+
+	t.Run("Parentheses", func(t *testing.T) {
+		content := testutil.ResultText(t, replace(t, map[string]any{"pattern": "double($x)", "replacement": "$x * 2"}), "")
+		testutil.AssertStringContains(t, content, "+	return (a + b) * 2, -(a * 2)")
+	})
+
+	t.Run("Apply", func(t *testing.T) {
+		args := map[string]any{"apply": true}
+		for k, v := range wrapArgs {
+			args[k] = v
+		}
+		content := testutil.ResultText(t, replace(t, args), "")
+		testutil.AssertStringContains(t, content, "Replaced 1 match(es)")
+
+		data, err := os.ReadFile(storeFile)
+		if err != nil {
+			t.Fatal(err)
+		}
+		testutil.AssertStringContains(t, string(data), `return fmt.Errorf("open "+name+": %w", err)`)
+		testutil.AssertStringNotContains(t, string(data), "example.com/simple/errs")
+
+		// The session sees the change at once.
+		content = testutil.ResultText(t, replace(t, wrapArgs), "")
+		testutil.AssertStringContains(t, content, "No matches found")
+		res, err := session.CallTool(ctx, &mcp.CallToolParams{Name: "go_build_check", Arguments: map[string]any{}})
+		if err != nil {
+			t.Fatalf("Failed to call go_build_check: %v", err)
+		}
+		testutil.AssertStringNotContains(t, testutil.ResultText(t, res, ""), "store.go")
+	})
+
+	t.Run("InvalidInput", func(t *testing.T) {
+		for _, args := range []map[string]any{
+			{"pattern": "double($x)", "replacement": "triple($y)"},
+			{"pattern": "double($x)", "replacement": "$_ * 2"},
+			{"pattern": "double($x)", "replacement": "x := $x"},
+			{"pattern": "double($x)", "replacement": "$x *"},
+		} {
+			if res := replace(t, args); !res.IsError {
+				t.Errorf("Expected an error for %v, got:\n%s", args, testutil.ResultText(t, res, ""))
+			}
+		}
+	})
+}
+
+// TestStructuralReplaceEditorOverlay verifies that go_structural_replace
+// refuses to apply changes to a file with unsaved changes in an editor
+// sharing the session, and then changes no file at all.
+func TestStructuralReplaceEditorOverlay(t *testing.T) {
+	goplsMcpPath := testutil.BuildGoplsMcp(t)
+	projectDir := testutil.CopyProjectTo(t, "simple")
+
+	files := map[string]string{
+		"calc/a.go": "package calc\n\nfunc double(n int) int { return n * 2 }\n\nfunc A(n int) int { return double(n) }\n",
+		"calc/b.go": "package calc\n\nfunc B(n int) int { return double(n + 1) }\n",
+	}
+	for name, content := range files {
+		path := filepath.Join(projectDir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	socket := filepath.Join(t.TempDir(), "lsp.sock")
+	session, ctx, cleanup := testutil.StartMCPServerWithArgs(t, goplsMcpPath, "-workdir", projectDir, "-lsp", "unix;"+socket)
+	defer cleanup()
+	editor, conn := connectEditor(t, ctx, socket, projectDir)
+	defer conn.Close()
+
+	// The editor has an unsaved change to b.go.
+	bFile := filepath.Join(projectDir, "calc", "b.go")
+	if err := editor.DidOpen(ctx, &protocol.DidOpenTextDocumentParams{
+		TextDocument: protocol.TextDocumentItem{
+			URI:        protocol.URIFromPath(bFile),
+			LanguageID: "go",
+			Version:    1,
+			Text:       files["calc/b.go"] + "\n// unsaved\n",
+		},
+	}); err != nil {
+		t.Fatalf("LSP didOpen failed: %v", err)
+	}
+
+	// didOpen is a notification: wait until the session has the overlay.
+	for i := 0; ; i++ {
+		res, err := session.CallTool(ctx, &mcp.CallToolParams{Name: "go_read_file", Arguments: map[string]any{"file": bFile}})
+		if err != nil {
+			t.Fatalf("Failed to call tool go_read_file: %v", err)
+		}
+		if strings.Contains(testutil.ResultText(t, res, ""), "// unsaved") {
+			break
+		}
+		if i == 50 {
+			t.Fatalf("The editor's buffer is not visible to the session")
+		}
+		time.Sleep(100 * time.Millisecond)
+	}
+
+	res, err := session.CallTool(ctx, &mcp.CallToolParams{Name: "go_structural_replace", Arguments: map[string]any{
+		"pattern": "double($x)", "replacement": "$x * 2", "apply": true,
+	}})
+	if err != nil {
+		t.Fatalf("Failed to call go_structural_replace: %v", err)
+	}
+	content := testutil.ResultText(t, res, "")
+	if !res.IsError {
+		t.Fatalf("Expected the replacement to be refused, got:\n%s", content)
+	}
+	testutil.AssertStringContains(t, content, "b.go has unsaved changes in an editor")
+	testutil.AssertStringContains(t, content, "no file was changed")
+
+	for name, want := range files {
+		data, err := os.ReadFile(filepath.Join(projectDir, name))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(data) != want {
+			t.Errorf("Expected %s to be unchanged, got:\n%s", name, data)
+		}
+	}
+}
@@ -0,0 +1,152 @@
+package integration
+
+// End-to-end test for go_structural_search.
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+	"golang.org/x/tools/gopls/mcpbridge/test/testutil"
+)
+
+// TestStructuralSearch verifies that go_structural_search matches code by
+// pattern, with metavariable bindings and type constraints.
+func TestStructuralSearch(t *testing.T) {
+	goplsMcpPath := testutil.BuildGoplsMcp(t)
+	projectDir := testutil.CopyProjectTo(t, "simple")
+
+	content := `package store
+
+import (
+	"database/sql"
+	"fmt"
+)
+
+const listQuery = "SELECT name FROM users"
+
+type Store struct{ db *sql.DB }
+
+func (s *Store) List() error {
+	rows, err := s.db.Query(listQuery)
+	if err != nil {
+		return err
+	}
+	return rows.Close()
+}
+
+func (s *Store) Find(name string) error {
+	rows, err := s.db.Query("SELECT * FROM users WHERE name = '" + name + "'")
+	if err != nil {
+		return fmt.Errorf("find %s: %w", name, err)
+	}
+	return rows.Close()
+}
+
+func count(db *sql.DB, table string) (int, error) {
+	var n int
+	err := db.QueryRow("SELECT COUNT(*) FROM "+table, 1).Scan(&n)
+	n = n + n
+	return n, err
+}
+`
+	dir := filepath.Join(projectDir, "store")
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "store.go"), []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	session, ctx, cleanup := testutil.StartMCPServerWithArgs(t, goplsMcpPath, "-workdir", projectDir)
+	defer cleanup()
+
+	search := func(t *testing.T, args map[string]any) *mcp.CallToolResult {
+		t.Helper()
+		res, err := session.CallTool(ctx, &mcp.CallToolParams{Name: "go_structural_search", Arguments: args})
+		if err != nil {
+			t.Fatalf("Failed to call go_structural_search: %v", err)
+		}
+		return res
+	}
+	searchText := func(t *testing.T, args map[string]any) string {
+		t.Helper()
+		return testutil.ResultText(t, search(t, args), "")
+	}
+
+	t.Run("Bindings", func(t *testing.T) {
+		res := search(t, map[string]any{"pattern": "$db.Query($q, $*_)"})
+		content := testutil.ResultText(t, res, "")
+		testutil.AssertStringContains(t, content, "Found 2 match(es)")
+		testutil.AssertStringContains(t, content, "in (*Store).List")
+		testutil.AssertStringContains(t, content, "$db = s.db, $q = listQuery")
+		testutil.AssertStringContains(t, content, "in (*Store).Find")
+
+		result, ok := res.StructuredContent.(map[string]any)
+		if !ok {
+			t.Fatalf("Expected a structured result, got %T", res.StructuredContent)
+		}
+		matches, _ := result["matches"].([]any)
+		if len(matches) != 2 {
+			t.Fatalf("Expected 2 matches, got %d", len(matches))
+		}
+		m, _ := matches[0].(map[string]any)
+		bindings, _ := m["bindings"].(map[string]any)
+		if m["line"] != float64(13) || m["text"] != "s.db.Query(listQuery)" || bindings["q"] != "listQuery" || m["enclosing_function"] != "(*Store).List" {
+			t.Errorf("Unexpected first match: %v", m)
+		}
+	})
+
+	t.Run("Constraints", func(t *testing.T) {
+		// Queries built at run time.
+		content := searchText(t, map[string]any{"pattern": "$db.Query($q, $*_)", "constraints": map[string]any{"$q": "!const"}})
+		testutil.AssertStringContains(t, content, "Found 1 match(es)")
+		testutil.AssertStringContains(t, content, "in (*Store).Find")
+
+		content = searchText(t, map[string]any{"pattern": "$db.$m($*_)", "constraints": map[string]any{"db": "*sql.DB"}})
+		testutil.AssertStringContains(t, content, "$db = s.db, $m = Query")
+		testutil.AssertStringContains(t, content, "$db = db, $m = QueryRow")
+		testutil.AssertStringNotContains(t, content, "rows.Close")
+
+		content = searchText(t, map[string]any{"pattern": "fmt.Errorf($*_, $err)", "constraints": map[string]any{"err": "implements:error"}})
+		testutil.AssertStringContains(t, content, "$err = err")
+	})
+
+	t.Run("Statements", func(t *testing.T) {
+		content := searchText(t, map[string]any{"pattern": "if $err != nil { return $err }"})
+		testutil.AssertStringContains(t, content, "Found 1 match(es)")
+		testutil.AssertStringContains(t, content, "in (*Store).List")
+
+		content = searchText(t, map[string]any{"pattern": "$x, $err := $_; if $err != nil { return $*_ }"})
+		testutil.AssertStringContains(t, content, "Found 2 match(es)")
+		testutil.AssertStringContains(t, content, "$err = err, $x = rows")
+	})
+
+	t.Run("RepeatedMetavariable", func(t *testing.T) {
+		content := searchText(t, map[string]any{"pattern": "$x = $x + $x"})
+		testutil.AssertStringContains(t, content, "Found 1 match(es)")
+		testutil.AssertStringContains(t, content, "in count")
+		testutil.AssertStringContains(t, searchText(t, map[string]any{"pattern": "$x + $x"}), "n + n")
+	})
+
+	t.Run("PackageFilter", func(t *testing.T) {
+		args := map[string]any{"pattern": "$db.Query($*_)", "package": "example.com/simple"}
+		testutil.AssertStringNotContains(t, searchText(t, args), "No matches")
+		args["package"] = "."
+		testutil.AssertStringContains(t, searchText(t, args), "No matches found")
+	})
+
+	t.Run("InvalidInput", func(t *testing.T) {
+		for _, args := range []map[string]any{
+			{"pattern": ""},
+			{"pattern": "$x +"},
+			{"pattern": "$x", "constraints": map[string]any{"$y": "string"}},
+			{"pattern": "$x", "constraints": map[string]any{"$x": "implements:"}},
+		} {
+			if res := search(t, args); !res.IsError {
+				t.Errorf("Expected an error for %v, got:\n%s", args, testutil.ResultText(t, res, ""))
+			}
+		}
+	})
+}
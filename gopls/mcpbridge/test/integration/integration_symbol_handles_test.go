@@ -0,0 +1,137 @@
+package integration
+
+// End-to-end test for symbol handles.
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+	"golang.org/x/tools/gopls/mcpbridge/test/testutil"
+)
+
+// TestSymbolHandles verifies that the symbols in results carry a handle,
+// that the locator tools accept it in place of symbol_name and
+// context_file, and that the handle of a deleted symbol is reported as
+// stale.
+func TestSymbolHandles(t *testing.T) {
+	goplsMcpPath := testutil.BuildGoplsMcp(t)
+	projectDir := testutil.CopyProjectTo(t, "simple")
+
+	session, ctx, cleanup := testutil.StartMCPServerWithArgs(t, goplsMcpPath, "-workdir", projectDir)
+	defer cleanup()
+
+	call := func(t *testing.T, tool string, args map[string]any) *mcp.CallToolResult {
+		t.Helper()
+		res, err := session.CallTool(ctx, &mcp.CallToolParams{Name: tool, Arguments: args})
+		if err != nil {
+			t.Fatalf("Failed to call %s: %v", tool, err)
+		}
+		return res
+	}
+	const greeting = "example.com/simple#Person.Greeting"
+	byHandle := func(handle string) map[string]any {
+		return map[string]any{"locator": map[string]any{"handle": handle}}
+	}
+
+	t.Run("Search", func(t *testing.T) {
+		res := call(t, "go_search", map[string]any{"query": "Greeting"})
+		testutil.AssertStringContains(t, testutil.ResultText(t, res, ""), "[handle: "+greeting+"]")
+
+		result, ok := res.StructuredContent.(map[string]any)
+		if !ok {
+			t.Fatalf("Expected a structured result, got %T", res.StructuredContent)
+		}
+		symbols, _ := result["symbols"].([]any)
+		var handles []any
+		for _, s := range symbols {
+			sym, _ := s.(map[string]any)
+			handles = append(handles, sym["handle"])
+		}
+		if len(handles) == 0 || handles[0] != greeting {
+			t.Errorf("Expected handle %q, got %v", greeting, handles)
+		}
+	})
+
+	t.Run("Definition", func(t *testing.T) {
+		res := call(t, "go_definition", byHandle(greeting))
+		if res.IsError {
+			t.Fatalf("go_definition failed: %s", testutil.ResultText(t, res, ""))
+		}
+		content := testutil.ResultText(t, res, "")
+		testutil.AssertStringContains(t, content, "main.go")
+		testutil.AssertStringContains(t, content, "**Handle**: `"+greeting+"`")
+	})
+
+	t.Run("References", func(t *testing.T) {
+		res := call(t, "go_symbol_references", byHandle("example.com/simple#Person.Name"))
+		if res.IsError {
+			t.Fatalf("go_symbol_references failed: %s", testutil.ResultText(t, res, ""))
+		}
+		testutil.AssertStringContains(t, testutil.ResultText(t, res, ""), "main.go")
+	})
+
+	t.Run("CallHierarchy", func(t *testing.T) {
+		args := byHandle(greeting)
+		args["direction"] = "incoming"
+		res := call(t, "go_get_call_hierarchy", args)
+		if res.IsError {
+			t.Fatalf("go_get_call_hierarchy failed: %s", testutil.ResultText(t, res, ""))
+		}
+		result, ok := res.StructuredContent.(map[string]any)
+		if !ok {
+			t.Fatalf("Expected a structured result, got %T", res.StructuredContent)
+		}
+		if symbol, _ := result["symbol"].(map[string]any); symbol["handle"] != greeting {
+			t.Errorf("Expected the symbol handle %q, got %v", greeting, symbol["handle"])
+		}
+		calls, _ := result["incoming_calls"].([]any)
+		var handles []any
+		for _, c := range calls {
+			from, _ := c.(map[string]any)["from"].(map[string]any)
+			handles = append(handles, from["handle"])
+		}
+		if len(handles) != 1 || handles[0] != "example.com/simple#main" {
+			t.Errorf("Expected the caller handle example.com/simple#main, got %v", handles)
+		}
+	})
+
+	t.Run("InvalidHandle", func(t *testing.T) {
+		for _, handle := range []string{"Greeting", "example.com/simple#", "example.com/simple#Person.Greeting.X"} {
+			if res := call(t, "go_definition", byHandle(handle)); !res.IsError {
+				t.Errorf("Expected an error for handle %q, got:\n%s", handle, testutil.ResultText(t, res, ""))
+			}
+		}
+	})
+
+	t.Run("Stale", func(t *testing.T) {
+		mainFile := filepath.Join(projectDir, "main.go")
+		data, err := os.ReadFile(mainFile)
+		if err != nil {
+			t.Fatal(err)
+		}
+		renamed := strings.ReplaceAll(string(data), "Greeting", "Greet")
+		if err := os.WriteFile(mainFile, []byte(renamed), 0644); err != nil {
+			t.Fatal(err)
+		}
+
+		var content string
+		if !waitFor(30*time.Second, func() bool {
+			res := call(t, "go_definition", byHandle(greeting))
+			content = testutil.ResultText(t, res, "")
+			return res.IsError
+		}) {
+			t.Fatalf("Expected the handle of a renamed method to be stale, got:\n%s", content)
+		}
+		testutil.AssertStringContains(t, content, "stale symbol handle")
+
+		// The handle of the new name resolves.
+		res := call(t, "go_definition", byHandle("example.com/simple#Person.Greet"))
+		if res.IsError {
+			t.Fatalf("go_definition failed: %s", testutil.ResultText(t, res, ""))
+		}
+	})
+}
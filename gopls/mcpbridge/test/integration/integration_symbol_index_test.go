@@ -0,0 +1,123 @@
+package integration
+
+// End-to-end test for the go_search symbol index: incremental updates from
+// file watcher events, and persistence across restarts.
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+	"golang.org/x/tools/gopls/mcpbridge/test/testutil"
+)
+
+// searchSymbols calls go_search and returns its text output.
+func searchSymbols(t *testing.T, session *mcp.ClientSession, query string) string {
+	t.Helper()
+	res, err := session.CallTool(t.Context(), &mcp.CallToolParams{
+		Name:      "go_search",
+		Arguments: map[string]any{"query": query},
+	})
+	if err != nil {
+		t.Fatalf("Failed to call go_search: %v", err)
+	}
+	return testutil.ResultText(t, res, "")
+}
+
+// TestSymbolIndexUpdates verifies that go_search sees files created,
+// changed and deleted after the index was built.
+func TestSymbolIndexUpdates(t *testing.T) {
+	goplsMcpPath := testutil.BuildGoplsMcp(t)
+	projectDir := testutil.CopyProjectTo(t, "simple")
+
+	session, _, cleanup := testutil.StartMCPServerWithArgs(t, goplsMcpPath, "-workdir", projectDir)
+	defer cleanup()
+
+	// Build the index.
+	testutil.AssertStringContains(t, searchSymbols(t, session, "Hello"), "main.go")
+
+	extraGo := filepath.Join(projectDir, "extra.go")
+	write := func(t *testing.T, content string) {
+		t.Helper()
+		if err := os.WriteFile(extraGo, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	t.Run("Created", func(t *testing.T) {
+		write(t, "package main\n\nfunc IndexedLater() {}\n")
+		if !waitFor(30*time.Second, func() bool {
+			return strings.Contains(searchSymbols(t, session, "IndexedLater"), "extra.go")
+		}) {
+			t.Fatal("Expected go_search to find the symbol of a created file")
+		}
+	})
+
+	t.Run("Changed", func(t *testing.T) {
+		write(t, "package main\n\nfunc RenamedLater() {}\n")
+		if !waitFor(30*time.Second, func() bool {
+			return strings.Contains(searchSymbols(t, session, "RenamedLater"), "extra.go") &&
+				!strings.Contains(searchSymbols(t, session, "IndexedLater"), "extra.go")
+		}) {
+			t.Fatal("Expected go_search to reflect the changed file")
+		}
+	})
+
+	t.Run("Deleted", func(t *testing.T) {
+		if err := os.Remove(extraGo); err != nil {
+			t.Fatal(err)
+		}
+		if !waitFor(30*time.Second, func() bool {
+			return !strings.Contains(searchSymbols(t, session, "RenamedLater"), "extra.go")
+		}) {
+			t.Fatal("Expected go_search to forget the symbols of a deleted file")
+		}
+	})
+}
+
+// TestSymbolIndexPersistence verifies that with search_index.persist, a
+// restarted server loads the index from the file cache instead of parsing
+// the workspace again.
+func TestSymbolIndexPersistence(t *testing.T) {
+	goplsMcpPath := testutil.BuildGoplsMcp(t)
+	projectDir := testutil.CopyProjectTo(t, "simple")
+	dir := t.TempDir()
+	t.Setenv("GOPLSCACHE", filepath.Join(dir, "cache"))
+
+	// Modification times are only trusted once they are a few seconds old.
+	old := time.Now().Add(-time.Hour)
+	files, _ := filepath.Glob(filepath.Join(projectDir, "*.go"))
+	for _, file := range files {
+		if err := os.Chtimes(file, old, old); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	configPath := filepath.Join(dir, "config.json")
+	if err := os.WriteFile(configPath, []byte(`{"search_index": {"persist": true}}`), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	run := func(t *testing.T, logFile string) string {
+		t.Helper()
+		session, _, cleanup := testutil.StartMCPServerWithArgs(t, goplsMcpPath,
+			"-workdir", projectDir, "-config", configPath, "-logfile", logFile)
+		testutil.AssertStringContains(t, searchSymbols(t, session, "Hello"), "main.go")
+		cleanup() // the index is saved on shutdown
+		data, err := os.ReadFile(logFile)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return string(data)
+	}
+
+	firstLog := run(t, filepath.Join(dir, "first.log"))
+	testutil.AssertStringContains(t, firstLog, "Saved symbol index")
+
+	secondLog := run(t, filepath.Join(dir, "second.log"))
+	testutil.AssertStringContains(t, secondLog, "Loaded symbol index")
+	testutil.AssertStringContains(t, secondLog, " 0 to parse")
+}
@@ -0,0 +1,105 @@
+package integration
+
+// End-to-end test for fully-qualified symbol paths in locators.
+
+import (
+	"testing"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+	"golang.org/x/tools/gopls/mcpbridge/test/testutil"
+)
+
+// TestSymbolPath verifies that the locator tools accept the fully-qualified
+// path of a symbol of the workspace, a dependency or the standard library,
+// including a package that the workspace does not import, in place of
+// symbol_name and context_file.
+func TestSymbolPath(t *testing.T) {
+	goplsMcpPath := testutil.BuildGoplsMcp(t)
+	projectDir := testutil.CopyProjectTo(t, "simple")
+
+	session, ctx, cleanup := testutil.StartMCPServerWithArgs(t, goplsMcpPath, "-workdir", projectDir)
+	defer cleanup()
+
+	call := func(t *testing.T, tool, path string) *mcp.CallToolResult {
+		t.Helper()
+		args := map[string]any{"locator": map[string]any{"symbol_path": path}}
+		res, err := session.CallTool(ctx, &mcp.CallToolParams{Name: tool, Arguments: args})
+		if err != nil {
+			t.Fatalf("Failed to call %s: %v", tool, err)
+		}
+		return res
+	}
+
+	t.Run("Definition", func(t *testing.T) {
+		for _, test := range []struct {
+			path, file, handle string
+		}{
+			{"example.com/simple.Hello", "main.go", "example.com/simple#Hello"},
+			{"example.com/simple.(*Person).Greeting", "main.go", "example.com/simple#Person.Greeting"},
+			{"example.com/simple.Person.Greeting", "main.go", "example.com/simple#Person.Greeting"},
+			{"fmt.Sprintf", "print.go", "fmt#Sprintf"},
+			{"os.(*File).Write", "file.go", "os#File.Write"},
+			{"io.Writer.Write", "io.go", "io#Writer.Write"},
+			// Packages that no workspace package imports are loaded on demand.
+			{"encoding/csv.NewReader", "reader.go", "encoding/csv#NewReader"},
+			{"text/tabwriter.(*Writer).Flush", "tabwriter.go", "text/tabwriter#Writer.Flush"},
+		} {
+			res := call(t, "go_definition", test.path)
+			content := testutil.ResultText(t, res, "")
+			if res.IsError {
+				t.Errorf("go_definition of %s failed: %s", test.path, content)
+				continue
+			}
+			testutil.AssertStringContains(t, content, test.file)
+			testutil.AssertStringContains(t, content, "**Handle**: `"+test.handle+"`")
+		}
+	})
+
+	t.Run("References", func(t *testing.T) {
+		res := call(t, "go_symbol_references", "example.com/simple.Person.Name")
+		if res.IsError {
+			t.Fatalf("go_symbol_references failed: %s", testutil.ResultText(t, res, ""))
+		}
+		testutil.AssertStringContains(t, testutil.ResultText(t, res, ""), "main.go")
+
+		// References to a standard library symbol from the workspace.
+		res = call(t, "go_symbol_references", "fmt.Println")
+		if res.IsError {
+			t.Fatalf("go_symbol_references failed: %s", testutil.ResultText(t, res, ""))
+		}
+		testutil.AssertStringContains(t, testutil.ResultText(t, res, ""), "main.go")
+	})
+
+	t.Run("HandleOfUnloadedPackage", func(t *testing.T) {
+		args := map[string]any{"locator": map[string]any{"handle": "container/ring#Ring.Len"}}
+		res, err := session.CallTool(ctx, &mcp.CallToolParams{Name: "go_definition", Arguments: args})
+		if err != nil {
+			t.Fatalf("Failed to call go_definition: %v", err)
+		}
+		content := testutil.ResultText(t, res, "")
+		if res.IsError {
+			t.Fatalf("go_definition of a handle of an unloaded package failed: %s", content)
+		}
+		testutil.AssertStringContains(t, content, "ring.go")
+	})
+
+	t.Run("NotFound", func(t *testing.T) {
+		for _, test := range []struct {
+			path, want string
+		}{
+			{"fmt.NoSuchFunc", "package fmt has no NoSuchFunc"},
+			{"example.com/simple.Person.Nope", "package example.com/simple has no Person.Nope"},
+			{"example.com/nosuchpkg.Foo", "neither in the workspace nor one of its dependencies"},
+			{"Sprintf", "invalid symbol path"},
+			{"fmt.(*pp.doPrintf", "invalid symbol path"},
+		} {
+			res := call(t, "go_definition", test.path)
+			content := testutil.ResultText(t, res, "")
+			if !res.IsError {
+				t.Errorf("Expected an error for %s, got:\n%s", test.path, content)
+				continue
+			}
+			testutil.AssertStringContains(t, content, test.want)
+		}
+	})
+}
@@ -0,0 +1,133 @@
+package integration
+
+// End-to-end test for go_sync_document functionality.
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+	"golang.org/x/tools/gopls/mcpbridge/test/testutil"
+)
+
+// TestGoSyncDocumentE2E verifies that client-pushed buffers are seen by other
+// tools until the document is closed again.
+func TestGoSyncDocumentE2E(t *testing.T) {
+	projectDir := t.TempDir()
+
+	goModContent := `module example.com/syncdoc
+
+go 1.21
+`
+	if err := os.WriteFile(filepath.Join(projectDir, "go.mod"), []byte(goModContent), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	mainPath := filepath.Join(projectDir, "main.go")
+	mainCode := `package main
+
+func main() {
+	println("saved")
+}
+`
+	if err := os.WriteFile(mainPath, []byte(mainCode), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	bufferCode := `package main
+
+func main() {
+	var unsaved int = "buffer"
+	_ = unsaved
+}
+`
+
+	callTool := func(t *testing.T, tool string, args map[string]any) *mcp.CallToolResult {
+		t.Helper()
+		res, err := globalSession.CallTool(globalCtx, &mcp.CallToolParams{Name: tool, Arguments: args})
+		if err != nil {
+			t.Fatalf("Failed to call tool %s: %v", tool, err)
+		}
+		if res.IsError {
+			t.Fatalf("Tool %s returned an error: %s", tool, testutil.ResultText(t, res, ""))
+		}
+		return res
+	}
+
+	t.Run("OpenDocumentIsSeenByTools", func(t *testing.T) {
+		res := callTool(t, "go_sync_document", map[string]any{
+			"Cwd":     projectDir,
+			"path":    mainPath,
+			"action":  "open",
+			"content": bufferCode,
+		})
+		content := testutil.ResultText(t, res, testutil.GoldenSyncDocumentOpen)
+		t.Logf("Sync result:\n%s", content)
+		if !strings.Contains(content, "version 1") {
+			t.Errorf("Expected first version to be 1, got: %s", content)
+		}
+
+		// go_read_file returns the buffer content.
+		res = callTool(t, "go_read_file", map[string]any{"file": mainPath})
+		if content := testutil.ResultText(t, res, ""); !strings.Contains(content, `"buffer"`) {
+			t.Errorf("Expected go_read_file to return the pushed buffer, got: %s", content)
+		}
+
+		// go_build_check reports the error that only exists in the buffer.
+		res = callTool(t, "go_build_check", map[string]any{"Cwd": projectDir})
+		if content := testutil.ResultText(t, res, ""); !strings.Contains(content, "cannot use") {
+			t.Errorf("Expected diagnostics for the pushed buffer, got: %s", content)
+		}
+	})
+
+	t.Run("ChangeIncrementsVersion", func(t *testing.T) {
+		res := callTool(t, "go_sync_document", map[string]any{
+			"Cwd":     projectDir,
+			"path":    mainPath,
+			"action":  "change",
+			"content": mainCode,
+		})
+		if content := testutil.ResultText(t, res, ""); !strings.Contains(content, "version 2") {
+			t.Errorf("Expected version 2 after change, got: %s", content)
+		}
+	})
+
+	t.Run("CloseFallsBackToDisk", func(t *testing.T) {
+		// Make the buffer differ from disk again, then close it.
+		callTool(t, "go_sync_document", map[string]any{
+			"Cwd":     projectDir,
+			"path":    mainPath,
+			"action":  "change",
+			"content": bufferCode,
+		})
+		res := callTool(t, "go_sync_document", map[string]any{
+			"Cwd":    projectDir,
+			"path":   mainPath,
+			"action": "close",
+		})
+		content := testutil.ResultText(t, res, testutil.GoldenSyncDocumentClose)
+		if !strings.Contains(content, "Open documents: 0") {
+			t.Errorf("Expected no open documents after close, got: %s", content)
+		}
+
+		res = callTool(t, "go_build_check", map[string]any{"Cwd": projectDir})
+		if content := testutil.ResultText(t, res, ""); !strings.Contains(content, "No issues found") {
+			t.Errorf("Expected clean diagnostics from disk after close, got: %s", content)
+		}
+	})
+
+	t.Run("InvalidAction", func(t *testing.T) {
+		res, err := globalSession.CallTool(globalCtx, &mcp.CallToolParams{Name: "go_sync_document", Arguments: map[string]any{
+			"path":   mainPath,
+			"action": "save",
+		}})
+		if err != nil {
+			t.Fatalf("Failed to call tool go_sync_document: %v", err)
+		}
+		if !res.IsError {
+			t.Errorf("Expected an error for an invalid action")
+		}
+	})
+}
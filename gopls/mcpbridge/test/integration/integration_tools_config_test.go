@@ -0,0 +1,135 @@
+package integration
+
+// End-to-end test for tool selection, read-only mode and per-tool
+// configuration.
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+	"golang.org/x/tools/gopls/mcpbridge/test/testutil"
+)
+
+// TestToolsConfig verifies that the tools config selects the served tools
+// and applies per-tool response limits and default arguments.
+func TestToolsConfig(t *testing.T) {
+	goplsMcpPath := testutil.BuildGoplsMcp(t)
+	projectDir := testutil.CopyProjectTo(t, "simple")
+
+	configPath := filepath.Join(t.TempDir(), "config.json")
+	config := `{
+  "tools": {
+    "disabled": ["refactoring", "go_get_started"],
+    "per_tool": {
+      "go_read_file": {"max_response_bytes": 100},
+      "go_search": {"defaults": {"max_results": 1}}
+    }
+  }
+}`
+	if err := os.WriteFile(configPath, []byte(config), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	session, ctx, cleanup := testutil.StartMCPServerWithArgs(t, goplsMcpPath, "-workdir", projectDir, "-config", configPath)
+	defer cleanup()
+
+	t.Run("Disabled", func(t *testing.T) {
+		tools := listToolNames(t, session)
+		for _, name := range []string{"go_dryrun_rename_symbol", "go_get_started"} {
+			if tools[name] {
+				t.Errorf("Disabled tool %s is served", name)
+			}
+		}
+		for _, name := range []string{"go_search", "go_read_file", "go_sync_document"} {
+			if !tools[name] {
+				t.Errorf("Tool %s is not served", name)
+			}
+		}
+
+		res, err := session.CallTool(ctx, &mcp.CallToolParams{Name: "go_list_tools", Arguments: map[string]any{}})
+		if err != nil {
+			t.Fatalf("Failed to call go_list_tools: %v", err)
+		}
+		content := testutil.ResultText(t, res, "")
+		testutil.AssertStringNotContains(t, content, "go_dryrun_rename_symbol")
+		testutil.AssertStringNotContains(t, content, "go_get_started")
+	})
+
+	t.Run("PerToolMaxResponseBytes", func(t *testing.T) {
+		res, err := session.CallTool(ctx, &mcp.CallToolParams{
+			Name:      "go_read_file",
+			Arguments: map[string]any{"file": filepath.Join(projectDir, "main.go")},
+		})
+		if err != nil {
+			t.Fatalf("Failed to call go_read_file: %v", err)
+		}
+		content := testutil.ResultText(t, res, "")
+		if len(content) > 110 {
+			t.Errorf("Expected the response to be limited to about 100 bytes, got %d:\n%s", len(content), content)
+		}
+	})
+
+	t.Run("Defaults", func(t *testing.T) {
+		search := func(args map[string]any) string {
+			res, err := session.CallTool(ctx, &mcp.CallToolParams{Name: "go_search", Arguments: args})
+			if err != nil {
+				t.Fatalf("Failed to call go_search: %v", err)
+			}
+			return testutil.ResultText(t, res, "")
+		}
+
+		// The default max_results limits the results...
+		content := search(map[string]any{"query": "e"})
+		if n := strings.Count(content, "main.go"); n != 1 {
+			t.Errorf("Expected 1 result with the default max_results, got %d:\n%s", n, content)
+		}
+		// ...unless the client sets it.
+		content = search(map[string]any{"query": "e", "max_results": 10})
+		if n := strings.Count(content, "main.go"); n < 2 {
+			t.Errorf("Expected several results with max_results set, got %d:\n%s", n, content)
+		}
+	})
+}
+
+// TestReadOnlyMode verifies that -read-only hides the tools that are not
+// read-only.
+func TestReadOnlyMode(t *testing.T) {
+	goplsMcpPath := testutil.BuildGoplsMcp(t)
+	projectDir := testutil.CopyProjectTo(t, "simple")
+
+	session, ctx, cleanup := testutil.StartMCPServerWithArgs(t, goplsMcpPath, "-workdir", projectDir, "-read-only")
+	defer cleanup()
+
+	tools := listToolNames(t, session)
+	if tools["go_sync_document"] {
+		t.Error("go_sync_document is served in read-only mode")
+	}
+	if !tools["go_search"] {
+		t.Error("go_search is not served in read-only mode")
+	}
+
+	for tool, err := range session.Tools(ctx, nil) {
+		if err != nil {
+			t.Fatal(err)
+		}
+		if tool.Annotations == nil || !tool.Annotations.ReadOnlyHint {
+			t.Errorf("Tool %s is not annotated as read-only", tool.Name)
+		}
+	}
+}
+
+// listToolNames returns the names of the tools served by the session.
+func listToolNames(t *testing.T, session *mcp.ClientSession) map[string]bool {
+	t.Helper()
+	names := make(map[string]bool)
+	for tool, err := range session.Tools(t.Context(), nil) {
+		if err != nil {
+			t.Fatalf("Failed to list tools: %v", err)
+		}
+		names[tool.Name] = true
+	}
+	return names
+}
@@ -95,6 +95,17 @@ const (
 	GoldenDiagnosticsDeduplication  = "go_build_check_deduplication.golden"
 	GoldenDiagnosticsTests          = "go_build_check_test_files_e2e.golden"
 
+	// Speculative Edit Tool (go_check_edit)
+	GoldenCheckEditTypeError = "go_check_edit_type_error.golden"
+	GoldenCheckEditNewFile   = "go_check_edit_new_file.golden"
+
+	// Document Sync Tool (go_sync_document)
+	GoldenSyncDocumentOpen  = "go_sync_document_open.golden"
+	GoldenSyncDocumentClose = "go_sync_document_close.golden"
+
+	// Server Status Tool (go_server_status)
+	GoldenServerStatus = "go_server_status.golden"
+
 	// Read File Tool (go_read_file)
 	GoldenReadFile                  = "go_read_file_e2e.golden"
 	GoldenReadFileExisting          = "go_read_file_existing.golden"
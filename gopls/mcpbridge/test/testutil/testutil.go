@@ -113,6 +113,48 @@ func StartMCPServer(t *testing.T, workdir string) (*mcp.ClientSession, context.C
 	return mcpSession, ctx, cleanup
 }
 
+// BuildGoplsMcp builds the gopls-mcp binary into a temporary directory and
+// returns its path. The test is skipped if the build fails.
+func BuildGoplsMcp(t *testing.T) string {
+	t.Helper()
+	testenv.NeedsExec(t)
+
+	goplsMcpPath := filepath.Join(t.TempDir(), "gopls-mcp")
+
+	// Navigate from test/testutil to project root (where main.go is located)
+	projectRoot, err := filepath.Abs("../../../..")
+	if err != nil {
+		t.Fatalf("Failed to get project root: %v", err)
+	}
+	buildCmd := exec.Command("go", "build", "-o", goplsMcpPath, ".")
+	buildCmd.Dir = projectRoot
+	if output, err := buildCmd.CombinedOutput(); err != nil {
+		t.Skipf("Skipping test: failed to build gopls-mcp: %v\n%s", err, output)
+	}
+	return goplsMcpPath
+}
+
+// StartMCPServerWithArgs starts the given gopls-mcp binary with arbitrary
+// command-line arguments and connects an MCP client to it over stdio.
+func StartMCPServerWithArgs(t *testing.T, goplsMcpPath string, args ...string) (*mcp.ClientSession, context.Context, func()) {
+	t.Helper()
+
+	goplsMcpCmd := exec.Command(goplsMcpPath, args...)
+	ctx := t.Context()
+	client := mcp.NewClient(&mcp.Implementation{Name: "test-client", Version: "v0.0.1"}, nil)
+	mcpSession, err := client.Connect(ctx, &mcp.CommandTransport{Command: goplsMcpCmd}, nil)
+	if err != nil {
+		t.Fatalf("Failed to connect to gopls-mcp: %v", err)
+	}
+
+	cleanup := func() {
+		if err := mcpSession.Close(); err != nil {
+			t.Logf("MCP connection closed with error (expected): %v", err)
+		}
+	}
+	return mcpSession, ctx, cleanup
+}
+
 // StartSharedMCPServer builds and starts a gopls-mcp server with DYNAMIC VIEWS enabled.
 // TEST-ONLY: This enables the GOPMCS_ALLOW_DYNAMIC_VIEWS environment variable, which allows
 // one gopls-mcp process to create views for multiple test directories on-demand.
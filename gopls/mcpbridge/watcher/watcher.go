@@ -7,8 +7,10 @@ import (
 	"context"
 	"fmt"
 	"log"
+	"path/filepath"
 	"runtime"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"golang.org/x/tools/gopls/internal/filewatcher"
@@ -27,6 +29,18 @@ type Watcher struct {
 	eventQueue []protocol.FileEvent
 	eventMu    sync.Mutex
 	eventReady chan struct{}
+
+	// pending counts the file events queued but not yet delivered to the
+	// LSP server.
+	pending atomic.Int64
+
+	// fileHandlers are the handlers registered by HandleFile, by path.
+	fileHandlers   map[string]func(protocol.FileEvent)
+	fileHandlersMu sync.Mutex
+
+	// subscribers are the functions registered by Subscribe.
+	subscribers   []func([]protocol.FileEvent)
+	subscribersMu sync.Mutex
 }
 
 type ChangeWatchedFiles interface {
@@ -71,6 +85,9 @@ func New(server ChangeWatchedFiles, dir string, opts ...filewatcher.Option) (*Wa
 
 				if len(events) > 0 {
 					w.notifyServer(events)
+					w.notifySubscribers(events)
+					w.handleFiles(events)
+					w.pending.Add(-int64(len(events)))
 				}
 			case <-w.stopCh:
 				return
@@ -110,13 +127,14 @@ func New(server ChangeWatchedFiles, dir string, opts ...filewatcher.Option) (*Wa
 		// Queue the events for processing
 		queueMu.Lock()
 		queue = append(queue, events...)
+		w.pending.Add(int64(len(events)))
 		queueMu.Unlock()
 
 		select {
 		case nonempty <- struct{}{}:
 		default:
 		}
-	}, errHandler, opts...)
+	}, errHandler, append(opts, filewatcher.WithWatchFile(w.watchesFile))...)
 
 	if err != nil {
 		return nil, fmt.Errorf("failed to create file watcher: %w", err)
@@ -134,6 +152,64 @@ func New(server ChangeWatchedFiles, dir string, opts ...filewatcher.Option) (*Wa
 	return w, nil
 }
 
+// HandleFile registers fn to be called with the change events of the file
+// at path, which must be in the watched directory tree but may have any
+// extension (e.g. a configuration file). fn observes the events: they are
+// still delivered to the LSP server and the subscribers first, so a file
+// such as go.mod may be both a configuration file and a file of gopls. fn
+// is called from the event processing goroutine, so file events are not
+// delivered while it runs.
+func (w *Watcher) HandleFile(path string, fn func(protocol.FileEvent)) {
+	w.fileHandlersMu.Lock()
+	defer w.fileHandlersMu.Unlock()
+	if w.fileHandlers == nil {
+		w.fileHandlers = make(map[string]func(protocol.FileEvent))
+	}
+	w.fileHandlers[filepath.Clean(path)] = fn
+}
+
+// watchesFile reports whether a handler is registered for the file at path.
+func (w *Watcher) watchesFile(path string) bool {
+	w.fileHandlersMu.Lock()
+	defer w.fileHandlersMu.Unlock()
+	_, ok := w.fileHandlers[filepath.Clean(path)]
+	return ok
+}
+
+// handleFiles passes the events of files registered by HandleFile to their
+// handlers.
+func (w *Watcher) handleFiles(events []protocol.FileEvent) {
+	for _, e := range events {
+		w.fileHandlersMu.Lock()
+		fn := w.fileHandlers[filepath.Clean(e.URI.Path())]
+		w.fileHandlersMu.Unlock()
+		if fn != nil {
+			log.Printf("[gopls-mcp/watcher] Handling %s %s", e.Type, e.URI.Path())
+			fn(e)
+		}
+	}
+}
+
+// Subscribe registers fn to be called with each batch of file events, after
+// it was delivered to the LSP server. fn is called from the event processing
+// goroutine, so it should be fast.
+func (w *Watcher) Subscribe(fn func([]protocol.FileEvent)) {
+	w.subscribersMu.Lock()
+	defer w.subscribersMu.Unlock()
+	w.subscribers = append(w.subscribers, fn)
+}
+
+// notifySubscribers passes file events to the functions registered by
+// Subscribe.
+func (w *Watcher) notifySubscribers(events []protocol.FileEvent) {
+	w.subscribersMu.Lock()
+	subscribers := w.subscribers
+	w.subscribersMu.Unlock()
+	for _, fn := range subscribers {
+		fn(events)
+	}
+}
+
 // notifyServer sends file change notifications to the LSP server.
 // This implements the file change notification flow that gopls expects.
 func (w *Watcher) notifyServer(events []protocol.FileEvent) {
@@ -163,6 +239,12 @@ func (w *Watcher) Close() error {
 	return w.fw.Close()
 }
 
+// Pending returns the number of file events that were detected but not yet
+// delivered to the LSP server.
+func (w *Watcher) Pending() int {
+	return int(w.pending.Load())
+}
+
 // Dir returns the directory being watched.
 func (w *Watcher) Dir() string {
 	return w.dir
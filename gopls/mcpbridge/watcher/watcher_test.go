@@ -323,3 +323,43 @@ func Goodbye() string {
 		}
 	}
 }
+
+// TestWatcherHandleFile tests that the events of a file registered with
+// HandleFile go to its handler, and still to the server.
+func TestWatcherHandleFile(t *testing.T) {
+	testenv.NeedsExec(t)
+
+	tmpDir := t.TempDir()
+	configFile := filepath.Join(tmpDir, "config.json")
+
+	mock := &mockServer{}
+	w, err := New(mock, tmpDir)
+	if err != nil {
+		t.Fatalf("Failed to create watcher: %v", err)
+	}
+	defer w.Close()
+
+	handled := make(chan protocol.FileEvent, 10)
+	w.HandleFile(configFile, func(e protocol.FileEvent) {
+		handled <- e
+	})
+
+	// Give the watcher time to start watching
+	time.Sleep(100 * time.Millisecond)
+
+	if err := os.WriteFile(configFile, []byte("{}"), 0644); err != nil {
+		t.Fatalf("Failed to write config.json: %v", err)
+	}
+
+	select {
+	case e := <-handled:
+		if e.URI.Path() != configFile {
+			t.Errorf("Handler called for %s, want %s", e.URI.Path(), configFile)
+		}
+	case <-time.After(10 * time.Second):
+		t.Fatal("Timed out waiting for the config.json event")
+	}
+	if n := mock.GetFileChangeCount(); n == 0 {
+		t.Error("Expected the server to be notified of the handled file too")
+	}
+}